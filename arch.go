@@ -0,0 +1,142 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CPU architectures reported by DetectArch, named like Go's GOARCH values.
+const (
+	ArchAMD64   = "amd64"
+	ArchARM64   = "arm64"
+	Arch386     = "386"
+	ArchARM     = "arm"
+	ArchPPC64LE = "ppc64le"
+	ArchS390X   = "s390x"
+)
+
+// ErrUnknownArch is returned when the architecture of a container cannot be determined.
+var ErrUnknownArch = errors.New("unknown container architecture")
+
+// ErrNoMatchingArch is returned when a MultiArchBinary has no variant for the container's architecture.
+var ErrNoMatchingArch = errors.New("no binary for the container's architecture")
+
+// unameArchs maps machine names reported by 'uname -m' to architectures.
+var unameArchs = map[string]string{
+	"x86_64":  ArchAMD64,
+	"amd64":   ArchAMD64,
+	"aarch64": ArchARM64,
+	"arm64":   ArchARM64,
+	"i386":    Arch386,
+	"i686":    Arch386,
+	"armv6l":  ArchARM,
+	"armv7l":  ArchARM,
+	"ppc64le": ArchPPC64LE,
+	"s390x":   ArchS390X,
+}
+
+// elfArchs maps ELF e_machine values to architectures.
+var elfArchs = map[uint16]string{
+	0x03: Arch386,
+	0x28: ArchARM,
+	0x3e: ArchAMD64,
+	0xb7: ArchARM64,
+	0x15: ArchPPC64LE,
+	0x16: ArchS390X,
+}
+
+// elfProbeScript prints the ELF header of a binary running in the container, for containers without uname.
+const elfProbeScript = `head -c 20 /proc/self/exe 2>/dev/null || dd if=/proc/self/exe bs=20 count=1 2>/dev/null`
+
+// DetectArch determines the CPU architecture of a container, identified by the container's name and
+// the associated pod's name, and returns it as a GOARCH value, e.g. ArchAMD64 or ArchARM64. It runs 'uname -m'
+// and falls back to reading the ELF header of a binary running in the container when uname is not available.
+// Clusters mixing amd64 and arm64 nodes need this to pick matching binaries for upload.
+func (k8s *K8SExec) DetectArch(ctx context.Context, podName string, containerName string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	if _, err := k8s.exec(ctx, podName, containerName, []string{"uname", "-m"}, nil, &stdout, &stderr, false); err == nil {
+		machine := strings.TrimSpace(stdout.String())
+		if arch, ok := unameArchs[machine]; ok {
+			return arch, nil
+		}
+		return "", fmt.Errorf("%w: machine %q in %s/%s", ErrUnknownArch, machine, podName, containerName)
+	}
+
+	stdout.Reset()
+	if _, err := k8s.exec(ctx, podName, containerName, []string{"sh", "-c", elfProbeScript}, nil, &stdout, &stderr, false); err != nil {
+		return "", fmt.Errorf("%w: probing %s/%s: %w", ErrUnknownArch, podName, containerName, err)
+	}
+	return elfArch(stdout.Bytes())
+}
+
+// elfArch returns the architecture of an ELF binary given its header.
+func elfArch(header []byte) (string, error) {
+	if len(header) < 20 || !bytes.HasPrefix(header, []byte("\x7fELF")) {
+		return "", fmt.Errorf("%w: not an ELF header", ErrUnknownArch)
+	}
+	var byteOrder binary.ByteOrder = binary.LittleEndian
+	if header[5] == 2 {
+		byteOrder = binary.BigEndian
+	}
+	machine := byteOrder.Uint16(header[18:20])
+	arch, ok := elfArchs[machine]
+	if !ok {
+		return "", fmt.Errorf("%w: ELF machine 0x%x", ErrUnknownArch, machine)
+	}
+	if arch == ArchPPC64LE && header[5] == 2 {
+		return "", fmt.Errorf("%w: big endian ppc64", ErrUnknownArch)
+	}
+	return arch, nil
+}
+
+// MultiArchBinary holds variants of a binary built for different architectures, keyed by GOARCH values
+// such as ArchAMD64 and ArchARM64.
+type MultiArchBinary map[string][]byte
+
+// Select returns the variant of the binary matching 'arch', or an error wrapping ErrNoMatchingArch.
+func (binaries MultiArchBinary) Select(arch string) ([]byte, error) {
+	variant, ok := binaries[arch]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoMatchingArch, arch)
+	}
+	return variant, nil
+}
+
+// uploadBinaryScript stores standard input in the file given as the first parameter and makes it executable.
+const uploadBinaryScript = `cat > "$1" && chmod 700 "$1"`
+
+// ExecBinary detects the architecture of a container, uploads the matching variant of 'binaries' to a temporary
+// file in the container, executes it with 'args' and removes it afterwards. It requires a shell and a writable
+// /tmp in the container. The returned ExecutionStatus is the status of the uploaded binary's execution; errors
+// are returned when the architecture cannot be detected, no variant matches it or the upload fails.
+func (k8s *K8SExec) ExecBinary(ctx context.Context, podName string, containerName string, binaries MultiArchBinary, args []string) (*ExecutionStatus, error) {
+	arch, err := k8s.DetectArch(ctx, podName, containerName)
+	if err != nil {
+		return nil, err
+	}
+	variant, err := binaries.Select(arch)
+	if err != nil {
+		return nil, err
+	}
+
+	remotePath := tempPath("")
+
+	k8s.addArtifact(podName, containerName, remotePath)
+	upload := k8s.ExecWithPayload(ctx, podName, containerName, []string{"sh", "-c", uploadBinaryScript, "sh", remotePath}, variant)
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), k8s.fileOpTimeout())
+		defer cancel()
+		if status := k8s.ExecWithContext(cleanupCtx, podName, containerName, []string{"rm", "-f", remotePath}, nil); status.RetCode == Success {
+			k8s.forgetArtifacts(podName, containerName, remotePath)
+		}
+	}()
+	if upload.RetCode != Success {
+		return nil, fmt.Errorf("uploading binary to %s/%s:%s: %s", podName, containerName, remotePath, strings.Join(upload.Error, " "))
+	}
+
+	return k8s.ExecWithContext(ctx, podName, containerName, append([]string{remotePath}, args...), nil), nil
+}
@@ -0,0 +1,89 @@
+package k8sexec
+
+import (
+	"errors"
+	"io"
+	"os"
+	"runtime"
+	"testing"
+)
+
+// elfHeader returns the first 20 bytes of an ELF header with the given data encoding (1 for little endian,
+// 2 for big endian) and machine, encoded in that byte order.
+func elfHeader(encoding byte, machine uint16) []byte {
+	header := []byte{0x7f, 'E', 'L', 'F', 2, encoding, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2, 0, 0, 0}
+	if encoding == 2 {
+		header[16], header[17] = 0, 2
+		header[18], header[19] = byte(machine>>8), byte(machine)
+	} else {
+		header[18], header[19] = byte(machine), byte(machine>>8)
+	}
+	return header
+}
+
+func TestElfArch(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []byte
+		want   string
+		err    bool
+	}{
+		{name: "x86-64", header: elfHeader(1, 0x3e), want: ArchAMD64},
+		{name: "aarch64", header: elfHeader(1, 0xb7), want: ArchARM64},
+		{name: "i386", header: elfHeader(1, 0x03), want: Arch386},
+		{name: "arm", header: elfHeader(1, 0x28), want: ArchARM},
+		{name: "ppc64le", header: elfHeader(1, 0x15), want: ArchPPC64LE},
+		{name: "s390x", header: elfHeader(2, 0x16), want: ArchS390X},
+		{name: "big endian ppc64", header: elfHeader(2, 0x15), err: true},
+		{name: "unknown machine", header: elfHeader(1, 0xf3), err: true},
+		{name: "longer input", header: append(elfHeader(1, 0x3e), make([]byte, 44)...), want: ArchAMD64},
+		{name: "truncated header", header: elfHeader(1, 0x3e)[:19], err: true},
+		{name: "empty output", header: nil, err: true},
+		{name: "not an ELF binary", header: []byte("#!/bin/sh\necho hello world\n"), err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			arch, err := elfArch(test.header)
+			if test.err {
+				if !errors.Is(err, ErrUnknownArch) {
+					t.Errorf("elfArch = %q, %v, want an error wrapping ErrUnknownArch", arch, err)
+				}
+				return
+			}
+			if err != nil || arch != test.want {
+				t.Errorf("elfArch = %q, %v, want %q", arch, err, test.want)
+			}
+		})
+	}
+}
+
+// TestElfArchOfTestBinary checks elfArch against the header of the running test binary.
+func TestElfArchOfTestBinary(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("test binaries are ELF binaries on Linux only")
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(executable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	header := make([]byte, 20)
+	if _, err := io.ReadFull(f, header); err != nil {
+		t.Fatal(err)
+	}
+
+	switch runtime.GOARCH {
+	case ArchAMD64, ArchARM64, Arch386, ArchARM, ArchPPC64LE, ArchS390X:
+	default:
+		t.Skipf("architecture %s is not detected", runtime.GOARCH)
+	}
+	arch, err := elfArch(header)
+	if err != nil || arch != runtime.GOARCH {
+		t.Errorf("elfArch = %q, %v, want %q", arch, err, runtime.GOARCH)
+	}
+}
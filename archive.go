@@ -0,0 +1,31 @@
+package k8sexec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+)
+
+// ArchiveDir writes a gzip-compressed tar archive of the directory 'remoteDir' in a container, identified by
+// the container's name and the associated pod's name, to 'w', e.g. to collect whole configuration trees such as
+// /etc as audit evidence. Entries are named relative to the parent of 'remoteDir', so archiving /etc yields
+// entries like "etc/passwd". The archive is streamed from the container and compressed on the fly, without being
+// buffered in memory. Files which cannot be read in the container, e.g. because of missing permissions, make
+// 'tar' fail; the archive written to 'w' then lacks them and an error describing the failure is returned.
+// The download is governed by the provided context.
+func (k8s *K8SExec) ArchiveDir(ctx context.Context, podName string, containerName string, remoteDir string, w io.Writer) error {
+	remoteDir = path.Clean(remoteDir)
+	compressor := gzip.NewWriter(w)
+	compressor.Name = path.Base(remoteDir) + ".tar"
+
+	var stderr bytes.Buffer
+	cmd := []string{"sh", "-c", tarScript, "sh", path.Dir(remoteDir), path.Base(remoteDir)}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, compressor, &stderr)
+	if closeErr := compressor.Close(); closeErr != nil && err == nil {
+		return fmt.Errorf("archiving %s/%s:%s: %w", podName, containerName, remoteDir, closeErr)
+	}
+	return copyError("archiving", podName, containerName, remoteDir, retCode, err, &stderr)
+}
@@ -0,0 +1,166 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Artifact is a file or directory the package created in a container, e.g. a file written with WriteFile,
+// a toolbox or a workspace, recorded so it can be removed with ShredArtifacts.
+type Artifact struct {
+	Namespace string `json:"namespace"`
+	Pod       string `json:"pod"`
+	Container string `json:"container"`
+	Path      string `json:"path"`
+}
+
+// artifactRegistry is the manifest of the artifacts created by an instance and the instances derived from it,
+// in the order of their creation.
+type artifactRegistry struct {
+	mu        sync.Mutex
+	artifacts []Artifact
+}
+
+// addArtifact records a path created in a container. Paths already recorded are not duplicated.
+func (k8s *K8SExec) addArtifact(podName string, containerName string, path string) {
+	if k8s.artifacts == nil {
+		return
+	}
+	artifact := Artifact{Namespace: k8s.Namespace, Pod: podName, Container: containerName, Path: path}
+	k8s.artifacts.mu.Lock()
+	defer k8s.artifacts.mu.Unlock()
+	if !slices.Contains(k8s.artifacts.artifacts, artifact) {
+		k8s.artifacts.artifacts = append(k8s.artifacts.artifacts, artifact)
+	}
+}
+
+// forgetArtifacts removes paths which no longer exist in a container from the manifest, along with the paths
+// below them.
+func (k8s *K8SExec) forgetArtifacts(podName string, containerName string, paths ...string) {
+	if k8s.artifacts == nil {
+		return
+	}
+	k8s.artifacts.mu.Lock()
+	defer k8s.artifacts.mu.Unlock()
+	k8s.artifacts.artifacts = slices.DeleteFunc(k8s.artifacts.artifacts, func(artifact Artifact) bool {
+		if artifact.Namespace != k8s.Namespace || artifact.Pod != podName || artifact.Container != containerName {
+			return false
+		}
+		return slices.ContainsFunc(paths, func(path string) bool {
+			return artifact.Path == path || strings.HasPrefix(artifact.Path, strings.TrimSuffix(path, "/")+"/")
+		})
+	})
+}
+
+// Artifacts returns the files and directories created in containers by the instance and the instances derived
+// from it, e.g. with WithNamespace, which have not been removed yet: files written with WriteFile to Linux
+// containers, including the binaries of toolboxes and the files uploaded to workspaces, the applet directories
+// of toolboxes, workspaces and the binaries uploaded by ExecBinary while they run. They are listed in the order
+// of their creation.
+func (k8s *K8SExec) Artifacts() []Artifact {
+	if k8s.artifacts == nil {
+		return nil
+	}
+	k8s.artifacts.mu.Lock()
+	defer k8s.artifacts.mu.Unlock()
+	return slices.Clone(k8s.artifacts.artifacts)
+}
+
+// shredScript overwrites and removes the paths given as the parameters and prints every path which no longer
+// exists, NUL-terminated. Regular files, including those below directories, are overwritten with 'shred', or
+// with zeroes written by 'dd' in containers lacking it, before they are removed; symbolic links are removed
+// without touching their targets. Files which cannot be overwritten are left in place and reported on standard
+// error, with exit code 1.
+const shredScript = `command -v shred >/dev/null 2>&1 || command -v dd >/dev/null 2>&1 || { echo "no shred or dd available" >&2; exit 127; }
+wipe() {
+	chmod u+w "$1" 2>/dev/null
+	if command -v shred >/dev/null 2>&1; then
+		shred -n 1 -z -- "$1"
+	else
+		n=$(wc -c < "$1") || return
+		[ "$n" -eq 0 ] || dd if=/dev/zero of="$1" bs=4096 count=$(( (n + 4095) / 4096 )) conv=notrunc 2>/dev/null
+	fi || { echo "cannot overwrite $1" >&2; return 1; }
+	sync 2>/dev/null || :
+}
+walk() {
+	for f in "$1"/* "$1"/.[!.]* "$1"/..?*; do
+		if [ -L "$f" ]; then :
+		elif [ -d "$f" ]; then walk "$f"
+		elif [ -f "$f" ]; then wipe "$f" || w=1
+		fi
+	done
+}
+s=0
+for p; do
+	w=0
+	if [ -L "$p" ]; then :
+	elif [ -d "$p" ]; then walk "$p"
+	elif [ -f "$p" ]; then wipe "$p" || w=1
+	fi
+	[ "$w" = 0 ] && rm -rf -- "$p" || s=1
+	[ -e "$p" ] || [ -L "$p" ] || printf '%s\0' "$p"
+done
+exit $s`
+
+// ShredArtifacts overwrites and removes the artifacts left in containers by the instance and the instances
+// derived from it, see Artifacts, so no uploaded scripts, binaries or data remain after a session, as audit
+// requirements may demand. Regular files are overwritten with 'shred', or with 'dd' in containers lacking it,
+// before they are removed, which requires 'sh' in the containers. Artifacts are forgotten once they are
+// removed, as are artifacts of pods which no longer exist, so failed removals can be retried by calling
+// ShredArtifacts again. The returned error joins the failures of all containers; containers lacking both
+// 'shred' and 'dd' are reported with errors wrapping ErrUtilNotFound, and their artifacts are left in place.
+// Overwriting is best effort on copy-on-write and journaling file systems, which may keep copies of the
+// original blocks. The removal is governed by the provided context.
+func (k8s *K8SExec) ShredArtifacts(ctx context.Context) error {
+	type containerRef struct{ namespace, pod, container string }
+	var containers []containerRef
+	paths := make(map[containerRef][]string)
+	for _, artifact := range k8s.Artifacts() {
+		ref := containerRef{artifact.Namespace, artifact.Pod, artifact.Container}
+		if _, ok := paths[ref]; !ok {
+			containers = append(containers, ref)
+		}
+		paths[ref] = append(paths[ref], artifact.Path)
+	}
+
+	var errs []error
+	for _, ref := range containers {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		derived := k8s.WithNamespace(ref.namespace)
+		if err := derived.shredPaths(ctx, ref.pod, ref.container, paths[ref]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// shredPaths overwrites and removes paths in a container with shredScript and forgets the paths removed.
+func (k8s *K8SExec) shredPaths(ctx context.Context, podName string, containerName string, paths []string) error {
+	var stdout, stderr bytes.Buffer
+	cmd := append([]string{"sh", "-c", shredScript, "sh"}, paths...)
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	target := strings.Join(paths, ",")
+	if errors.Is(err, ErrPodNotFound) {
+		k8s.forgetArtifacts(podName, containerName, paths...)
+		return nil
+	}
+	if removed := strings.Split(stdout.String(), "\x00"); len(removed) > 1 {
+		k8s.forgetArtifacts(podName, containerName, removed[:len(removed)-1]...)
+	}
+	if retCode == CommandNotFound && strings.Contains(stderr.String(), "no shred or dd available") {
+		return fmt.Errorf("shredding %s/%s:%s: %w: shred or dd", podName, containerName, target, ErrUtilNotFound)
+	}
+	if err := copyError("shredding", podName, containerName, target, retCode, err, &stderr); err != nil {
+		return err
+	}
+	k8s.logger().Debug("artifacts shredded", "pod", podName, "container", containerName, "paths", len(paths))
+	return nil
+}
@@ -0,0 +1,71 @@
+package k8sexec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"net/url"
+)
+
+// Attach connects 'stdin', 'stdout' and 'stderr' to the main process of a container, identified by
+// the container's name and the associated pod's name, using the pods/attach subresource, instead of spawning
+// a new process like Exec does. It is useful for containers whose entrypoint is interactive, e.g. a REPL.
+// Streams which are nil are not attached; attaching standard input requires the container to be created with
+// 'stdin: true', and 'tty' requires 'tty: true'. Attach returns when the process exits or the streams are closed;
+// detaching does not stop the process. An empty container name selects the pod's default container.
+func (k8s *K8SExec) Attach(podName string, containerName string, stdin io.Reader, stdout io.Writer, stderr io.Writer, tty bool) error {
+	return k8s.AttachWithContext(context.Background(), podName, containerName, stdin, stdout, stderr, tty)
+}
+
+// AttachWithContext connects the streams to the main process of a container like Attach. The use of this
+// function must provide a context that will govern the attachment; cancelling it detaches the streams.
+func (k8s *K8SExec) AttachWithContext(ctx context.Context, podName string, containerName string, stdin io.Reader, stdout io.Writer, stderr io.Writer, tty bool) error {
+	if containerName == "" {
+		var err error
+		if containerName, err = k8s.DefaultContainer(ctx, podName); err != nil {
+			return err
+		}
+	}
+	if k8s.rateLimiter != nil {
+		if err := k8s.rateLimiter.Wait(ctx); err != nil {
+			return fmt.Errorf("%w: %w", ErrThrottled, err)
+		}
+	}
+
+	k8s.logger().Debug("attaching to container", "pod", podName, "container", containerName)
+	executor, err := k8s.newExecutor(k8s.attachURL(podName, containerName, stdin != nil, stdout != nil, stderr != nil, tty))
+	if err != nil {
+		return fmt.Errorf("creating executor: %w", err)
+	}
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+		Tty:    tty,
+	})
+	if err != nil {
+		return wrapStreamError(ctx, err, podName, containerName)
+	}
+	return nil
+}
+
+// attachURL returns the URL of the attach subresource of the pod.
+func (k8s *K8SExec) attachURL(podName string, containerName string, stdin, stdout, stderr, tty bool) *url.URL {
+	return k8s.Clientset.CoreV1().RESTClient().
+		Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(k8s.Namespace).
+		SubResource("attach").
+		VersionedParams(&coreV1.PodAttachOptions{
+			Container: containerName,
+			Stdin:     stdin,
+			Stdout:    stdout,
+			Stderr:    stderr,
+			TTY:       tty,
+		}, scheme.ParameterCodec).
+		URL()
+}
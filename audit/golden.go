@@ -0,0 +1,152 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hhruszka/k8sexec"
+	"github.com/hhruszka/k8sexec/internal/textdiff"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// GoldenMode tells how the actual output of a command is compared with the expected one.
+type GoldenMode string
+
+const (
+	// GoldenLiteral requires the output to be equal to the expected text, ignoring trailing newlines.
+	GoldenLiteral GoldenMode = "literal"
+	// GoldenRegexp requires the output to match the expected regular expression.
+	GoldenRegexp GoldenMode = "regexp"
+	// GoldenJSON requires the output to be a JSON document semantically equal to the expected one,
+	// regardless of formatting and key order.
+	GoldenJSON GoldenMode = "json"
+)
+
+// GoldenOutput is the expected output of a command, used by CompareOutputs to check targets for conformance.
+type GoldenOutput struct {
+	Mode     GoldenMode
+	Expected string
+
+	pattern  *regexp.Regexp
+	document any
+}
+
+// ExpectLiteral returns a GoldenOutput requiring the output to be equal to 'expected'.
+func ExpectLiteral(expected string) GoldenOutput {
+	return GoldenOutput{Mode: GoldenLiteral, Expected: expected}
+}
+
+// ExpectRegexp returns a GoldenOutput requiring the output to match the regular expression 'expr'.
+// Use anchors and the (?s) flag to match the whole output.
+func ExpectRegexp(expr string) (GoldenOutput, error) {
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return GoldenOutput{}, fmt.Errorf("compiling expected output: %w", err)
+	}
+	return GoldenOutput{Mode: GoldenRegexp, Expected: expr, pattern: pattern}, nil
+}
+
+// ExpectJSON returns a GoldenOutput requiring the output to be a JSON document equal to 'expected'.
+func ExpectJSON(expected string) (GoldenOutput, error) {
+	var document any
+	if err := json.Unmarshal([]byte(expected), &document); err != nil {
+		return GoldenOutput{}, fmt.Errorf("parsing expected output: %w", err)
+	}
+	return GoldenOutput{Mode: GoldenJSON, Expected: expected, document: document}, nil
+}
+
+// Compare compares the actual output with the expected one. It reports whether they conform and, if they do not,
+// a description of the difference: a unified diff for literal and JSON outputs, and the output itself for regular
+// expressions.
+func (golden GoldenOutput) Compare(actual string) (bool, string, error) {
+	switch golden.Mode {
+	case GoldenLiteral:
+		expected := strings.TrimRight(golden.Expected, "\n")
+		actual = strings.TrimRight(actual, "\n")
+		if expected == actual {
+			return true, "", nil
+		}
+		return false, textdiff.Unified("expected", "actual", expected+"\n", actual+"\n"), nil
+
+	case GoldenRegexp:
+		pattern := golden.pattern
+		if pattern == nil {
+			var err error
+			if pattern, err = regexp.Compile(golden.Expected); err != nil {
+				return false, "", fmt.Errorf("compiling expected output: %w", err)
+			}
+		}
+		if pattern.MatchString(actual) {
+			return true, "", nil
+		}
+		return false, fmt.Sprintf("output does not match %q:\n%s", golden.Expected, actual), nil
+
+	case GoldenJSON:
+		expected := golden.document
+		if expected == nil {
+			if err := json.Unmarshal([]byte(golden.Expected), &expected); err != nil {
+				return false, "", fmt.Errorf("parsing expected output: %w", err)
+			}
+		}
+		var document any
+		if err := json.Unmarshal([]byte(actual), &document); err != nil {
+			return false, fmt.Sprintf("output is not valid JSON: %v", err), nil
+		}
+		if reflect.DeepEqual(expected, document) {
+			return true, "", nil
+		}
+		return false, textdiff.Unified("expected", "actual", indentJSON(expected), indentJSON(document)), nil
+
+	default:
+		return false, "", fmt.Errorf("unknown golden output mode %q", golden.Mode)
+	}
+}
+
+// ComparisonResult is the result of comparing the output of a command in a single target with the expected one.
+type ComparisonResult struct {
+	k8sexec.Target
+	// Passed reports whether the command succeeded and its output conformed to the expected one.
+	Passed bool `json:"Passed"`
+	// Diff describes how the output differs from the expected one.
+	Diff string `json:"Diff,omitempty"`
+	// Status is the status of the command's execution.
+	Status *k8sexec.ExecutionStatus `json:"Status"`
+}
+
+// CompareOutputs executes the command provided as arguments ('args') in every target, like BatchExec, and
+// compares the standard output of every execution with 'expected'. It returns a pass/fail result per target,
+// in the order of 'targets', with a diff for targets whose output does not conform. Executions that do not
+// succeed fail the comparison. This is the backbone of configuration conformance checks, e.g. verifying
+// that a configuration file has the same content in all containers.
+func CompareOutputs(ctx context.Context, k8s *k8sexec.K8SExec, targets []k8sexec.Target, args []string, expected GoldenOutput, options k8sexec.BatchOptions) ([]ComparisonResult, error) {
+	if _, _, err := expected.Compare(""); err != nil {
+		return nil, err
+	}
+
+	statuses := k8s.BatchExec(ctx, targets, args, options)
+	results := make([]ComparisonResult, len(targets))
+	for i, status := range statuses {
+		results[i] = ComparisonResult{Target: targets[i], Status: status}
+		if status.RetCode != k8sexec.Success {
+			results[i].Diff = fmt.Sprintf("command failed with exit code %d", status.RetCode)
+			continue
+		}
+		passed, diff, _ := expected.Compare(strings.Join(status.Stdout, "\n"))
+		results[i].Passed = passed
+		results[i].Diff = diff
+	}
+	return results, nil
+}
+
+// indentJSON formats a decoded JSON document with sorted keys, one value per line, so that differences
+// between documents are shown line by line.
+func indentJSON(document any) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(document)
+	return buffer.String()
+}
@@ -0,0 +1,102 @@
+// Package audit holds the report and conformance machinery of k8sexec: the secret scanner, conformance checks
+// comparing outputs of commands with expected ones, and SARIF logs. It is built on the exported API of the root
+// k8sexec package, which executes commands in containers and does not depend on this package, so programs that
+// only execute commands or transfer files do not carry the audit machinery.
+package audit
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// sarifVersion and sarifSchema identify the version of the SARIF format written by WriteSARIF.
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// SARIFRule describes a rule reported in a SARIF log, e.g. a kind of secret detected by the secret scanner.
+type SARIFRule struct {
+	ID          string
+	Description string
+}
+
+// SARIFResult is a single finding reported in a SARIF log.
+type SARIFResult struct {
+	RuleID string
+	// Level is the SARIF level of the result: "error", "warning" or "note". "warning" is used when it is empty.
+	Level   string
+	Message string
+	// URI identifies the artifact the finding was found in.
+	URI string
+	// Line is the 1-based line of the finding, or zero if it is not known.
+	Line int
+}
+
+// WriteSARIF writes a SARIF 2.1.0 log with a single run of the tool named 'toolName' to 'w'. SARIF logs are
+// understood by code scanning dashboards and most security report tooling.
+func WriteSARIF(w io.Writer, toolName string, rules []SARIFRule, results []SARIFResult) error {
+	type message struct {
+		Text string `json:"text"`
+	}
+	type rule struct {
+		ID               string  `json:"id"`
+		ShortDescription message `json:"shortDescription"`
+	}
+	type region struct {
+		StartLine int `json:"startLine"`
+	}
+	type physicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *region `json:"region,omitempty"`
+	}
+	type location struct {
+		PhysicalLocation physicalLocation `json:"physicalLocation"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+	type driver struct {
+		Name  string `json:"name"`
+		Rules []rule `json:"rules"`
+	}
+	type run struct {
+		Tool struct {
+			Driver driver `json:"driver"`
+		} `json:"tool"`
+		Results []result `json:"results"`
+	}
+	type log struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []run  `json:"runs"`
+	}
+
+	var sarifRun run
+	sarifRun.Tool.Driver = driver{Name: toolName, Rules: make([]rule, 0, len(rules))}
+	for _, r := range rules {
+		sarifRun.Tool.Driver.Rules = append(sarifRun.Tool.Driver.Rules, rule{ID: r.ID, ShortDescription: message{Text: r.Description}})
+	}
+	sarifRun.Results = make([]result, 0, len(results))
+	for _, r := range results {
+		level := r.Level
+		if level == "" {
+			level = "warning"
+		}
+		var loc location
+		loc.PhysicalLocation.ArtifactLocation.URI = r.URI
+		if r.Line > 0 {
+			loc.PhysicalLocation.Region = &region{StartLine: r.Line}
+		}
+		sarifRun.Results = append(sarifRun.Results, result{RuleID: r.RuleID, Level: level, Message: message{Text: r.Message}, Locations: []location{loc}})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log{Schema: sarifSchema, Version: sarifVersion, Runs: []run{sarifRun}})
+}
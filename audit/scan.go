@@ -0,0 +1,247 @@
+package audit
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hhruszka/k8sexec"
+	"io"
+	"path"
+	"strings"
+	"sync"
+)
+
+// DefaultSecretScanPaths are the directories scanned by ScanSecrets when SecretScanOptions.Paths is not set.
+var DefaultSecretScanPaths = []string{"/etc", "/home", "/root", "/opt", "/srv", "/app"}
+
+// DefaultSecretMaxFileSize is the size of the largest file scanned locally when the container has no grep.
+const DefaultSecretMaxFileSize = 1 << 20
+
+// SecretScanOptions configures ScanSecrets.
+type SecretScanOptions struct {
+	// Paths are files and directories scanned recursively. DefaultSecretScanPaths is used when it is empty.
+	Paths []string
+	// Rules are the patterns looked for. DefaultSecretRules is used when it is empty.
+	Rules []SecretRule
+	// MaxFileSize limits the size of files scanned locally, when the container provides no grep.
+	// DefaultSecretMaxFileSize is used when it is not set.
+	MaxFileSize int64
+	// Batch controls concurrency and timeouts of the scan. Stdin and Checkpoint are ignored.
+	Batch k8sexec.BatchOptions
+}
+
+// SecretFinding is a credential pattern found in a container's file.
+type SecretFinding struct {
+	k8sexec.Target
+	RuleID string `json:"RuleID"`
+	Path   string `json:"Path"`
+	Line   int    `json:"Line"`
+	// Match is the matched text, redacted so reports do not disclose the credential itself.
+	Match string `json:"Match"`
+}
+
+// SecretScanReport is the result of ScanSecrets.
+type SecretScanReport struct {
+	Findings []SecretFinding `json:"Findings"`
+	// Failures holds statuses of containers which could not be scanned. Their Err fields hold the causes, e.g.
+	// an error wrapping k8sexec.ErrInvalidPattern if a rule is not a valid POSIX extended regular expression.
+	Failures []*k8sexec.ExecutionStatus `json:"Failures,omitempty"`
+}
+
+// ScanSecrets scans filesystems of the target containers for credential patterns such as private key headers,
+// AWS access keys and JDBC URLs with passwords. Containers providing grep are scanned remotely, so only matching
+// lines are transferred; other containers are streamed as a tar archive and scanned locally. The scan of
+// different containers runs in parallel as controlled by options.Batch. Findings can be written as a SARIF log
+// with WriteSecretFindingsSARIF.
+func ScanSecrets(ctx context.Context, k8s *k8sexec.K8SExec, targets []k8sexec.Target, options SecretScanOptions) (*SecretScanReport, error) {
+	matcher, err := NewSecretMatcher(options.Rules)
+	if err != nil {
+		return nil, err
+	}
+	scanner := &secretScanner{k8s: k8s, matcher: matcher, options: options}
+	if len(scanner.options.Paths) == 0 {
+		scanner.options.Paths = DefaultSecretScanPaths
+	}
+	if scanner.options.MaxFileSize <= 0 {
+		scanner.options.MaxFileSize = DefaultSecretMaxFileSize
+	}
+
+	var report SecretScanReport
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	limiter := options.Batch.ConcurrencyLimiter()
+	for _, target := range targets {
+		if err := limiter.Acquire(ctx); err != nil {
+			failure := failureStatus(target, k8sexec.ContextExitCode(err), err, "")
+			// scans of earlier targets may still be appending their failures
+			mu.Lock()
+			report.Failures = append(report.Failures, failure)
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(target k8sexec.Target) {
+			defer wg.Done()
+			defer limiter.Release()
+			findings, failure := scanner.scan(ctx, target)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Findings = append(report.Findings, findings...)
+			if failure != nil {
+				report.Failures = append(report.Failures, failure)
+			}
+		}(target)
+	}
+	wg.Wait()
+
+	return &report, nil
+}
+
+// WriteSecretFindingsSARIF writes secret findings as a SARIF log. 'rules' are the rules the scan was run with;
+// DefaultSecretRules is used when it is empty.
+func WriteSecretFindingsSARIF(w io.Writer, rules []SecretRule, findings []SecretFinding) error {
+	if len(rules) == 0 {
+		rules = DefaultSecretRules
+	}
+	sarifRules := make([]SARIFRule, len(rules))
+	for i, rule := range rules {
+		sarifRules[i] = SARIFRule{ID: rule.ID, Description: rule.Description}
+	}
+	results := make([]SARIFResult, len(findings))
+	for i, finding := range findings {
+		results[i] = SARIFResult{
+			RuleID:  finding.RuleID,
+			Level:   "error",
+			Message: fmt.Sprintf("Possible secret %q in container %s of pod %s", finding.Match, finding.Container, finding.Pod),
+			URI:     path.Join(finding.Pod, finding.Container, finding.Path),
+			Line:    finding.Line,
+		}
+	}
+	return WriteSARIF(w, "k8sexec-secrets", sarifRules, results)
+}
+
+// secretScanner scans single containers for secrets.
+type secretScanner struct {
+	k8s     *k8sexec.K8SExec
+	matcher *SecretMatcher
+	options SecretScanOptions
+}
+
+// scan scans a single container, remotely with grep if it is available and locally otherwise.
+// A failed scan is reported with a non-nil ExecutionStatus.
+func (scanner *secretScanner) scan(ctx context.Context, target k8sexec.Target) ([]SecretFinding, *k8sexec.ExecutionStatus) {
+	ctx, cancel := context.WithTimeout(ctx, scanner.k8s.ExecTimeout(scanner.options.Batch.Timeout))
+	defer cancel()
+
+	utils, err := scanner.k8s.CheckUtilsInContainerWithContext(ctx, target.Pod, target.Container, []string{"grep", "tar"})
+	if err != nil {
+		return nil, failureStatus(target, k8sexec.ContextExitCode(ctx.Err()), err, "")
+	}
+	switch {
+	case utils["grep"]:
+		return scanner.grep(ctx, target)
+	case utils["tar"]:
+		return scanner.download(ctx, target)
+	default:
+		err := fmt.Errorf("scanning %s/%s: %w: grep or tar", target.Pod, target.Container, k8sexec.ErrUtilNotFound)
+		return nil, failureStatus(target, k8sexec.CommandNotFound, err, "")
+	}
+}
+
+// grep scans a container with grep running in the container, see k8sexec.Grep, looking for any of the rules'
+// expressions at once. Matching lines are classified locally.
+func (scanner *secretScanner) grep(ctx context.Context, target k8sexec.Target) ([]SecretFinding, *k8sexec.ExecutionStatus) {
+	rules := scanner.matcher.Rules()
+	expressions := make([]string, len(rules))
+	for i, rule := range rules {
+		expressions[i] = "(" + rule.Expression + ")"
+	}
+	matches, err := scanner.k8s.Grep(ctx, target.Pod, target.Container, strings.Join(expressions, "|"), scanner.options.Paths, k8sexec.GrepOptions{Extended: true})
+	switch {
+	case errors.Is(err, k8sexec.ErrInvalidPattern):
+		// a rule is not a valid POSIX extended regular expression, so no container could be scanned with it
+		return nil, failureStatus(target, k8sexec.IncorrectUsage, err, "")
+	case err != nil:
+		return nil, failureStatus(target, k8sexec.ContextExitCode(ctx.Err()), err, "")
+	}
+
+	var findings []SecretFinding
+	for _, match := range matches {
+		findings = append(findings, scanner.match(target, match.Path, match.Line, match.Text)...)
+	}
+	return findings, nil
+}
+
+// execResult is the result of an execution running in a separate goroutine.
+type execResult struct {
+	retCode k8sexec.ExitCode
+	err     error
+}
+
+// download streams the scanned paths as a tar archive and scans the files locally.
+func (scanner *secretScanner) download(ctx context.Context, target k8sexec.Target) ([]SecretFinding, *k8sexec.ExecutionStatus) {
+	reader, writer := io.Pipe()
+	var stderr bytes.Buffer
+	done := make(chan execResult, 1)
+	go func() {
+		cmd := append([]string{"tar", "cf", "-"}, scanner.options.Paths...)
+		retCode, err := scanner.k8s.ExecStream(ctx, target.Pod, target.Container, cmd, nil, writer, &stderr)
+		_ = writer.Close()
+		done <- execResult{retCode: retCode, err: err}
+	}()
+
+	var findings []SecretFinding
+	archive := tar.NewReader(reader)
+	var readErr error
+	for {
+		header, err := archive.Next()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				readErr = err
+			}
+			break
+		}
+		if header.Typeflag != tar.TypeReg || header.Size > scanner.options.MaxFileSize {
+			continue
+		}
+		lines := bufio.NewScanner(archive)
+		lines.Buffer(nil, int(scanner.options.MaxFileSize))
+		for line := 1; lines.Scan(); line++ {
+			findings = append(findings, scanner.match(target, "/"+strings.TrimPrefix(header.Name, "/"), line, lines.Text())...)
+		}
+	}
+	// drain the stream so the command can complete
+	_, _ = io.Copy(io.Discard, reader)
+
+	result := <-done
+	// tar exits with an error when some files could not be read, which does not invalidate the findings
+	if result.err != nil && (result.retCode == k8sexec.InternalAppError || ctx.Err() != nil) {
+		return findings, failureStatus(target, result.retCode, result.err, stderr.String())
+	}
+	if readErr != nil {
+		return findings, failureStatus(target, k8sexec.InternalAppError, fmt.Errorf("reading archive: %w", readErr), stderr.String())
+	}
+	return findings, nil
+}
+
+// failureStatus returns the status of a failed scan of 'target', carrying 'err' in its Err field so the cause can
+// be checked with errors.Is.
+func failureStatus(target k8sexec.Target, retCode k8sexec.ExitCode, err error, stderr string) *k8sexec.ExecutionStatus {
+	status := k8sexec.NewExecutionStatus(target.Pod, target.Container, retCode, err.Error(), "", stderr)
+	status.Err = err
+	return status
+}
+
+// match classifies a line of a file and returns findings for all rules matching it.
+func (scanner *secretScanner) match(target k8sexec.Target, filePath string, line int, text string) []SecretFinding {
+	var findings []SecretFinding
+	for _, match := range scanner.matcher.Match(text) {
+		findings = append(findings, SecretFinding{Target: target, RuleID: match.RuleID, Path: filePath, Line: line, Match: match.Match})
+	}
+	return findings
+}
@@ -0,0 +1,80 @@
+package audit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SecretRule describes a credential pattern looked for by the secret scanner. Expression must be valid both as
+// a POSIX extended regular expression, since it is passed to grep in containers, and as a Go regular expression,
+// since matches are classified and files are scanned locally with it.
+type SecretRule struct {
+	ID          string
+	Description string
+	Expression  string
+}
+
+// DefaultSecretRules are the credential patterns used by the secret scanner when no rules are given.
+var DefaultSecretRules = []SecretRule{
+	{ID: "private-key", Description: "Private key", Expression: `-----BEGIN ([A-Z0-9]+ )*PRIVATE KEY-----`},
+	{ID: "aws-access-key-id", Description: "AWS access key ID", Expression: `(AKIA|ASIA)[0-9A-Z]{16}`},
+	{ID: "jdbc-password", Description: "JDBC URL with a password parameter", Expression: `jdbc:[a-zA-Z0-9]+:[^ "']*[Pp][Aa][Ss][Ss][Ww][Oo][Rr][Dd]=[^&; "']+`},
+	{ID: "jdbc-credentials", Description: "JDBC URL with embedded credentials", Expression: `jdbc:[a-zA-Z0-9]+://[^/:@ "']+:[^/@ "']+@`},
+}
+
+// SecretMatch is a secret rule matching a line of a file.
+type SecretMatch struct {
+	RuleID string
+	// Match is the matched text, redacted so reports do not disclose the credential itself.
+	Match string
+}
+
+// SecretMatcher classifies lines of files according to secret rules. It is safe for concurrent use by multiple
+// goroutines.
+type SecretMatcher struct {
+	rules    []SecretRule
+	patterns []*regexp.Regexp
+}
+
+// NewSecretMatcher compiles the expressions of 'rules', or of DefaultSecretRules if it is empty, and returns
+// a matcher looking for them.
+func NewSecretMatcher(rules []SecretRule) (*SecretMatcher, error) {
+	if len(rules) == 0 {
+		rules = DefaultSecretRules
+	}
+	patterns := make([]*regexp.Regexp, len(rules))
+	for i, rule := range rules {
+		pattern, err := regexp.Compile(rule.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("compiling secret rule %s: %w", rule.ID, err)
+		}
+		patterns[i] = pattern
+	}
+	return &SecretMatcher{rules: rules, patterns: patterns}, nil
+}
+
+// Rules returns the rules the matcher looks for.
+func (matcher *SecretMatcher) Rules() []SecretRule {
+	return matcher.rules
+}
+
+// Match classifies a line of a file and returns a match for every rule matching it.
+func (matcher *SecretMatcher) Match(text string) []SecretMatch {
+	var matches []SecretMatch
+	for i, pattern := range matcher.patterns {
+		if match := pattern.FindString(text); match != "" {
+			matches = append(matches, SecretMatch{RuleID: matcher.rules[i].ID, Match: redact(match)})
+		}
+	}
+	return matches
+}
+
+// redact keeps the beginning of a matched secret, enough to recognize it, and masks the rest.
+func redact(match string) string {
+	const visible = 8
+	if len(match) <= visible {
+		return match
+	}
+	return match[:visible] + strings.Repeat("*", min(len(match)-visible, 16))
+}
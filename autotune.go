@@ -0,0 +1,136 @@
+package k8sexec
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Defaults of AutoTuneOptions.
+const (
+	DefaultAutoTunePercentile   = 0.95
+	DefaultAutoTuneMaxErrorRate = 0.1
+	DefaultAutoTuneInterval     = 10 * time.Second
+	DefaultAutoTuneMinSamples   = 5
+)
+
+// AutoTuneOptions configures an AutoTuner. Zero values select defaults.
+type AutoTuneOptions struct {
+	// Min and Max bound the concurrency chosen by the tuner. Min defaults to 1 and Max to four times
+	// the limiter's initial limit.
+	Min int
+	Max int
+	// TargetLatency is the highest acceptable latency percentile of executions. When the observed percentile
+	// exceeds it, concurrency is decreased. Latency is not considered when it is not set.
+	TargetLatency time.Duration
+	// Percentile of latency compared with TargetLatency, e.g. 0.95 for p95. Defaults to DefaultAutoTunePercentile.
+	Percentile float64
+	// MaxErrorRate is the highest acceptable ratio of executions failing because of API or transport errors
+	// (InternalAppError, ExecutionTimeOut). Defaults to DefaultAutoTuneMaxErrorRate.
+	MaxErrorRate float64
+	// Interval is the period of concurrency adjustments. Defaults to DefaultAutoTuneInterval.
+	Interval time.Duration
+	// MinSamples is the minimal number of executions observed in an interval to adjust concurrency.
+	// Defaults to DefaultAutoTuneMinSamples.
+	MinSamples int
+}
+
+// AutoTuner adjusts the limit of a ConcurrencyLimiter based on observed execution latency and API error rates,
+// in the additive-increase/multiplicative-decrease (AIMD) fashion: as long as the cluster keeps up, concurrency
+// grows by one every interval, and once latency or errors exceed their thresholds it is halved. This maximizes
+// throughput on healthy clusters while backing off quickly on struggling ones.
+type AutoTuner struct {
+	limiter *ConcurrencyLimiter
+	options AutoTuneOptions
+	logger  *slog.Logger
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	failures  int
+}
+
+// NewAutoTuner creates a tuner adjusting the limit of 'limiter'.
+func NewAutoTuner(limiter *ConcurrencyLimiter, options AutoTuneOptions) *AutoTuner {
+	if options.Min < 1 {
+		options.Min = 1
+	}
+	if options.Max < options.Min {
+		options.Max = max(4*limiter.Limit(), options.Min)
+	}
+	if options.Percentile <= 0 || options.Percentile > 1 {
+		options.Percentile = DefaultAutoTunePercentile
+	}
+	if options.MaxErrorRate <= 0 {
+		options.MaxErrorRate = DefaultAutoTuneMaxErrorRate
+	}
+	if options.Interval <= 0 {
+		options.Interval = DefaultAutoTuneInterval
+	}
+	if options.MinSamples <= 0 {
+		options.MinSamples = DefaultAutoTuneMinSamples
+	}
+	return &AutoTuner{limiter: limiter, options: options, logger: discardLogger}
+}
+
+// Observe records the latency of an execution and whether it failed because of an API or transport error.
+func (tuner *AutoTuner) Observe(latency time.Duration, failed bool) {
+	tuner.mu.Lock()
+	defer tuner.mu.Unlock()
+	tuner.latencies = append(tuner.latencies, latency)
+	if failed {
+		tuner.failures++
+	}
+}
+
+// Run adjusts the concurrency every interval until 'ctx' is done.
+func (tuner *AutoTuner) Run(ctx context.Context) {
+	ticker := time.NewTicker(tuner.options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			tuner.adjust()
+		}
+	}
+}
+
+// adjust evaluates executions observed since the last adjustment and changes the concurrency accordingly.
+func (tuner *AutoTuner) adjust() {
+	tuner.mu.Lock()
+	latencies, failures := tuner.latencies, tuner.failures
+	if len(latencies) < tuner.options.MinSamples {
+		tuner.mu.Unlock()
+		return
+	}
+	tuner.latencies, tuner.failures = nil, 0
+	tuner.mu.Unlock()
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := latencies[int(float64(len(latencies)-1)*tuner.options.Percentile)]
+	errorRate := float64(failures) / float64(len(latencies))
+
+	limit := tuner.limiter.Limit()
+	newLimit := limit
+	if errorRate > tuner.options.MaxErrorRate || tuner.options.TargetLatency > 0 && percentile > tuner.options.TargetLatency {
+		newLimit = max(limit/2, tuner.options.Min)
+	} else {
+		newLimit = min(limit+1, tuner.options.Max)
+	}
+
+	if newLimit != limit {
+		tuner.logger.Info("adjusting batch concurrency", "from", limit, "to", newLimit,
+			"latency", percentile, "errorRate", errorRate)
+		tuner.limiter.SetLimit(newLimit)
+	}
+}
+
+// failedExecution reports whether an execution failed because of an API or transport error rather than
+// because of the executed command.
+func failedExecution(status *ExecutionStatus) bool {
+	return status.RetCode == InternalAppError || status.RetCode == ExecutionTimeOut
+}
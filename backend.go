@@ -0,0 +1,198 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	exec2 "k8s.io/client-go/util/exec"
+	"net/url"
+	"os/exec"
+)
+
+// ExecRequest describes a single execution of a command in a container, passed to an ExecBackend.
+// Streams which are nil are not attached. TerminalSizeQueue, if set, delivers size changes of the terminal
+// allocated for TTY executions.
+type ExecRequest struct {
+	Namespace         string
+	Pod               string
+	Container         string
+	Command           []string
+	Stdin             io.Reader
+	Stdout            io.Writer
+	Stderr            io.Writer
+	TTY               bool
+	TerminalSizeQueue remotecommand.TerminalSizeQueue
+}
+
+// ExecBackend is the transport executing commands in containers. All higher-level APIs of K8SExec (Exec,
+// batch runs, file operations and others) run on top of the configured backend, so environments where
+// the default SPDY transport is blocked or patched can switch to another one with WithBackend.
+// Stream must return an error implementing exec2.ExitError (e.g. exec2.CodeExitError) when the command
+// completes with a non-zero exit code, and any other error when the command could not be executed.
+type ExecBackend interface {
+	Stream(ctx context.Context, k8s *K8SExec, request ExecRequest) error
+}
+
+// WithBackend sets the backend used to execute commands. SPDYBackend is used by default.
+func WithBackend(backend ExecBackend) Option {
+	return func(k8s *K8SExec) {
+		k8s.backend = backend
+	}
+}
+
+// execBackend returns the configured backend, or SPDYBackend if none was set.
+func (k8s *K8SExec) execBackend() ExecBackend {
+	if k8s.backend == nil {
+		return SPDYBackend{}
+	}
+	return k8s.backend
+}
+
+// execURL returns the URL of the exec subresource of the pod for the given request.
+func (k8s *K8SExec) execURL(request ExecRequest) *url.URL {
+	return k8s.Clientset.CoreV1().RESTClient().
+		Post().
+		Resource("pods").
+		Name(request.Pod).
+		Namespace(request.Namespace).
+		SubResource("exec").
+		VersionedParams(&coreV1.PodExecOptions{
+			Container: request.Container,
+			Command:   request.Command,
+			Stdin:     request.Stdin != nil,
+			Stdout:    request.Stdout != nil,
+			Stderr:    request.Stderr != nil,
+			TTY:       request.TTY,
+		}, scheme.ParameterCodec).
+		URL()
+}
+
+// SPDYBackend executes commands over SPDY streams, as kubectl exec does. It is the default backend
+// and honors the transport cache enabled with WithTransportCache.
+type SPDYBackend struct{}
+
+// Stream executes the request over SPDY.
+func (SPDYBackend) Stream(ctx context.Context, k8s *K8SExec, request ExecRequest) error {
+	execURL := k8s.execURL(request)
+	if k8s.executors != nil {
+		return k8s.streamCached(ctx, execURL, request)
+	}
+	executor, err := k8s.newExecutor(execURL)
+	if err != nil {
+		return fmt.Errorf("creating executor: %w", err)
+	}
+	return executor.StreamWithContext(ctx, streamOptions(request))
+}
+
+// streamCached executes the request with an executor taken from the executor cache. The executor is returned to
+// the cache afterwards, unless the execution failed because the pod or the container went away.
+func (k8s *K8SExec) streamCached(ctx context.Context, execURL *url.URL, request ExecRequest) error {
+	executor, err := k8s.executorFor(execURL)
+	if err != nil {
+		return fmt.Errorf("creating executor: %w", err)
+	}
+	container := request.Namespace + "/" + request.Pod + "/" + request.Container
+	err = executor.StreamWithContext(ctx, streamOptions(request))
+	var exitError exec2.ExitError
+	switch {
+	case err == nil || errors.As(err, &exitError):
+		k8s.executors.release(execURL, container, executor)
+	case isContainerRestart(err) || isContainerNotFound(err) || isPodNotFound(err):
+		k8s.executors.evictContainer(container)
+	}
+	return err
+}
+
+// streamOptions returns the options streaming the request's input and outputs.
+func streamOptions(request ExecRequest) remotecommand.StreamOptions {
+	return remotecommand.StreamOptions{
+		Stdin:             request.Stdin,
+		Stdout:            request.Stdout,
+		Stderr:            request.Stderr,
+		Tty:               request.TTY,
+		TerminalSizeQueue: request.TerminalSizeQueue,
+	}
+}
+
+// newExecutor creates an SPDY executor for the given exec URL, using the transport cache when it is enabled.
+func (k8s *K8SExec) newExecutor(execURL *url.URL) (remotecommand.Executor, error) {
+	if k8s.transports == nil {
+		return remotecommand.NewSPDYExecutor(k8s.Config, "POST", execURL)
+	}
+	transport, upgrader, err := k8s.transports.roundTripperFor(k8s.Config, k8s.heartbeat)
+	if err != nil {
+		return nil, fmt.Errorf("building exec transport: %w", err)
+	}
+	return remotecommand.NewSPDYExecutorForTransports(transport, upgrader, "POST", execURL)
+}
+
+// WebSocketBackend executes commands over WebSockets, using the v5 streaming protocol supported by API
+// servers since Kubernetes 1.29 (behind the TranslateStreamCloseWebsocketRequests feature gate in 1.29).
+// It is useful when proxies or load balancers in front of the API server do not support SPDY.
+type WebSocketBackend struct{}
+
+// Stream executes the request over a WebSocket connection.
+func (WebSocketBackend) Stream(ctx context.Context, k8s *K8SExec, request ExecRequest) error {
+	executor, err := remotecommand.NewWebSocketExecutor(k8s.Config, "GET", k8s.execURL(request).String())
+	if err != nil {
+		return fmt.Errorf("creating executor: %w", err)
+	}
+	return executor.StreamWithContext(ctx, streamOptions(request))
+}
+
+// KubectlBackend executes commands by running 'kubectl exec' as a subprocess. It is a fallback for
+// environments where the streaming libraries cannot reach the cluster but a locally installed (and possibly
+// patched) kubectl can. kubectl reports failures of its own with exit code 1, so they cannot always be told
+// from the command's exit code 1.
+type KubectlBackend struct {
+	// Path is the path of the kubectl binary. "kubectl" from PATH is used when it is empty.
+	Path string
+	// Kubeconfig is the kubeconfig passed to kubectl. The kubeconfig the instance was created with is used
+	// when it is empty.
+	Kubeconfig string
+	// Args are additional arguments passed to kubectl before the exec subcommand, e.g. "--context=lab".
+	Args []string
+}
+
+// Stream executes the request with kubectl.
+func (backend KubectlBackend) Stream(ctx context.Context, k8s *K8SExec, request ExecRequest) error {
+	path := backend.Path
+	if path == "" {
+		path = "kubectl"
+	}
+	kubeconfig := backend.Kubeconfig
+	if kubeconfig == "" {
+		kubeconfig = k8s.kubeconfig
+	}
+
+	var args []string
+	if kubeconfig != "" {
+		args = append(args, "--kubeconfig", kubeconfig)
+	}
+	args = append(args, backend.Args...)
+	args = append(args, "exec", "--namespace", request.Namespace, request.Pod, "--container", request.Container)
+	if request.Stdin != nil {
+		args = append(args, "--stdin")
+	}
+	if request.TTY {
+		args = append(args, "--tty")
+	}
+	args = append(args, "--")
+	args = append(args, request.Command...)
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = request.Stdin
+	cmd.Stdout = request.Stdout
+	cmd.Stderr = request.Stderr
+	err := cmd.Run()
+
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) && ctx.Err() == nil {
+		return exec2.CodeExitError{Err: err, Code: exitError.ExitCode()}
+	}
+	return err
+}
@@ -0,0 +1,233 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	coreV1 "k8s.io/api/core/v1"
+	"sync"
+	"time"
+)
+
+// DefaultBatchConcurrency is the number of commands executed at the same time by BatchExec when
+// BatchOptions.Concurrency is not set.
+const DefaultBatchConcurrency = 10
+
+// Target identifies a container, by its name and the name of its pod, in which a command is executed.
+type Target struct {
+	Pod       string `json:"Pod"`
+	Container string `json:"Container"`
+}
+
+// TargetsFromPods returns a Target for every container of every pod in the list, e.g. of pods returned by
+// GetUniquePods.
+func TargetsFromPods(pods []coreV1.Pod) []Target {
+	var targets []Target
+	for _, pod := range pods {
+		for _, container := range pod.Spec.Containers {
+			targets = append(targets, Target{Pod: pod.Name, Container: container.Name})
+		}
+	}
+	return targets
+}
+
+// BatchOptions configures the execution of a command across many targets with BatchExec.
+type BatchOptions struct {
+	// Concurrency is the maximum number of commands executed at the same time.
+	// DefaultBatchConcurrency is used when it is not set. It is ignored if Limiter is set.
+	Concurrency int
+	// Limiter, if set, bounds the number of commands executed at the same time instead of Concurrency.
+	// Its limit can be changed with SetLimit while the batch run is in flight.
+	Limiter *ConcurrencyLimiter
+	// AutoTune, if set, enables automatic adjustment of concurrency based on observed execution latency and
+	// error rates, starting from Concurrency (or Limiter's limit).
+	AutoTune *AutoTuneOptions
+	// Timeout bounds every single execution. The instance's default exec timeout is used when it is not set.
+	Timeout time.Duration
+	// Stdin, if set, is delivered to every execution via standard input.
+	Stdin []byte
+	// Capture selects the outputs kept in results. CaptureFull is used by default.
+	Capture CapturePolicy
+	// MaxOutputBytes, if set, limits the size of each output kept in results, see WithMaxOutputBytes.
+	MaxOutputBytes int64
+	// Checkpoint, if set, receives results of completed targets periodically, so an interrupted batch run
+	// can be continued with Resume.
+	Checkpoint CheckpointStore
+	// CheckpointInterval is the minimal period between two checkpoints. DefaultCheckpointInterval is used
+	// when it is not set.
+	CheckpointInterval time.Duration
+	// SkipUnhealthy enables a health gate: targets in evicted or not ready pods, or in containers stuck in
+	// CrashLoopBackOff or ImagePullBackOff, are not executed but reported with the ExecutionSkipped exit code
+	// and the reason in SkipReason.
+	SkipUnhealthy bool
+	// DryRun disables executions: targets are resolved and validated, including the RBAC check of the pods/exec
+	// subresource and the existence of pods and containers, and reported with synthetic results describing
+	// what would be executed where, see ExecutionStatus.DryRun. Checkpoints are not saved in dry-run mode.
+	DryRun bool
+}
+
+// BatchExec executes the command provided as arguments ('args') in every target container, running up to
+// options.Concurrency executions at the same time. It returns one ExecutionStatus per target, in the order of
+// 'targets'. The whole batch is governed by 'ctx': once it is cancelled, executions in flight are interrupted
+// and targets not started yet are reported as failed with the context's error.
+// If options.Checkpoint is set, results of completed targets are saved periodically and at the end of the run.
+func (k8s *K8SExec) BatchExec(ctx context.Context, targets []Target, args []string, options BatchOptions) []*ExecutionStatus {
+	return k8s.runBatch(ctx, targets, args, options, nil)
+}
+
+// Resume continues a batch run interrupted before it completed, e.g. because of a lost connection to the cluster.
+// Results saved in options.Checkpoint by the previous run are loaded and the command is executed only in targets
+// that did not complete yet. The returned results cover all targets, in the order of 'targets', and checkpoints
+// keep being saved, so Resume can be called again if the run gets interrupted once more.
+func (k8s *K8SExec) Resume(ctx context.Context, targets []Target, args []string, options BatchOptions) ([]*ExecutionStatus, error) {
+	if options.Checkpoint == nil {
+		return nil, errors.New("resuming a batch run requires a checkpoint store")
+	}
+	previous, err := options.Checkpoint.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(map[Target]*ExecutionStatus)
+	for _, status := range previous {
+		if completed(status) {
+			done[Target{Pod: status.Pod, Container: status.Container}] = status
+		}
+	}
+
+	var pending []Target
+	for _, target := range targets {
+		if _, ok := done[target]; !ok {
+			pending = append(pending, target)
+		}
+	}
+	k8s.logger().Info("resuming batch run", "completed", len(targets)-len(pending), "pending", len(pending))
+
+	var carried []*ExecutionStatus
+	for _, status := range done {
+		carried = append(carried, status)
+	}
+	pendingResults := k8s.runBatch(ctx, pending, args, options, carried)
+
+	results := make([]*ExecutionStatus, 0, len(targets))
+	for _, target := range targets {
+		if status, ok := done[target]; ok {
+			results = append(results, status)
+			continue
+		}
+		results = append(results, pendingResults[0])
+		pendingResults = pendingResults[1:]
+	}
+	return results, nil
+}
+
+// runBatch executes the command of a batch run in all targets. Results of a previous run passed in 'previous'
+// are included in checkpoints.
+func (k8s *K8SExec) runBatch(ctx context.Context, targets []Target, args []string, options BatchOptions, previous []*ExecutionStatus) []*ExecutionStatus {
+	var plan *dryRunPlan
+	if options.DryRun {
+		plan = k8s.newDryRunPlan(ctx)
+		options.Checkpoint, options.AutoTune = nil, nil
+	}
+
+	checkpoints := newCheckpointer(options, k8s.logger(), previous)
+	defer checkpoints.flush()
+
+	limiter := options.ConcurrencyLimiter()
+
+	var tuner *AutoTuner
+	if options.AutoTune != nil {
+		tuner = NewAutoTuner(limiter, *options.AutoTune)
+		tuner.logger = k8s.logger()
+
+		tuneCtx, stopTuning := context.WithCancel(ctx)
+		defer stopTuning()
+		go tuner.Run(tuneCtx)
+	}
+
+	var gate *healthGate
+	if options.SkipUnhealthy {
+		gate = k8s.newHealthGate(ctx, targets)
+	}
+
+	results := make([]*ExecutionStatus, len(targets))
+	var wg sync.WaitGroup
+
+	for i, target := range targets {
+		if reason := gate.skipReason(target); reason != "" {
+			k8s.logger().Info("skipping unhealthy target", "pod", target.Pod, "container", target.Container, "reason", reason)
+			results[i] = skippedStatus(target, reason)
+			checkpoints.add(results[i])
+			continue
+		}
+		if err := limiter.Acquire(ctx); err != nil {
+			results[i] = NewExecutionStatus(target.Pod, target.Container, ContextExitCode(err), err.Error(), "", "")
+			results[i].Err = err
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			defer limiter.Release()
+			start := time.Now()
+			if plan != nil {
+				results[i] = k8s.dryRunStatus(ctx, plan, target, args, options)
+			} else {
+				results[i] = k8s.batchExecOne(ctx, target, args, options)
+			}
+			if tuner != nil {
+				tuner.Observe(time.Since(start), failedExecution(results[i]))
+			}
+			checkpoints.add(results[i])
+		}(i, target)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// ConcurrencyLimiter returns options.Limiter, or a new limiter allowing options.Concurrency operations if it is
+// not set. Runs across many targets built outside this package, e.g. the secret scan of the audit package, use it
+// to honor the same options as BatchExec.
+func (options BatchOptions) ConcurrencyLimiter() *ConcurrencyLimiter {
+	if options.Limiter != nil {
+		return options.Limiter
+	}
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+	return NewConcurrencyLimiter(concurrency)
+}
+
+// batchExecOne executes the command of a batch in a single target.
+func (k8s *K8SExec) batchExecOne(ctx context.Context, target Target, args []string, options BatchOptions) *ExecutionStatus {
+	ctx, cancel := context.WithTimeout(ctx, k8s.ExecTimeout(options.Timeout))
+	defer cancel()
+
+	var stdin io.Reader
+	if options.Stdin != nil {
+		stdin = bytes.NewReader(options.Stdin)
+	}
+
+	status := k8s.ExecWithOptions(ctx, target.Pod, target.Container, args,
+		WithStdin(stdin), WithCapture(options.Capture), WithMaxOutputBytes(options.MaxOutputBytes))
+	if status.RetCode == InternalAppError && ctx.Err() != nil {
+		status.RetCode = ContextExitCode(ctx.Err())
+	}
+	return status
+}
+
+// ContextExitCode maps an error of a finished context to an ExitCode: ExecutionTimeOut for an expired deadline,
+// ExecutionCancelled for a cancellation and InternalAppError for any other error.
+func ContextExitCode(err error) ExitCode {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ExecutionTimeOut
+	case errors.Is(err, context.Canceled):
+		return ExecutionCancelled
+	}
+	return InternalAppError
+}
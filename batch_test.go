@@ -0,0 +1,91 @@
+package k8sexec
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResumeOrdersResults(t *testing.T) {
+	targets := []Target{
+		{Pod: "web-0", Container: "app"},
+		{Pod: "web-0", Container: "sidecar"},
+		{Pod: "web-1", Container: "app"},
+		{Pod: "web-2", Container: "app"},
+	}
+	saved := func(target Target, retCode ExitCode) *ExecutionStatus {
+		return NewExecutionStatus(target.Pod, target.Container, retCode, "", "saved", "")
+	}
+
+	tests := []struct {
+		name     string
+		previous []*ExecutionStatus
+		// executed lists the indexes of targets expected to be executed rather than carried over
+		executed []int
+	}{
+		{
+			name:     "no checkpoint",
+			executed: []int{0, 1, 2, 3},
+		},
+		{
+			name:     "completed targets saved out of order",
+			previous: []*ExecutionStatus{saved(targets[3], Success), saved(targets[0], GeneralError)},
+			executed: []int{1, 2},
+		},
+		{
+			name:     "interrupted executions are repeated",
+			previous: []*ExecutionStatus{saved(targets[1], Success), saved(targets[2], ExecutionCancelled), saved(targets[3], InternalAppError)},
+			executed: []int{0, 2, 3},
+		},
+		{
+			name:     "all targets completed",
+			previous: []*ExecutionStatus{saved(targets[2], Success), saved(targets[1], Success), saved(targets[0], Success), saved(targets[3], Success)},
+		},
+		{
+			name:     "results of unknown targets are ignored",
+			previous: []*ExecutionStatus{saved(Target{Pod: "gone", Container: "app"}, Success), saved(targets[2], Success)},
+			executed: []int{0, 1, 3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			checkpoint := FileCheckpoint{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+			if test.previous != nil {
+				if err := checkpoint.Save(test.previous); err != nil {
+					t.Fatal(err)
+				}
+			}
+			backend := &fakeBackend{}
+			k8s := newTestK8SExec(t, backend)
+
+			results, err := k8s.Resume(context.Background(), targets, []string{"echo", "executed"}, BatchOptions{Checkpoint: checkpoint})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != len(targets) {
+				t.Fatalf("%d results, want %d", len(results), len(targets))
+			}
+			executed := make(map[int]bool)
+			for _, i := range test.executed {
+				executed[i] = true
+			}
+			for i, result := range results {
+				if result.Pod != targets[i].Pod || result.Container != targets[i].Container {
+					t.Errorf("result %d is of %s/%s, want %s/%s", i, result.Pod, result.Container, targets[i].Pod, targets[i].Container)
+				}
+				want := "saved"
+				if executed[i] {
+					want = "executed"
+				}
+				if output := strings.Join(result.Stdout, "\n"); output != want {
+					t.Errorf("result %d has output %q, want %q", i, output, want)
+				}
+			}
+			if streams := backend.streams.Load(); streams != int64(len(test.executed)) {
+				t.Errorf("%d executions, want %d", streams, len(test.executed))
+			}
+		})
+	}
+}
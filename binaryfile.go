@@ -0,0 +1,75 @@
+package k8sexec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// readBinaryFileScript prints the name of the encoding on the first line, followed by the content of the file
+// given as the first parameter encoded with the first available utility: base64, od or uuencode.
+const readBinaryFileScript = checkReadableScript + `if command -v base64 >/dev/null 2>&1; then
+  echo base64; exec base64 "$1"
+elif command -v od >/dev/null 2>&1; then
+  echo od; exec od -An -v -tx1 "$1"
+elif command -v uuencode >/dev/null 2>&1; then
+  echo uuencode; exec uuencode -m "$1" file
+fi
+echo "no encoder available: base64, od or uuencode" >&2
+exit 127`
+
+// ReadBinaryFile reads the file 'path' in a container, identified by the container's name and the associated
+// pod's name, byte for byte, including NUL bytes and invalid UTF-8, which get mangled by text-oriented reads.
+// The file is encoded in the container with 'base64', 'od' or 'uuencode', whichever is available first, and
+// decoded locally, so its content travels as text. The whole file is held in memory; ReadFileTo should be used
+// for large files. Missing and unreadable files are reported with errors wrapping ErrFileNotFound and
+// ErrFileNotReadable, and containers lacking all the encoders with an error wrapping ErrUtilNotFound.
+// The read is governed by the provided context.
+func (k8s *K8SExec) ReadBinaryFile(ctx context.Context, podName string, containerName string, path string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"sh", "-c", readBinaryFileScript, "sh", path}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if retCode == CommandNotFound && strings.Contains(stderr.String(), "no encoder available") {
+		return nil, fmt.Errorf("reading %s/%s:%s: %w: base64, od or uuencode", podName, containerName, path, ErrUtilNotFound)
+	}
+	if err := fileError("reading", podName, containerName, path, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+
+	encoding, encoded, _ := bytes.Cut(stdout.Bytes(), []byte("\n"))
+	content, err := decodeFile(string(encoding), encoded)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s/%s:%s: decoding %s output: %w", podName, containerName, path, encoding, err)
+	}
+	return content, nil
+}
+
+// decodeFile decodes a file encoded by readBinaryFileScript with the given encoding.
+func decodeFile(encoding string, encoded []byte) ([]byte, error) {
+	switch encoding {
+	case "base64":
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(encoded)), ""))
+	case "od":
+		return hex.DecodeString(strings.Join(strings.Fields(string(encoded)), ""))
+	case "uuencode":
+		// uuencode -m wraps base64 lines between a "begin-base64" header and a "====" trailer
+		var lines []string
+		scanner := bufio.NewScanner(bytes.NewReader(encoded))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "begin-base64") || line == "" {
+				continue
+			}
+			if line == "====" {
+				break
+			}
+			lines = append(lines, line)
+		}
+		return base64.StdEncoding.DecodeString(strings.Join(lines, ""))
+	}
+	return nil, fmt.Errorf("unknown encoding %q", encoding)
+}
@@ -0,0 +1,47 @@
+package k8sexec
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeFile(t *testing.T) {
+	binary := []byte("\x00\x01binary\xff\n")
+	long := bytes.Repeat([]byte("a"), 100)
+
+	tests := []struct {
+		name     string
+		encoding string
+		encoded  string
+		want     []byte
+		err      bool
+	}{
+		{name: "base64", encoding: "base64", encoded: "AAFiaW5hcnn/Cg==\n", want: binary},
+		{name: "base64 wrapped lines", encoding: "base64", encoded: "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFh\nYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYQ==\n", want: long},
+		{name: "base64 empty file", encoding: "base64", encoded: "", want: nil},
+		{name: "od", encoding: "od", encoded: " 00 01 62 69 6e 61 72 79 ff 0a\n", want: binary},
+		{name: "od several lines", encoding: "od", encoded: " 00 01 62 69 6e 61 72 79\n ff 0a\n", want: binary},
+		{name: "od empty file", encoding: "od", encoded: "", want: nil},
+		{name: "uuencode", encoding: "uuencode", encoded: "begin-base64 644 file\nAAFiaW5hcnn/Cg==\n====\n", want: binary},
+		{name: "uuencode empty file", encoding: "uuencode", encoded: "begin-base64 644 file\n====\n", want: nil},
+		{name: "uuencode output after the trailer", encoding: "uuencode", encoded: "begin-base64 644 file\nAAFiaW5hcnn/Cg==\n====\ngarbage\n", want: binary},
+		{name: "corrupted base64", encoding: "base64", encoded: "AAF*aW5h\n", err: true},
+		{name: "odd od output", encoding: "od", encoded: " 00 0\n", err: true},
+		{name: "unknown encoding", encoding: "xxd", encoded: "00000000: 0001", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			content, err := decodeFile(test.encoding, []byte(test.encoded))
+			if test.err {
+				if err == nil {
+					t.Errorf("decodeFile(%q) = %q, want an error", test.encoded, content)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(content, test.want) {
+				t.Errorf("decodeFile(%q) = %q, %v, want %q", test.encoded, content, err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package k8sexec
+
+import (
+	"context"
+	"io"
+)
+
+// CapturePolicy selects which outputs of a command are transferred from the container and kept in
+// the ExecutionStatus. Outputs which are not captured are not requested from the Kubernetes API, so they are
+// neither transferred over the network nor kept in memory. The only exception is CaptureExitCode without
+// standard input: the API server requires at least one stream, so standard output is transferred and dropped.
+type CapturePolicy int
+
+const (
+	// CaptureFull captures both standard output and standard error. It is the default policy.
+	CaptureFull CapturePolicy = iota
+	// CaptureStderr captures only standard error, which is usually enough to explain a failure.
+	CaptureStderr
+	// CaptureExitCode captures no output; only the exit code and errors are reported. Standard output is still
+	// transferred, but dropped, if the command has no standard input.
+	CaptureExitCode
+	// CaptureHashes transfers both outputs but keeps only their SHA-256 digests, in StdoutSHA256 and
+	// StderrSHA256, which is enough to compare outputs across many containers.
+	CaptureHashes
+)
+
+// String returns the name of the policy.
+func (policy CapturePolicy) String() string {
+	switch policy {
+	case CaptureFull:
+		return "full"
+	case CaptureStderr:
+		return "stderr"
+	case CaptureExitCode:
+		return "exit-code"
+	case CaptureHashes:
+		return "hashes"
+	default:
+		return "unknown"
+	}
+}
+
+// ExecWithCapture executes a command provided through standard input ('stdin') or as arguments ('args'),
+// or a combination of both, like ExecWithContext, but keeps only the outputs selected by 'policy'.
+// Massive compliance runs that only care about exit codes should use CaptureExitCode, so that outputs of
+// thousands of commands are neither transferred nor buffered in memory.
+// The use of this function must provide a context that will govern the command execution.
+func (k8s *K8SExec) ExecWithCapture(ctx context.Context, podName string, containerName string, args []string, stdin io.Reader, policy CapturePolicy) *ExecutionStatus {
+	return k8s.ExecWithOptions(ctx, podName, containerName, args, WithStdin(stdin), WithCapture(policy))
+}
@@ -0,0 +1,160 @@
+package k8sexec
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Default terminal dimensions written to cast headers when CastHeader does not set them.
+const (
+	DefaultCastWidth  = 80
+	DefaultCastHeight = 24
+)
+
+// CastHeader describes a recorded session in the header of an asciinema v2 cast file.
+type CastHeader struct {
+	Width   int
+	Height  int
+	Title   string
+	Command string
+}
+
+// CastRecorder records a session, i.e. timestamped chunks of a command's output and optionally input, in
+// the asciinema v2 cast format (https://docs.asciinema.org/manual/asciicast/v2/), providing replayable evidence
+// of what was executed in a container and what it returned. A recorder is attached to an execution with
+// WithCastRecording, or its Output and Input wrappers can be used with ExecStream, ExecTTY and similar
+// functions directly. It is safe for concurrent use; all chunks are timed relative to its creation.
+type CastRecorder struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+	err   error
+}
+
+// NewCastRecorder creates a recorder writing the cast to 'w' and writes the cast's header.
+func NewCastRecorder(w io.Writer, header CastHeader) (*CastRecorder, error) {
+	recorder := &CastRecorder{w: w, start: time.Now()}
+	encoded, err := json.Marshal(struct {
+		Version   int    `json:"version"`
+		Width     int    `json:"width"`
+		Height    int    `json:"height"`
+		Timestamp int64  `json:"timestamp"`
+		Title     string `json:"title,omitempty"`
+		Command   string `json:"command,omitempty"`
+	}{
+		Version:   2,
+		Width:     cmp.Or(header.Width, DefaultCastWidth),
+		Height:    cmp.Or(header.Height, DefaultCastHeight),
+		Timestamp: recorder.start.Unix(),
+		Title:     header.Title,
+		Command:   header.Command,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", encoded); err != nil {
+		return nil, fmt.Errorf("writing cast header: %w", err)
+	}
+	return recorder, nil
+}
+
+// Output returns a writer passing everything written to it to 'w' and recording it as output. A nil 'w' is
+// allowed, in which case output is only recorded.
+func (recorder *CastRecorder) Output(w io.Writer) io.Writer {
+	if w == nil {
+		w = io.Discard
+	}
+	return &castWriter{recorder: recorder, kind: "o", w: w}
+}
+
+// Input returns a reader recording everything read from 'r' as input, e.g. keystrokes sent to an interactive
+// shell. Input events are optional in the cast format and ignored by most players.
+func (recorder *CastRecorder) Input(r io.Reader) io.Reader {
+	return &castReader{recorder: recorder, r: r, events: &castWriter{recorder: recorder, kind: "i", w: io.Discard}}
+}
+
+// Err returns the first error encountered while writing the cast, if any.
+func (recorder *CastRecorder) Err() error {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	return recorder.err
+}
+
+// event writes a single event to the cast. Recording errors do not interrupt the session; they are kept
+// and reported by Err.
+func (recorder *CastRecorder) event(kind string, data string) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if recorder.err != nil {
+		return
+	}
+
+	encoded, err := json.Marshal([]any{time.Since(recorder.start).Seconds(), kind, data})
+	if err == nil {
+		_, err = fmt.Fprintf(recorder.w, "%s\n", encoded)
+	}
+	recorder.err = err
+}
+
+// castWriter records chunks written to it as events. Multibyte UTF-8 sequences split between chunks are held
+// back until they are complete, since events must contain valid text.
+type castWriter struct {
+	recorder *CastRecorder
+	kind     string
+	w        io.Writer
+
+	mu      sync.Mutex
+	pending []byte
+}
+
+func (cw *castWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+
+	cw.mu.Lock()
+	data := append(cw.pending, p[:n]...)
+	cut := len(data)
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				cut = i
+			}
+			break
+		}
+	}
+	cw.pending = append([]byte{}, data[cut:]...)
+	cw.mu.Unlock()
+
+	if cut > 0 {
+		cw.recorder.event(cw.kind, strings.ToValidUTF8(string(data[:cut]), "\uFFFD"))
+	}
+	return n, err
+}
+
+// castReader records chunks read from the wrapped reader as input events.
+type castReader struct {
+	recorder *CastRecorder
+	r        io.Reader
+	events   *castWriter
+}
+
+func (cr *castReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	if n > 0 {
+		_, _ = cr.events.Write(p[:n])
+	}
+	return n, err
+}
+
+// WithCastRecording records the execution's output, and its standard input if provided, to 'recorder' in
+// the asciinema v2 cast format. Standard output and standard error are recorded as they arrive, interleaved.
+func WithCastRecording(recorder *CastRecorder) ExecOption {
+	return func(config *execConfig) {
+		config.recorder = recorder
+	}
+}
@@ -0,0 +1,140 @@
+package k8sexec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultCheckpointInterval is the minimal period between two checkpoints of a batch run when
+// BatchOptions.CheckpointInterval is not set.
+const DefaultCheckpointInterval = 10 * time.Second
+
+// CheckpointStore persists results of targets completed by a batch run, so an interrupted run can be resumed
+// with Resume without executing the command again in targets that already completed.
+type CheckpointStore interface {
+	// Load returns results saved by the last checkpoint, or no results if there was none.
+	Load() ([]*ExecutionStatus, error)
+	// Save replaces the saved checkpoint with the given results.
+	Save(results []*ExecutionStatus) error
+}
+
+// FileCheckpoint is a CheckpointStore keeping results in a JSON file at Path. The file is replaced atomically,
+// so an interruption while saving never leaves a corrupted checkpoint behind.
+type FileCheckpoint struct {
+	Path string
+}
+
+// Load reads results from the checkpoint file. A missing file means that no checkpoint was saved yet.
+func (checkpoint FileCheckpoint) Load() ([]*ExecutionStatus, error) {
+	data, err := os.ReadFile(checkpoint.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading checkpoint: %w", err)
+	}
+
+	var results []*ExecutionStatus
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("decoding checkpoint %s: %w", checkpoint.Path, err)
+	}
+	return results, nil
+}
+
+// Save writes results to a temporary file and renames it to the checkpoint file.
+func (checkpoint FileCheckpoint) Save(results []*ExecutionStatus) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("encoding checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(checkpoint.Path), filepath.Base(checkpoint.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), checkpoint.Path); err != nil {
+		return fmt.Errorf("replacing checkpoint: %w", err)
+	}
+	return nil
+}
+
+// completed reports whether a result is final and does not need to be repeated when a run is resumed, i.e.
+// whether the command was executed and its exit code obtained. Executions that failed because of connectivity
+// problems, timeouts or cancellation are repeated.
+func completed(status *ExecutionStatus) bool {
+	return status != nil && status.RetCode >= Success
+}
+
+// checkpointer collects results of completed targets during a batch run and periodically saves them.
+type checkpointer struct {
+	store    CheckpointStore
+	interval time.Duration
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	results  []*ExecutionStatus
+	lastSave time.Time
+}
+
+// newCheckpointer returns a checkpointer seeded with results of a previous run, or nil if the batch run is
+// not checkpointed.
+func newCheckpointer(options BatchOptions, logger *slog.Logger, previous []*ExecutionStatus) *checkpointer {
+	if options.Checkpoint == nil {
+		return nil
+	}
+	interval := options.CheckpointInterval
+	if interval <= 0 {
+		interval = DefaultCheckpointInterval
+	}
+	return &checkpointer{store: options.Checkpoint, interval: interval, logger: logger, results: previous, lastSave: time.Now()}
+}
+
+// add records the result of a target and saves a checkpoint if the checkpoint interval has elapsed.
+func (c *checkpointer) add(status *ExecutionStatus) {
+	if c == nil || !completed(status) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = append(c.results, status)
+	if time.Since(c.lastSave) >= c.interval {
+		c.save()
+	}
+}
+
+// flush saves a final checkpoint at the end of a batch run.
+func (c *checkpointer) flush() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.save()
+}
+
+// save stores collected results; it must be called with the mutex held. Failing to save a checkpoint
+// does not interrupt the batch run, so it is only logged.
+func (c *checkpointer) save() {
+	c.lastSave = time.Now()
+	if err := c.store.Save(c.results); err != nil {
+		c.logger.Warn("saving batch checkpoint failed", "error", err)
+	}
+}
@@ -0,0 +1,132 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// ChecksumAlgorithm is an algorithm of checksums computed by Checksum.
+type ChecksumAlgorithm string
+
+// Checksum algorithms supported by Checksum.
+const (
+	ChecksumSHA256 ChecksumAlgorithm = "sha256"
+	ChecksumSHA1   ChecksumAlgorithm = "sha1"
+	ChecksumMD5    ChecksumAlgorithm = "md5"
+	// ChecksumCRC is the CRC computed by the POSIX 'cksum' utility.
+	ChecksumCRC ChecksumAlgorithm = "cksum"
+)
+
+// checksumScript prints the checksum of the file given as the first parameter computed with the algorithm
+// given as the second parameter by the first available utility supporting it, or nothing if none is available.
+// The file is read from standard input, so the output does not depend on its name.
+const checksumScript = checkReadableScript + `try() {
+	command -v "$1" >/dev/null 2>&1 && s=$("$@" < "$f" 2>/dev/null) && [ -n "$s" ] && echo "${s%% *}" && exit 0
+}
+f=$1
+case $2 in
+sha256) try sha256sum; try shasum -a 256; try openssl dgst -sha256 -r ;;
+sha1) try sha1sum; try shasum -a 1; try openssl dgst -sha1 -r ;;
+md5) try md5sum; try md5 -q; try openssl dgst -md5 -r ;;
+cksum) try cksum ;;
+esac
+exit 0`
+
+// Checksum returns the checksum of the file 'path' in a container, identified by the container's name and
+// the associated pod's name, computed with 'algorithm', e.g. to compare binaries and configuration files
+// across pods or against golden values. The checksum is computed in the container by the first available of
+// 'sha256sum', 'shasum', 'md5sum', 'openssl' or 'cksum' supporting the algorithm. If none is available, the file
+// is read with ReadBinaryFile, which holds it in memory, and the checksum is computed locally. Checksums are
+// normalized to lowercase hexadecimal digits, including CRCs, which 'cksum' prints as decimal numbers.
+// Missing and unreadable files are reported with errors wrapping ErrFileNotFound and ErrFileNotReadable.
+// The call is governed by the provided context.
+func (k8s *K8SExec) Checksum(ctx context.Context, podName string, containerName string, path string, algorithm ChecksumAlgorithm) (string, error) {
+	var newHash func() hash.Hash
+	switch algorithm {
+	case ChecksumSHA256:
+		newHash = sha256.New
+	case ChecksumSHA1:
+		newHash = sha1.New
+	case ChecksumMD5:
+		newHash = md5.New
+	case ChecksumCRC:
+	default:
+		return "", fmt.Errorf("computing checksum of %s/%s:%s: unsupported algorithm %q", podName, containerName, path, algorithm)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"sh", "-c", checksumScript, "sh", path, string(algorithm)}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if err := fileError("computing checksum of", podName, containerName, path, retCode, err, &stderr); err != nil {
+		return "", err
+	}
+
+	if sum := strings.TrimSpace(stdout.String()); sum != "" {
+		checksum, err := normalizeChecksum(algorithm, sum)
+		if err != nil {
+			return "", fmt.Errorf("computing checksum of %s/%s:%s: %w", podName, containerName, path, err)
+		}
+		return checksum, nil
+	}
+
+	k8s.logger().Debug("no checksum utility in the container, computing checksum locally", "pod", podName,
+		"container", containerName, "path", path, "algorithm", algorithm)
+	content, err := k8s.ReadBinaryFile(ctx, podName, containerName, path)
+	if err != nil {
+		return "", err
+	}
+	if newHash == nil {
+		return fmt.Sprintf("%08x", posixChecksum(content)), nil
+	}
+	h := newHash()
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// normalizeChecksum validates a checksum printed by checksumScript and converts it to lowercase hexadecimal
+// digits.
+func normalizeChecksum(algorithm ChecksumAlgorithm, sum string) (string, error) {
+	if algorithm == ChecksumCRC {
+		crc, err := strconv.ParseUint(sum, 10, 32)
+		if err != nil {
+			return "", fmt.Errorf("parsing checksum %q: %w", sum, err)
+		}
+		return fmt.Sprintf("%08x", crc), nil
+	}
+	sum = strings.ToLower(sum)
+	if _, err := hex.DecodeString(sum); err != nil {
+		return "", fmt.Errorf("parsing checksum %q: %w", sum, err)
+	}
+	return sum, nil
+}
+
+// posixChecksum computes the CRC of 'data' the way the POSIX 'cksum' utility does: a non-reflected CRC-32 of
+// the data followed by its length, least significant byte first, with the result complemented.
+func posixChecksum(data []byte) uint32 {
+	var crc uint32
+	update := func(b byte) {
+		crc ^= uint32(b) << 24
+		for range 8 {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	for _, b := range data {
+		update(b)
+	}
+	for n := len(data); n > 0; n >>= 8 {
+		update(byte(n))
+	}
+	return ^crc
+}
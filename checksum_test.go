@@ -0,0 +1,82 @@
+package k8sexec
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestPosixChecksum(t *testing.T) {
+	// expected values as printed by 'cksum'
+	tests := []struct {
+		name string
+		data []byte
+		want uint32
+	}{
+		{name: "empty file", data: nil, want: 4294967295},
+		{name: "single NUL byte", data: []byte{0}, want: 4215202376},
+		{name: "text", data: []byte("hello world\n"), want: 3733384285},
+		{name: "length spanning several bytes", data: make([]byte, 100000), want: 1260869142},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := posixChecksum(test.data); got != test.want {
+				t.Errorf("posixChecksum = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeChecksum(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm ChecksumAlgorithm
+		sum       string
+		want      string
+		err       bool
+	}{
+		{name: "sha256", algorithm: ChecksumSHA256, sum: "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447", want: "a948904f2f0f479b8f8197694b30184b0d2ed1c1cd2a1ec0fb85d299a192a447"},
+		{name: "uppercase md5", algorithm: ChecksumMD5, sum: "6F5902AC237024BDD0C176CB93063DC4", want: "6f5902ac237024bdd0c176cb93063dc4"},
+		{name: "CRC of text", algorithm: ChecksumCRC, sum: "3733384285", want: "de86ec5d"},
+		{name: "CRC of empty file", algorithm: ChecksumCRC, sum: "4294967295", want: "ffffffff"},
+		{name: "small CRC is padded", algorithm: ChecksumCRC, sum: "255", want: "000000ff"},
+		{name: "CRC out of range", algorithm: ChecksumCRC, sum: "4294967296", err: true},
+		{name: "hexadecimal CRC", algorithm: ChecksumCRC, sum: "de86ec5d", err: true},
+		{name: "openssl output without -r", algorithm: ChecksumSHA1, sum: "SHA1(stdin)=", err: true},
+		{name: "odd number of digits", algorithm: ChecksumSHA1, sum: "abc", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := normalizeChecksum(test.algorithm, test.sum)
+			if test.err {
+				if err == nil {
+					t.Errorf("normalizeChecksum(%q) = %q, want an error", test.sum, got)
+				}
+				return
+			}
+			if err != nil || got != test.want {
+				t.Errorf("normalizeChecksum(%q) = %q, %v, want %q", test.sum, got, err, test.want)
+			}
+		})
+	}
+}
+
+// TestPosixChecksumOfCommandOutput checks with the local 'cksum' that checksums computed locally agree with
+// those computed in containers.
+func TestPosixChecksumOfCommandOutput(t *testing.T) {
+	if _, err := exec.LookPath("cksum"); err != nil {
+		t.Skip("no cksum available")
+	}
+	data := append([]byte("name with spaces\nand newlines\n"), bytes.Repeat([]byte{0xff}, 300)...)
+	output := runShell(t, `{ printf 'name with spaces\nand newlines\n'; head -c 300 /dev/zero | tr '\000' '\377'; } | cksum`)
+	sum, _, _ := strings.Cut(string(output), " ")
+
+	got, err := normalizeChecksum(ChecksumCRC, sum)
+	if want := fmt.Sprintf("%08x", posixChecksum(data)); err != nil || got != want {
+		t.Errorf("cksum printed %q, normalized to %q, %v, want %q", output, got, err, want)
+	}
+}
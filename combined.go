@@ -0,0 +1,36 @@
+package k8sexec
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// WithCombinedOutput merges standard output and standard error into a single stream reported in Stdout,
+// in the order the chunks of both streams are received, the way 'kubectl exec' shows them. Stderr is left empty.
+func WithCombinedOutput() ExecOption {
+	return func(config *execConfig) {
+		config.combined = true
+	}
+}
+
+// ExecCombined executes a command provided through standard input ('stdin') or as arguments ('args'),
+// or a combination of both, and reports its standard output and standard error multiplexed into a single
+// ordered stream in Stdout. Separate buffers lose the relative ordering of the streams, which matters when
+// diagnosing failures of scripts mixing progress output with error messages.
+// The use of this function must provide a context that will govern the command execution.
+func (k8s *K8SExec) ExecCombined(ctx context.Context, podName string, containerName string, args []string, stdin io.Reader) *ExecutionStatus {
+	return k8s.ExecWithOptions(ctx, podName, containerName, args, WithStdin(stdin), WithCombinedOutput())
+}
+
+// syncWriter serializes writes of several streams to a single writer.
+type syncWriter struct {
+	mu     sync.Mutex
+	writer io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.writer.Write(p)
+}
@@ -0,0 +1,70 @@
+package k8sexec
+
+import (
+	"strings"
+)
+
+// Command builds shell command lines which are safe to execute with 'sh -c', e.g.:
+//
+//	cmd := NewCommand("cat").QuotedArg(path).Arg("2>/dev/null").Pipe("head").Arg("-n", "10")
+//	status := k8s.Exec(pod, container, cmd.Sh(), nil, 0)
+//
+// Arguments are added either verbatim with Arg, which is meant for trusted shell words like flags, redirections
+// or globs, or with QuotedArg, which quotes untrusted values such as paths or patterns so the shell passes them
+// to the command unchanged, whatever quotes, spaces or metacharacters they contain. Using QuotedArg instead of
+// formatting values into scripts with fmt.Sprintf prevents command injection.
+type Command struct {
+	stages [][]string
+}
+
+// NewCommand starts a command line with the command 'name' and its arguments 'args', which are quoted.
+func NewCommand(name string, args ...string) *Command {
+	return (&Command{stages: [][]string{{}}}).Arg(quoteCommandName(name)).QuotedArg(args...)
+}
+
+// quoteCommandName quotes the name of a command like shellQuote. Names containing '=' are always quoted, since
+// the shell would take an unquoted one for a variable assignment and run the next word as the command.
+func quoteCommandName(name string) string {
+	if strings.Contains(name, "=") {
+		return "'" + strings.ReplaceAll(name, "'", `'\''`) + "'"
+	}
+	return shellQuote(name)
+}
+
+// Arg appends shell words to the current stage of the command line verbatim, without quoting them.
+// It must only be used with trusted values.
+func (cmd *Command) Arg(words ...string) *Command {
+	last := len(cmd.stages) - 1
+	cmd.stages[last] = append(cmd.stages[last], words...)
+	return cmd
+}
+
+// QuotedArg appends arguments to the current stage of the command line, quoted so that they are passed
+// to the command verbatim.
+func (cmd *Command) QuotedArg(args ...string) *Command {
+	for _, arg := range args {
+		cmd.Arg(shellQuote(arg))
+	}
+	return cmd
+}
+
+// Pipe starts a new stage of the command line, the command 'name' with its quoted arguments 'args',
+// reading the standard output of the previous stage.
+func (cmd *Command) Pipe(name string, args ...string) *Command {
+	cmd.stages = append(cmd.stages, []string{})
+	return cmd.Arg(quoteCommandName(name)).QuotedArg(args...)
+}
+
+// String returns the command line.
+func (cmd *Command) String() string {
+	stages := make([]string, len(cmd.stages))
+	for i, stage := range cmd.stages {
+		stages[i] = strings.Join(stage, " ")
+	}
+	return strings.Join(stages, " | ")
+}
+
+// Sh returns the arguments executing the command line with 'sh -c', to be passed to Exec and its variants.
+func (cmd *Command) Sh() []string {
+	return []string{"sh", "-c", cmd.String()}
+}
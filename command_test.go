@@ -0,0 +1,68 @@
+package k8sexec
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestCommandString(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  *Command
+		want string
+	}{
+		{name: "plain words", cmd: NewCommand("ls", "-la", "/etc/app.d"), want: "ls -la /etc/app.d"},
+		{name: "empty argument", cmd: NewCommand("test", "-n", ""), want: "test -n ''"},
+		{name: "spaces", cmd: NewCommand("cat", "/data/my file"), want: "cat '/data/my file'"},
+		{name: "single quotes", cmd: NewCommand("echo", "it's"), want: `echo 'it'\''s'`},
+		{name: "verbatim words", cmd: NewCommand("cat").QuotedArg("/var/log/*.log").Arg("2>/dev/null"), want: "cat '/var/log/*.log' 2>/dev/null"},
+		{name: "pipeline", cmd: NewCommand("cat", "a b").Pipe("head").Arg("-n", "10"), want: "cat 'a b' | head -n 10"},
+		{name: "assignment-like command name", cmd: NewCommand("PATH=/tmp", "sh"), want: "'PATH=/tmp' sh"},
+		{name: "assignment-like argument", cmd: NewCommand("env", "A=1"), want: "env A=1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.cmd.String(); got != test.want {
+				t.Errorf("String() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+// TestCommandQuotingInShell checks with the local sh that quoted arguments reach the command verbatim.
+func TestCommandQuotingInShell(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh available")
+	}
+	tests := []struct {
+		name string
+		arg  string
+	}{
+		{name: "empty", arg: ""},
+		{name: "spaces", arg: "  leading and trailing  "},
+		{name: "newlines", arg: "line 1\nline 2\n"},
+		{name: "quotes", arg: `'single' "double" it's`},
+		{name: "command substitution", arg: "$(echo injected) `echo injected`"},
+		{name: "parameters", arg: "$HOME ${PATH} $1 $@"},
+		{name: "globs", arg: "/* ?.txt [ab]"},
+		{name: "metacharacters", arg: "a; echo injected & b | c > /tmp/x < /etc/passwd"},
+		{name: "backslashes", arg: `\n \\ \' \`},
+		{name: "tilde and hash", arg: "~ #comment"},
+		{name: "unicode", arg: "zażółć gęślą jaźń"},
+		{name: "leading dash", arg: "-n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := NewCommand("printf", "[%s]").QuotedArg(test.arg).Pipe("cat")
+			output, err := exec.Command("sh", "-c", cmd.String()).Output()
+			if err != nil {
+				t.Fatalf("running %q: %v", cmd.String(), err)
+			}
+			if want := "[" + test.arg + "]"; string(output) != want {
+				t.Errorf("%q printed %q, want %q", cmd.String(), output, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/hhruszka/k8sexec/internal/textdiff"
+	"slices"
+	"sync"
+	"unicode/utf8"
+)
+
+// FileVariant is a distinct content of a file found by CompareFile, along with the containers holding it.
+type FileVariant struct {
+	// SHA256 is the hex-encoded SHA-256 checksum of the content.
+	SHA256 string `json:"SHA256"`
+	// Size is the size of the content in bytes.
+	Size int `json:"Size"`
+	// Targets are the containers holding the content, in the order of the compared targets.
+	Targets []Target `json:"Targets"`
+	// Diff is a unified diff from the most common variant to this one. It is empty for the most common variant,
+	// and only notes the difference if either content is binary.
+	Diff string `json:"Diff,omitempty"`
+}
+
+// FileComparison is the result of CompareFile.
+type FileComparison struct {
+	Path string `json:"Path"`
+	// Variants are the distinct contents of the file, the most common first.
+	Variants []FileVariant `json:"Variants"`
+	// Failures holds statuses of containers from which the file could not be read.
+	Failures []*ExecutionStatus `json:"Failures,omitempty"`
+}
+
+// Identical reports whether the file was read from all containers and has the same content in all of them.
+func (comparison *FileComparison) Identical() bool {
+	return len(comparison.Variants) <= 1 && len(comparison.Failures) == 0
+}
+
+// CompareFile reads the file 'path' from every target concurrently, with ReadFile, and groups the targets by
+// the content of the file, detecting configuration drift across replicas. Variants are identified by their
+// SHA-256 checksums and sorted by the number of targets holding them, the most common variant first; every
+// other variant carries a unified diff from the most common one. Concurrency and timeouts of the reads are
+// controlled by options.Concurrency (or options.Limiter) and options.Timeout; other batch options are ignored.
+// Targets from which the file could not be read, e.g. because it is missing, are reported in Failures.
+// The comparison is governed by the provided context.
+func (k8s *K8SExec) CompareFile(ctx context.Context, targets []Target, path string, options BatchOptions) (*FileComparison, error) {
+	contents := make([][]byte, len(targets))
+	failures := make([]*ExecutionStatus, len(targets))
+	var wg sync.WaitGroup
+	limiter := options.ConcurrencyLimiter()
+	for i, target := range targets {
+		if err := limiter.Acquire(ctx); err != nil {
+			failures[i] = NewExecutionStatus(target.Pod, target.Container, ContextExitCode(err), err.Error(), "", "")
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, target Target) {
+			defer wg.Done()
+			defer limiter.Release()
+			ctx, cancel := context.WithTimeout(ctx, k8s.ExecTimeout(options.Timeout))
+			defer cancel()
+
+			content, status, err := k8s.ReadFile(ctx, target.Pod, target.Container, path)
+			if err != nil {
+				retCode := InternalAppError
+				if status != nil {
+					retCode = status.RetCode
+				}
+				failures[i] = NewExecutionStatus(target.Pod, target.Container, retCode, err.Error(), "", "")
+				failures[i].Err = err
+				return
+			}
+			contents[i] = content
+		}(i, target)
+	}
+	wg.Wait()
+
+	comparison := &FileComparison{Path: path}
+	var variantContents [][]byte
+	index := make(map[string]int)
+	for i, target := range targets {
+		if failures[i] != nil {
+			comparison.Failures = append(comparison.Failures, failures[i])
+			continue
+		}
+		sum := sha256.Sum256(contents[i])
+		checksum := hex.EncodeToString(sum[:])
+		variant, ok := index[checksum]
+		if !ok {
+			variant = len(comparison.Variants)
+			index[checksum] = variant
+			comparison.Variants = append(comparison.Variants, FileVariant{SHA256: checksum, Size: len(contents[i])})
+			variantContents = append(variantContents, contents[i])
+		}
+		comparison.Variants[variant].Targets = append(comparison.Variants[variant].Targets, target)
+	}
+
+	order := make([]int, len(comparison.Variants))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return len(comparison.Variants[b].Targets) - len(comparison.Variants[a].Targets)
+	})
+	variants := make([]FileVariant, len(order))
+	for i, variant := range order {
+		variants[i] = comparison.Variants[variant]
+		if i > 0 {
+			reference := variants[0].Targets[0]
+			variants[i].Diff = fileDiff(
+				fmt.Sprintf("%s/%s:%s", reference.Pod, reference.Container, path),
+				fmt.Sprintf("%s/%s:%s", variants[i].Targets[0].Pod, variants[i].Targets[0].Container, path),
+				variantContents[order[0]], variantContents[variant])
+		}
+	}
+	comparison.Variants = variants
+	return comparison, nil
+}
+
+// fileDiff returns a unified diff of two file contents, or a note that they differ if either is binary.
+func fileDiff(fromName string, toName string, from []byte, to []byte) string {
+	if isBinary(from) || isBinary(to) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", fromName, toName)
+	}
+	return textdiff.Unified(fromName, toName, string(from), string(to))
+}
+
+// isBinary reports whether a content is binary rather than text: it contains NUL bytes or is not valid UTF-8.
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content, 0) >= 0 || !utf8.Valid(content)
+}
@@ -0,0 +1,81 @@
+package k8sexec
+
+import (
+	"context"
+	"sync"
+)
+
+// ConcurrencyLimiter bounds the number of operations running at the same time. Unlike a fixed-size semaphore,
+// its limit can be changed while operations are in flight: raising it immediately admits waiting operations,
+// and lowering it lets running operations complete while new ones wait until the number of running operations
+// drops below the new limit. It is used by batch runs (see BatchOptions.Limiter) to let operators dial a scan
+// down or up without restarting it.
+type ConcurrencyLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	active  int
+	changed chan struct{}
+}
+
+// NewConcurrencyLimiter creates a limiter allowing 'limit' operations at the same time. Limits lower than one
+// are raised to one.
+func NewConcurrencyLimiter(limit int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{limit: max(limit, 1), changed: make(chan struct{})}
+}
+
+// Acquire waits until the number of running operations is below the limit and registers a new one.
+// It returns the context's error if 'ctx' is done first.
+func (limiter *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	for {
+		limiter.mu.Lock()
+		if limiter.active < limiter.limit {
+			limiter.active++
+			limiter.mu.Unlock()
+			return nil
+		}
+		changed := limiter.changed
+		limiter.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Release unregisters an operation registered by Acquire.
+func (limiter *ConcurrencyLimiter) Release() {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.active--
+	limiter.notify()
+}
+
+// SetLimit changes the limit; limits lower than one are raised to one.
+func (limiter *ConcurrencyLimiter) SetLimit(limit int) {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	limiter.limit = max(limit, 1)
+	limiter.notify()
+}
+
+// Limit returns the current limit.
+func (limiter *ConcurrencyLimiter) Limit() int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	return limiter.limit
+}
+
+// Active returns the number of operations currently running.
+func (limiter *ConcurrencyLimiter) Active() int {
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	return limiter.active
+}
+
+// notify wakes up all waiting operations; it must be called with the mutex held.
+func (limiter *ConcurrencyLimiter) notify() {
+	close(limiter.changed)
+	limiter.changed = make(chan struct{})
+}
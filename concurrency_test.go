@@ -0,0 +1,207 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	exec2 "k8s.io/client-go/util/exec"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// testKubeconfig points at a cluster which is never contacted, since executions go to fakeBackend.
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: test
+  cluster:
+    server: https://127.0.0.1:6443
+    insecure-skip-tls-verify: true
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+current-context: test
+users:
+- name: test
+  user:
+    token: test
+`
+
+// fakeBackend executes commands without a cluster. Like the SPDY backend, it takes executors from the executor
+// cache for every execution and hands them back afterwards, so the cache and the transport cache are exercised
+// by concurrent executions. Only "sh" is available in the fake containers, and commands named "restart" behave
+// like executions interrupted by a container restart.
+type fakeBackend struct {
+	streams atomic.Int64
+}
+
+func (backend *fakeBackend) Stream(ctx context.Context, k8s *K8SExec, request ExecRequest) error {
+	backend.streams.Add(1)
+	if request.Stdin == nil && request.Stdout == nil && request.Stderr == nil {
+		return errors.New("you must specify at least 1 of stdin, stdout, stderr")
+	}
+
+	if k8s.executors != nil {
+		execURL := k8s.execURL(request)
+		executor, err := k8s.executorFor(execURL)
+		if err != nil {
+			return fmt.Errorf("creating executor: %w", err)
+		}
+		container := request.Namespace + "/" + request.Pod + "/" + request.Container
+		if request.Command[0] == "restart" {
+			k8s.executors.evictContainer(container)
+		} else {
+			k8s.executors.release(execURL, container, executor)
+		}
+	}
+
+	switch request.Command[0] {
+	case "sh":
+		if request.Stdin == nil {
+			return nil
+		}
+		// scripts are delivered via standard input and echoed back
+		script, err := io.ReadAll(request.Stdin)
+		if err != nil {
+			return err
+		}
+		if request.Stdout != nil {
+			_, err = request.Stdout.Write(script)
+		}
+		return err
+	case "echo":
+		if request.Stdout != nil {
+			_, err := io.WriteString(request.Stdout, strings.Join(request.Command[1:], " "))
+			return err
+		}
+		return nil
+	case "false":
+		return exec2.CodeExitError{Err: errors.New("command terminated with exit code 1"), Code: 1}
+	}
+	return exec2.CodeExitError{Err: errors.New("command terminated with exit code 127"), Code: 127}
+}
+
+// newTestK8SExec returns an instance created by NewK8SExec, executing commands with 'backend'.
+func newTestK8SExec(t *testing.T, backend ExecBackend, opts ...Option) *K8SExec {
+	t.Helper()
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(kubeconfig, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	k8s, err := NewK8SExec(kubeconfig, "default", append(opts, WithBackend(backend))...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k8s
+}
+
+// TestConcurrentExecutions runs executions of all kinds on a shared instance, and on instances derived from it,
+// from many goroutines at once. It is meant to be run with 'go test -race', which reports unguarded accesses to
+// the shell cache, the transport cache and the executor cache.
+func TestConcurrentExecutions(t *testing.T) {
+	const workers = 32
+	const rounds = 20
+
+	backend := &fakeBackend{}
+	k8s := newTestK8SExec(t, backend, WithExecutorCache(8))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds)
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			instance := k8s
+			if worker%4 == 0 {
+				instance = k8s.WithNamespace(fmt.Sprintf("namespace-%d", worker%3))
+			}
+			for round := 0; round < rounds; round++ {
+				pod := fmt.Sprintf("pod-%d", (worker+round)%5)
+				container := fmt.Sprintf("container-%d", round%3)
+
+				switch round % 6 {
+				case 0:
+					message := fmt.Sprintf("%d-%d", worker, round)
+					status := instance.ExecWithContext(ctx, pod, container, []string{"echo", message}, nil)
+					if status.RetCode != Success || strings.Join(status.Stdout, "\n") != message {
+						errs <- fmt.Errorf("echo %s: exit code %d, stdout %q", message, status.RetCode, status.Stdout)
+					}
+				case 1:
+					shell, err := instance.DetectShell(ctx, pod, container)
+					if err != nil || !slices.Equal(shell, []string{"sh"}) {
+						errs <- fmt.Errorf("detecting shell: %v, %v", shell, err)
+					}
+				case 2:
+					script := fmt.Sprintf("echo %d-%d", worker, round)
+					status := instance.ExecScriptWithContext(ctx, pod, container, script)
+					if status.RetCode != Success || strings.Join(status.Stdout, "\n") != script {
+						errs <- fmt.Errorf("script %q: exit code %d, stdout %q", script, status.RetCode, status.Stdout)
+					}
+				case 3:
+					status := instance.ExecWithOptions(ctx, pod, container, []string{"false"}, WithCapture(CaptureExitCode))
+					if status.RetCode != 1 {
+						errs <- fmt.Errorf("false: exit code %d", status.RetCode)
+					}
+				case 4:
+					retCode, err := instance.ExecStream(ctx, pod, container, []string{"echo", "dropped"}, nil, nil, nil)
+					if retCode != Success || err != nil {
+						errs <- fmt.Errorf("streaming without outputs: exit code %d, %v", retCode, err)
+					}
+				case 5:
+					instance.ExecWithContext(ctx, pod, container, []string{"restart"}, nil)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if backend.streams.Load() == 0 {
+		t.Fatal("no executions reached the backend")
+	}
+	k8s.executors.mu.Lock()
+	defer k8s.executors.mu.Unlock()
+	if k8s.executors.lru.Len() > 8 || len(k8s.executors.entries) != k8s.executors.lru.Len() {
+		t.Errorf("executor cache holds %d executors and %d keys, want at most 8 of both", k8s.executors.lru.Len(),
+			len(k8s.executors.entries))
+	}
+}
+
+// TestConcurrentTransportCache builds round trippers from a shared transport cache from many goroutines at once,
+// which must all be built on the same TLS configuration.
+func TestConcurrentTransportCache(t *testing.T) {
+	k8s := newTestK8SExec(t, &fakeBackend{}, WithTransportCache())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := k8s.transports.roundTripperFor(k8s.Config, nil); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	if k8s.transports.tlsConfig == nil || k8s.transports.tlsConfig.ClientSessionCache == nil {
+		t.Error("transport cache holds no TLS configuration with a session cache")
+	}
+}
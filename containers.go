@@ -0,0 +1,38 @@
+package k8sexec
+
+import (
+	"github.com/hhruszka/k8sexec/discovery"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ContainerState is the state of a container as reported by the kubelet.
+type ContainerState = discovery.ContainerState
+
+const (
+	ContainerRunning      = discovery.ContainerRunning
+	ContainerWaiting      = discovery.ContainerWaiting
+	ContainerTerminated   = discovery.ContainerTerminated
+	ContainerStateUnknown = discovery.ContainerStateUnknown
+)
+
+// ContainerInfo is a snapshot of a container's configuration and status.
+// Reason and Message explain the waiting or terminated state (e.g. "CrashLoopBackOff", "OOMKilled"),
+// and ExitCode is set for terminated containers.
+type ContainerInfo = discovery.ContainerInfo
+
+// GetContainers retrieves the pod with the given name and returns a snapshot of its init containers and
+// containers: names, images, states with reasons, restart counts and resource requests and limits.
+// Batch runners can use it to skip containers that are not running and to annotate failures.
+func (k8s *K8SExec) GetContainers(podName string) ([]ContainerInfo, error) {
+	pod, err := k8s.GetPod(podName, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return ContainersOf(pod), nil
+}
+
+// ContainersOf returns a snapshot of init containers and containers of an already retrieved pod.
+func ContainersOf(pod *coreV1.Pod) []ContainerInfo {
+	return discovery.ContainersOf(pod)
+}
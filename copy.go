@@ -0,0 +1,364 @@
+package k8sexec
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// untarScript extracts a tar archive read from standard input into the directory given as the first parameter,
+// creating the directory if needed, like 'kubectl cp' does.
+const untarScript = `mkdir -p "$1" && tar -xmf - -C "$1"`
+
+// writeBase64FileScript decodes base64 read from standard input into the file given as the first parameter,
+// creating its directory if needed, and sets its permissions to the octal mode given as the second parameter.
+const writeBase64FileScript = `mkdir -p "$(dirname "$1")" && base64 -d > "$1" && chmod "$2" "$1"`
+
+// CopyToPod copies the local file or directory 'localPath' to 'remotePath' in a container, identified by
+// the container's name and the associated pod's name, like 'kubectl cp' does: a directory is copied with its
+// whole content, and the copy is named after 'remotePath', whose parent directory is created if it does not
+// exist. The content is packed into a tar archive streamed to 'tar' in the container, without being buffered
+// in memory. Containers lacking 'tar' receive files one by one, base64-encoded and decoded with 'sh' and
+// 'base64'; if neither is available, an error wrapping ErrUtilNotFound is returned. Permissions of files and
+// symbolic links are preserved. The copy is governed by the provided context.
+func (k8s *K8SExec) CopyToPod(ctx context.Context, localPath string, podName string, containerName string, remotePath string) error {
+	if _, err := os.Lstat(localPath); err != nil {
+		return fmt.Errorf("copying %s: %w", localPath, err)
+	}
+	available, err := k8s.CheckUtilsInContainerWithContext(ctx, podName, containerName, []string{"tar", "base64"})
+	if err != nil {
+		return err
+	}
+	remotePath = path.Clean(remotePath)
+
+	switch {
+	case available["tar"]:
+		return k8s.copyToPodWithTar(ctx, localPath, podName, containerName, remotePath)
+	case available["base64"]:
+		return k8s.copyToPodWithBase64(ctx, localPath, podName, containerName, remotePath)
+	}
+	return fmt.Errorf("copying to %s/%s: %w: tar or base64", podName, containerName, ErrUtilNotFound)
+}
+
+// copyToPodWithTar streams a tar archive of 'localPath' to 'tar' in the container.
+func (k8s *K8SExec) copyToPodWithTar(ctx context.Context, localPath string, podName string, containerName string, remotePath string) error {
+	reader, writer := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := writeTar(writer, localPath, path.Base(remotePath))
+		_ = writer.CloseWithError(err)
+		done <- err
+	}()
+
+	var stderr bytes.Buffer
+	cmd := []string{"sh", "-c", untarScript, "sh", path.Dir(remotePath)}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, reader, nil, &stderr)
+	// unblock the archive writer if the command stopped reading it
+	_ = reader.Close()
+	// a local file which could not be archived is the cause of a failed extraction, if any; a closed pipe is
+	// merely a consequence of the failed execution
+	if writeErr := <-done; writeErr != nil && !errors.Is(writeErr, io.ErrClosedPipe) {
+		return fmt.Errorf("copying to %s/%s:%s: archiving %s: %w", podName, containerName, remotePath, localPath, writeErr)
+	}
+	return copyError("copying to", podName, containerName, remotePath, retCode, err, &stderr)
+}
+
+// writeTar writes a tar archive of 'localPath' to 'w', with entries named relative to 'name'.
+func writeTar(w io.Writer, localPath string, name string) error {
+	archive := tar.NewWriter(w)
+	err := filepath.WalkDir(localPath, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		var link string
+		if info.Mode()&fs.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(localPath, file)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(name, filepath.ToSlash(relative))
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFile(archive, file)
+	})
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// copyFile copies the content of the local file to 'w'.
+func copyFile(w io.Writer, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// copyToPodWithBase64 copies 'localPath' entry by entry, creating directories and symbolic links with single
+// commands and streaming files base64-encoded to the container.
+func (k8s *K8SExec) copyToPodWithBase64(ctx context.Context, localPath string, podName string, containerName string, remotePath string) error {
+	return filepath.WalkDir(localPath, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(localPath, file)
+		if err != nil {
+			return err
+		}
+		target := path.Join(remotePath, filepath.ToSlash(relative))
+		mode := strconv.FormatUint(uint64(info.Mode().Perm()), 8)
+
+		var cmd []string
+		var stdin io.Reader
+		switch {
+		case info.IsDir():
+			cmd = []string{"mkdir", "-p", target}
+		case info.Mode()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			cmd = []string{"ln", "-sfn", link, target}
+		case info.Mode().IsRegular():
+			reader, writer := io.Pipe()
+			go func() {
+				encoder := base64.NewEncoder(base64.StdEncoding, writer)
+				err := copyFile(encoder, file)
+				if err == nil {
+					err = encoder.Close()
+				}
+				_ = writer.CloseWithError(err)
+			}()
+			defer reader.Close()
+			cmd, stdin = []string{"sh", "-c", writeBase64FileScript, "sh", target, mode}, reader
+		default:
+			k8s.logger().Debug("skipping special file", "path", file)
+			return nil
+		}
+
+		var stderr bytes.Buffer
+		retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, stdin, nil, &stderr)
+		return copyError("copying to", podName, containerName, target, retCode, err, &stderr)
+	})
+}
+
+// copyError returns the error of a failed copy command, or nil if it succeeded.
+func copyError(operation string, podName string, containerName string, remotePath string, retCode ExitCode, err error, stderr *bytes.Buffer) error {
+	switch {
+	case err != nil && retCode < Success:
+		return fmt.Errorf("%s %s/%s:%s: %w", operation, podName, containerName, remotePath, err)
+	case retCode != Success:
+		return fmt.Errorf("%s %s/%s:%s: exit code %d: %s", operation, podName, containerName, remotePath, retCode, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// ErrChecksumMismatch is returned by CopyFromPod when a downloaded file differs from the file in the container.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// tarScript writes a tar archive of the entry given as the second parameter, relative to the directory given as
+// the first parameter, to standard output.
+const tarScript = `cd "$1" && tar -cf - "$2"`
+
+// checksumsScript prints SHA-256 checksums of all regular files of the entry given as the second parameter,
+// relative to the directory given as the first parameter.
+const checksumsScript = `cd "$1" && find "$2" -type f -exec sha256sum {} +`
+
+// CopyFromPod copies the file or directory 'remotePath' in a container, identified by the container's name and
+// the associated pod's name, to 'localPath', like 'kubectl cp' does: a directory is copied with its whole content,
+// and the copy is named after 'localPath'. A tar archive of the remote path is streamed from the container and
+// extracted on the fly, preserving permissions and symbolic links; entries which would be extracted outside of
+// 'localPath' are rejected. If the container provides 'sha256sum', the checksums of the copied files are verified
+// against the files in the container afterwards, and a mismatch is reported with an error wrapping
+// ErrChecksumMismatch; files modified in the container while being copied are reported this way as well.
+// The copy is governed by the provided context.
+func (k8s *K8SExec) CopyFromPod(ctx context.Context, podName string, containerName string, remotePath string, localPath string) error {
+	remotePath = path.Clean(remotePath)
+	dir, base := path.Dir(remotePath), path.Base(remotePath)
+
+	reader, writer := io.Pipe()
+	checksums := make(map[string]string)
+	extracted := make(chan error, 1)
+	go func() {
+		err := extractTar(reader, base, localPath, checksums)
+		// drain the archive, so the command is not blocked writing it
+		_, _ = io.Copy(io.Discard, reader)
+		extracted <- err
+	}()
+
+	var stderr bytes.Buffer
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, []string{"sh", "-c", tarScript, "sh", dir, base}, nil, writer, &stderr)
+	_ = writer.Close()
+	extractErr := <-extracted
+	if err := copyError("copying from", podName, containerName, remotePath, retCode, err, &stderr); err != nil {
+		return err
+	}
+	if extractErr != nil {
+		return fmt.Errorf("copying from %s/%s:%s: %w", podName, containerName, remotePath, extractErr)
+	}
+
+	return k8s.verifyChecksums(ctx, podName, containerName, dir, base, checksums)
+}
+
+// extractTar extracts a tar archive of entries named relative to 'name' into 'localPath', and records SHA-256
+// checksums of extracted files keyed by their names in the archive.
+func extractTar(r io.Reader, name string, localPath string, checksums map[string]string) error {
+	archive := tar.NewReader(r)
+	var symlinks []string
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		entry := path.Clean(header.Name)
+		relative, ok := strings.CutPrefix(entry, name)
+		if !ok || relative != "" && !strings.HasPrefix(relative, "/") {
+			return fmt.Errorf("unexpected archive entry %q", header.Name)
+		}
+		for _, symlink := range symlinks {
+			// writing to or through an extracted symbolic link could escape 'localPath'
+			if entry == symlink || strings.HasPrefix(entry, symlink+"/") {
+				return fmt.Errorf("archive entry %q is located at or below symbolic link %q", header.Name, symlink)
+			}
+		}
+		target := filepath.Join(localPath, filepath.FromSlash(relative))
+		mode := fs.FileMode(header.Mode).Perm()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := removeNonDirectory(target); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(target, 0o700); err != nil {
+				return err
+			}
+			// directories are made accessible while being extracted; their permissions are restored below
+			defer chmodDirectory(target, mode)
+		case tar.TypeSymlink:
+			_ = os.Remove(target)
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+			symlinks = append(symlinks, entry)
+		case tar.TypeReg:
+			hash := sha256.New()
+			if err := writeLocalFile(target, mode, io.TeeReader(archive, hash)); err != nil {
+				return err
+			}
+			checksums[entry] = hex.EncodeToString(hash.Sum(nil))
+		}
+	}
+}
+
+// removeNonDirectory removes the local file 'target' unless it is a directory or does not exist, so it can be
+// created anew without following a symbolic link left at its place.
+func removeNonDirectory(target string) error {
+	info, err := os.Lstat(target)
+	if errors.Is(err, fs.ErrNotExist) || err == nil && info.IsDir() {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.Remove(target)
+}
+
+// chmodDirectory sets the permissions of the local directory 'target', unless it has been replaced with
+// something else, e.g. a symbolic link, whose target must not be changed.
+func chmodDirectory(target string, mode fs.FileMode) {
+	if info, err := os.Lstat(target); err == nil && info.IsDir() {
+		_ = os.Chmod(target, mode)
+	}
+}
+
+// writeLocalFile writes the content read from 'r' to the local file 'target' with the permissions 'mode'.
+// A file or symbolic link already present at 'target' is replaced rather than written through.
+func writeLocalFile(target string, mode fs.FileMode, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+		return err
+	}
+	if err := removeNonDirectory(target); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		return err
+	}
+	// permissions of created files are masked by umask; setting them on the open file follows no link
+	if err := f.Chmod(mode); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// verifyChecksums compares the checksums of the copied files with the checksums of the files in the container.
+// The verification is skipped if the container does not provide 'sha256sum'.
+func (k8s *K8SExec) verifyChecksums(ctx context.Context, podName string, containerName string, dir string, base string, checksums map[string]string) error {
+	var stdout, stderr bytes.Buffer
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, []string{"sh", "-c", checksumsScript, "sh", dir, base}, nil, &stdout, &stderr)
+	if err != nil && retCode < Success {
+		return fmt.Errorf("verifying copy of %s/%s:%s: %w", podName, containerName, path.Join(dir, base), err)
+	}
+	if retCode != Success {
+		k8s.logger().Warn("cannot verify checksums of copied files", "pod", podName, "container", containerName,
+			"exitCode", retCode, "stderr", strings.TrimSpace(stderr.String()))
+		return nil
+	}
+
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		checksum, file, ok := strings.Cut(line, "  ")
+		if !ok {
+			continue
+		}
+		file = path.Clean(file)
+		if local, copied := checksums[file]; !copied || local != checksum {
+			return fmt.Errorf("verifying copy of %s/%s:%s: %w: %s", podName, containerName, path.Join(dir, file), ErrChecksumMismatch, file)
+		}
+	}
+	return nil
+}
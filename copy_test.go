@@ -0,0 +1,162 @@
+package k8sexec
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tarEntry is an entry of an archive built by buildTar.
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	mode     int64
+	content  string
+}
+
+// buildTar returns a tar archive of the entries.
+func buildTar(t *testing.T, entries []tarEntry) *bytes.Buffer {
+	t.Helper()
+	var buffer bytes.Buffer
+	archive := tar.NewWriter(&buffer)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Typeflag: entry.typeflag, Linkname: entry.linkname, Mode: entry.mode, Size: int64(len(entry.content))}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buffer
+}
+
+func TestExtractTarRejectsWritesThroughSymlinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries func(outside string) []tarEntry
+	}{
+		{
+			name: "regular file replacing an extracted link",
+			entries: func(outside string) []tarEntry {
+				return []tarEntry{
+					{name: "data", typeflag: tar.TypeDir, mode: 0o755},
+					{name: "data/link", typeflag: tar.TypeSymlink, linkname: filepath.Join(outside, "victim")},
+					{name: "data/link", typeflag: tar.TypeReg, mode: 0o777, content: "pwned"},
+				}
+			},
+		},
+		{
+			name: "directory replacing an extracted link",
+			entries: func(outside string) []tarEntry {
+				return []tarEntry{
+					{name: "data", typeflag: tar.TypeDir, mode: 0o755},
+					{name: "data/link", typeflag: tar.TypeSymlink, linkname: outside},
+					{name: "data/link", typeflag: tar.TypeDir, mode: 0o777},
+				}
+			},
+		},
+		{
+			name: "file below an extracted link",
+			entries: func(outside string) []tarEntry {
+				return []tarEntry{
+					{name: "data", typeflag: tar.TypeDir, mode: 0o755},
+					{name: "data/link", typeflag: tar.TypeSymlink, linkname: outside},
+					{name: "data/link/victim", typeflag: tar.TypeReg, mode: 0o777, content: "pwned"},
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			outside := t.TempDir()
+			victim := filepath.Join(outside, "victim")
+			if err := os.WriteFile(victim, []byte("original"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			local := filepath.Join(t.TempDir(), "data")
+
+			err := extractTar(buildTar(t, test.entries(outside)), "data", local, make(map[string]string))
+			if err == nil {
+				t.Error("extractTar accepted an entry written through a symbolic link")
+			}
+			content, _ := os.ReadFile(victim)
+			info, _ := os.Stat(victim)
+			if string(content) != "original" || info.Mode().Perm() != 0o600 {
+				t.Errorf("file outside the destination changed: content %q, mode %v", content, info.Mode().Perm())
+			}
+			if info, _ := os.Stat(outside); info.Mode().Perm() == 0o777 {
+				t.Error("directory outside the destination was made world-writable")
+			}
+		})
+	}
+}
+
+func TestExtractTarReplacesExistingSymlinks(t *testing.T) {
+	outside := t.TempDir()
+	victim := filepath.Join(outside, "victim")
+	if err := os.WriteFile(victim, []byte("original"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	local := filepath.Join(t.TempDir(), "data")
+	if err := os.Mkdir(local, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(victim, filepath.Join(local, "file")); err != nil {
+		t.Fatal(err)
+	}
+
+	checksums := make(map[string]string)
+	archive := buildTar(t, []tarEntry{
+		{name: "data", typeflag: tar.TypeDir, mode: 0o755},
+		{name: "data/file", typeflag: tar.TypeReg, mode: 0o640, content: "copied"},
+	})
+	if err := extractTar(archive, "data", local, checksums); err != nil {
+		t.Fatal(err)
+	}
+
+	if content, _ := os.ReadFile(victim); string(content) != "original" {
+		t.Errorf("file outside the destination was overwritten with %q", content)
+	}
+	info, err := os.Lstat(filepath.Join(local, "file"))
+	if err != nil || !info.Mode().IsRegular() || info.Mode().Perm() != 0o640 {
+		t.Fatalf("extracted file: %v, %v", info, err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(local, "file")); string(content) != "copied" {
+		t.Errorf("extracted file holds %q", content)
+	}
+	if _, ok := checksums["data/file"]; !ok {
+		t.Errorf("no checksum recorded for the extracted file: %v", checksums)
+	}
+}
+
+// stdinDiscardingBackend reads and drops the standard input of every execution, ignoring read errors like
+// the SPDY backend, which does not report failures of the local input to the caller.
+type stdinDiscardingBackend struct{}
+
+func (stdinDiscardingBackend) Stream(ctx context.Context, k8s *K8SExec, request ExecRequest) error {
+	if request.Stdin != nil {
+		_, _ = io.Copy(io.Discard, request.Stdin)
+	}
+	return nil
+}
+
+func TestCopyToPodWithTarReportsArchivingErrors(t *testing.T) {
+	k8s := newTestK8SExec(t, stdinDiscardingBackend{})
+
+	err := k8s.copyToPodWithTar(context.Background(), filepath.Join(t.TempDir(), "missing"), "web", "app", "/tmp/missing")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("copying a missing local file: %v, want an error wrapping fs.ErrNotExist", err)
+	}
+}
@@ -0,0 +1,53 @@
+package k8sexec
+
+import (
+	"context"
+	"fmt"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"slices"
+)
+
+// DefaultContainerAnnotation is the annotation kubectl uses to select the container of a pod when none is given.
+const DefaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+// knownSidecars are names of containers injected by service meshes and agents, which are skipped when choosing
+// the default container of a pod without the default container annotation.
+var knownSidecars = []string{"istio-proxy", "linkerd-proxy", "envoy", "envoy-sidecar", "vault-agent", "cloud-sql-proxy", "cloudsql-proxy"}
+
+// DefaultContainerOf returns the name of the container commands are executed in when no container name is given:
+// the container named by the kubectl.kubernetes.io/default-container annotation, or the first container which
+// is not a well-known sidecar, or the first container. It returns an empty string for pods without containers.
+func DefaultContainerOf(pod *coreV1.Pod) string {
+	if name, ok := pod.Annotations[DefaultContainerAnnotation]; ok {
+		for _, container := range pod.Spec.Containers {
+			if container.Name == name {
+				return name
+			}
+		}
+	}
+	for _, container := range pod.Spec.Containers {
+		if !slices.Contains(knownSidecars, container.Name) {
+			return container.Name
+		}
+	}
+	if len(pod.Spec.Containers) > 0 {
+		return pod.Spec.Containers[0].Name
+	}
+	return ""
+}
+
+// DefaultContainer retrieves the pod with the given name and returns the name of its default container,
+// see DefaultContainerOf. All methods executing commands select the default container this way when they
+// are given an empty container name, matching the behavior of kubectl.
+func (k8s *K8SExec) DefaultContainer(ctx context.Context, podName string) (string, error) {
+	pod, err := k8s.GetPodWithContext(ctx, podName, metaV1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	name := DefaultContainerOf(pod)
+	if name == "" {
+		return "", fmt.Errorf("pod %s/%s: %w", k8s.Namespace, podName, ErrContainerNotFound)
+	}
+	return name, nil
+}
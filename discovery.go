@@ -0,0 +1,207 @@
+package k8sexec
+
+import (
+	"context"
+	"github.com/hhruszka/k8sexec/discovery"
+	v1 "k8s.io/api/apps/v1"
+	batchV1 "k8s.io/api/batch/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetPod retrieves a Pod based on its name within the specified namespace.
+// The namespace is provided by the 'k8s' context. This function simplifies the process
+// of locating a specific Pod within a namespace, leveraging the Kubernetes client-go
+// library to interact with the Kubernetes API. It returns the found Pod and any error
+// encountered during the retrieval process.
+// The call is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) GetPod(podName string, options metaV1.GetOptions) (*coreV1.Pod, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.GetPodWithContext(ctx, podName, options)
+}
+
+// GetPodWithContext retrieves a Pod based on its name within the namespace provided by the 'k8s' context.
+// The call is governed by the provided context, which allows callers to cancel it or to set its deadline.
+func (k8s *K8SExec) GetPodWithContext(ctx context.Context, podName string, options metaV1.GetOptions) (*coreV1.Pod, error) {
+	return k8s.discoveryClient().GetPod(ctx, podName, options)
+}
+
+// GetPods retrieves all Pods within the namespace specified by the 'k8s' context.
+// This function utilizes the Kubernetes client-go library to fetch a list of Pods
+// from the specified namespace, facilitating the management and interaction with
+// Kubernetes resources. It returns a list of Pods and any error encountered during
+// the retrieval process.
+// The call is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) GetPods(options metaV1.ListOptions) ([]coreV1.Pod, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.GetPodsWithContext(ctx, options)
+}
+
+// GetPodsWithContext retrieves all Pods matching 'options' within the namespace specified by the 'k8s' context.
+// The call is governed by the provided context, which allows callers to cancel it or to set its deadline.
+func (k8s *K8SExec) GetPodsWithContext(ctx context.Context, options metaV1.ListOptions) ([]coreV1.Pod, error) {
+	return k8s.discoveryClient().GetPods(ctx, options)
+}
+
+// GetDeployments retrieves all Deployments within the namespace specified in the 'k8s' context.
+// It leverages the Kubernetes client-go library to query the Kubernetes API for Deployments,
+// aiming to streamline the process of managing Kubernetes resources.
+// This function returns an array of Deployments along with any error encountered during the query,
+// thus enabling comprehensive oversight of Deployment resources within the designated namespace.
+// The call is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) GetDeployments() (*v1.DeploymentList, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.GetDeploymentsWithContext(ctx)
+}
+
+// GetDeploymentsWithContext retrieves all Deployments within the namespace specified in the 'k8s' context.
+// The call is governed by the provided context, which allows callers to cancel it or to set its deadline.
+func (k8s *K8SExec) GetDeploymentsWithContext(ctx context.Context) (*v1.DeploymentList, error) {
+	return k8s.discoveryClient().GetDeployments(ctx)
+}
+
+// GetStatefulSets fetches all StatefulSets within the specified namespace, as determined by the 'k8s' context.
+// Utilizing the client-go library, this function communicates with the Kubernetes API to gather StatefulSets,
+// facilitating detailed management and operational oversight of these specific Kubernetes resources.
+// It returns a collection of StatefulSets and any errors encountered in the process, ensuring comprehensive
+// access to StatefulSet configurations within the given namespace.
+// The call is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) GetStatefulSets() (*v1.StatefulSetList, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.GetStatefulSetsWithContext(ctx)
+}
+
+// GetStatefulSetsWithContext retrieves all StatefulSets within the namespace specified in the 'k8s' context.
+// The call is governed by the provided context, which allows callers to cancel it or to set its deadline.
+func (k8s *K8SExec) GetStatefulSetsWithContext(ctx context.Context) (*v1.StatefulSetList, error) {
+	return k8s.discoveryClient().GetStatefulSets(ctx)
+}
+
+// GetDaemonSets fetches all DaemonSets within the specified namespace, as determined by the 'k8s' context.
+// Utilizing the client-go library, this function communicates with the Kubernetes API to gather DaemonSets,
+// facilitating detailed management and operational oversight of these specific Kubernetes resources.
+// It returns a collection of StatefulSets and any errors encountered in the process, ensuring comprehensive
+// access to StatefulSet configurations within the given namespace.
+// The call is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) GetDaemonSets() (*v1.DaemonSetList, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.GetDaemonSetsWithContext(ctx)
+}
+
+// GetDaemonSetsWithContext retrieves all DaemonSets within the namespace specified in the 'k8s' context.
+// The call is governed by the provided context, which allows callers to cancel it or to set its deadline.
+func (k8s *K8SExec) GetDaemonSetsWithContext(ctx context.Context) (*v1.DaemonSetList, error) {
+	return k8s.discoveryClient().GetDaemonSets(ctx)
+}
+
+// GetReplicaSets fetches all ReplicaSets within the namespace specified by the 'k8s' context, including those
+// managed by Deployments, which keep the ReplicaSets of their previous revisions. Together with the getters of
+// other workloads it allows building a complete inventory of the workloads of a namespace.
+// The call is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) GetReplicaSets() (*v1.ReplicaSetList, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.GetReplicaSetsWithContext(ctx)
+}
+
+// GetReplicaSetsWithContext retrieves all ReplicaSets within the namespace specified in the 'k8s' context.
+// The call is governed by the provided context, which allows callers to cancel it or to set its deadline.
+func (k8s *K8SExec) GetReplicaSetsWithContext(ctx context.Context) (*v1.ReplicaSetList, error) {
+	return k8s.discoveryClient().GetReplicaSets(ctx)
+}
+
+// GetJobs fetches all Jobs within the namespace specified by the 'k8s' context, both those created directly
+// and those created by CronJobs, whether they are still running or have completed, so inventories cover
+// batch workloads as well. The call is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) GetJobs() (*batchV1.JobList, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.GetJobsWithContext(ctx)
+}
+
+// GetJobsWithContext retrieves all Jobs within the namespace specified in the 'k8s' context.
+// The call is governed by the provided context, which allows callers to cancel it or to set its deadline.
+func (k8s *K8SExec) GetJobsWithContext(ctx context.Context) (*batchV1.JobList, error) {
+	return k8s.discoveryClient().GetJobs(ctx)
+}
+
+// GetCronJobs fetches all CronJobs within the namespace specified by the 'k8s' context, using the batch/v1
+// API available since Kubernetes 1.21. CronJobs run no pods between their scheduled runs; the pods of their
+// runs belong to the Jobs they create, see GetJobs. The call is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) GetCronJobs() (*batchV1.CronJobList, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.GetCronJobsWithContext(ctx)
+}
+
+// GetCronJobsWithContext retrieves all CronJobs within the namespace specified in the 'k8s' context.
+// The call is governed by the provided context, which allows callers to cancel it or to set its deadline.
+func (k8s *K8SExec) GetCronJobsWithContext(ctx context.Context) (*batchV1.CronJobList, error) {
+	return k8s.discoveryClient().GetCronJobs(ctx)
+}
+
+// GetPods retrieves a comprehensive and unique list of Pods within a given namespace,
+// as provided by the 'k8s' context. It targets Pods associated with Deployments, StatefulSets,
+// and those directly within the namespace, ensuring no duplicates.
+// It returns the total number of pods in the namespace and the selected pods. The context of the selection,
+// i.e. which workload each pod represents and why it was selected, is available from DiscoverUniquePods.
+// The whole discovery is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) GetUniquePods() (int, []coreV1.Pod, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.GetUniquePodsWithContext(ctx)
+}
+
+// GetUniquePodsWithContext retrieves a unique list of Pods within the namespace provided by the 'k8s' context,
+// like GetUniquePods. The discovery is governed by the provided context, which allows callers to cancel it or
+// to set a deadline suitable for large namespaces.
+func (k8s *K8SExec) GetUniquePodsWithContext(ctx context.Context) (int, []coreV1.Pod, error) {
+	return k8s.discoveryClient().GetUniquePods(ctx)
+}
+
+// DiscoveryReport describes the result of unique pod discovery in a namespace: for every workload the pod
+// selected to represent it, and the standalone pods not managed by any Deployment, StatefulSet or DaemonSet.
+type DiscoveryReport = discovery.Report
+
+// WorkloadReport describes a single workload found by DiscoverUniquePods.
+type WorkloadReport = discovery.WorkloadReport
+
+// DiscoverUniquePods finds a single representative pod for every Deployment, StatefulSet and DaemonSet of
+// the namespace provided by the 'k8s' context, plus all standalone pods. Unlike GetUniquePods, it returns
+// a structured report telling which workload each pod represents, how many replicas the workload has and why
+// the pod was selected, so callers do not have to re-derive this context from the pods themselves.
+// Representative pods are selected with the strategy set by WithRepresentativeStrategy, the first pod of every
+// workload by default; an unknown strategy is reported with an error wrapping ErrNoSuchStrategy.
+// The whole discovery is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) DiscoverUniquePods() (*DiscoveryReport, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.DiscoverUniquePodsWithContext(ctx)
+}
+
+// DiscoverUniquePodsWithContext builds the discovery report of the namespace provided by the 'k8s' context,
+// like DiscoverUniquePods. The discovery is governed by the provided context.
+func (k8s *K8SExec) DiscoverUniquePodsWithContext(ctx context.Context) (*DiscoveryReport, error) {
+	return k8s.discoveryClient().DiscoverUniquePods(ctx)
+}
+
+// discoveryClient returns a client discovering resources of the instance's namespace with its clientset,
+// representative strategy and logger.
+func (k8s *K8SExec) discoveryClient() *discovery.Client {
+	return &discovery.Client{Clientset: k8s.Clientset, Namespace: k8s.Namespace, Strategy: k8s.strategy, Logger: k8s.logger()}
+}
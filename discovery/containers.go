@@ -0,0 +1,91 @@
+package discovery
+
+import (
+	"context"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ContainerState is the state of a container as reported by the kubelet.
+type ContainerState string
+
+const (
+	ContainerRunning      ContainerState = "running"
+	ContainerWaiting      ContainerState = "waiting"
+	ContainerTerminated   ContainerState = "terminated"
+	ContainerStateUnknown ContainerState = "unknown"
+)
+
+// ContainerInfo is a snapshot of a container's configuration and status.
+// Reason and Message explain the waiting or terminated state (e.g. "CrashLoopBackOff", "OOMKilled"),
+// and ExitCode is set for terminated containers.
+type ContainerInfo struct {
+	Name         string              `json:"Name"`
+	Image        string              `json:"Image"`
+	Init         bool                `json:"Init,omitempty"`
+	State        ContainerState      `json:"State"`
+	Reason       string              `json:"Reason,omitempty"`
+	Message      string              `json:"Message,omitempty"`
+	ExitCode     int32               `json:"ExitCode,omitempty"`
+	Ready        bool                `json:"Ready"`
+	RestartCount int32               `json:"RestartCount"`
+	Requests     coreV1.ResourceList `json:"Requests,omitempty"`
+	Limits       coreV1.ResourceList `json:"Limits,omitempty"`
+}
+
+// GetContainers retrieves the pod with the given name and returns a snapshot of its init containers and
+// containers: names, images, states with reasons, restart counts and resource requests and limits.
+// Batch runners can use it to skip containers that are not running and to annotate failures.
+func (client *Client) GetContainers(ctx context.Context, podName string) ([]ContainerInfo, error) {
+	pod, err := client.GetPod(ctx, podName, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return ContainersOf(pod), nil
+}
+
+// ContainersOf returns a snapshot of init containers and containers of an already retrieved pod.
+func ContainersOf(pod *coreV1.Pod) []ContainerInfo {
+	var containers []ContainerInfo
+	containers = appendContainerInfos(containers, pod.Spec.InitContainers, pod.Status.InitContainerStatuses, true)
+	containers = appendContainerInfos(containers, pod.Spec.Containers, pod.Status.ContainerStatuses, false)
+	return containers
+}
+
+// appendContainerInfos combines container specs with their statuses and appends the result to 'containers'.
+func appendContainerInfos(containers []ContainerInfo, specs []coreV1.Container, statuses []coreV1.ContainerStatus, init bool) []ContainerInfo {
+	byName := make(map[string]coreV1.ContainerStatus, len(statuses))
+	for _, status := range statuses {
+		byName[status.Name] = status
+	}
+
+	for _, spec := range specs {
+		info := ContainerInfo{
+			Name:     spec.Name,
+			Image:    spec.Image,
+			Init:     init,
+			State:    ContainerStateUnknown,
+			Requests: spec.Resources.Requests,
+			Limits:   spec.Resources.Limits,
+		}
+		if status, ok := byName[spec.Name]; ok {
+			info.Ready = status.Ready
+			info.RestartCount = status.RestartCount
+			switch {
+			case status.State.Running != nil:
+				info.State = ContainerRunning
+			case status.State.Waiting != nil:
+				info.State = ContainerWaiting
+				info.Reason = status.State.Waiting.Reason
+				info.Message = status.State.Waiting.Message
+			case status.State.Terminated != nil:
+				info.State = ContainerTerminated
+				info.Reason = status.State.Terminated.Reason
+				info.Message = status.State.Terminated.Message
+				info.ExitCode = status.State.Terminated.ExitCode
+			}
+		}
+		containers = append(containers, info)
+	}
+	return containers
+}
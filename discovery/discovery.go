@@ -0,0 +1,296 @@
+// Package discovery finds pods, workloads, containers and images in a namespace through the Kubernetes API.
+// It needs no access to the exec subresource and does not depend on the exec, transfer and audit machinery of
+// the root k8sexec package, so inventory tools can use it on their own. The root package re-exports its types
+// and builds the discovery methods of K8SExec on top of Client.
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"github.com/hhruszka/k8sexec/internal/apierror"
+	"io"
+	v1 "k8s.io/api/apps/v1"
+	batchV1 "k8s.io/api/batch/v1"
+	coreV1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"log/slog"
+	"strings"
+)
+
+// Client retrieves resources of the namespace 'Namespace' with 'Clientset'. Every call is governed by
+// the context provided to it. A Client is safe for concurrent use by multiple goroutines as long as its fields
+// are not modified.
+type Client struct {
+	Clientset kubernetes.Interface
+	Namespace string
+	// Strategy selects the pods representing workloads in DiscoverUniquePods and GetUniquePods.
+	// StrategyFirst is used when it is not set.
+	Strategy RepresentativeStrategy
+	// Logger receives warnings about resources which could not be inspected. They are discarded if it is nil.
+	Logger *slog.Logger
+}
+
+// GetPod retrieves a Pod based on its name within the client's namespace. Missing pods are reported with
+// an error wrapping ErrPodNotFound.
+func (client *Client) GetPod(ctx context.Context, podName string, options metaV1.GetOptions) (*coreV1.Pod, error) {
+	pod, err := client.Clientset.CoreV1().Pods(client.Namespace).Get(ctx, podName, options)
+	if err != nil {
+		if apiErrors.IsNotFound(err) {
+			return nil, fmt.Errorf("getting pod %s/%s: %w: %w", client.Namespace, podName, ErrPodNotFound, err)
+		}
+		return nil, apierror.Wrap(err, "getting pod %s/%s", client.Namespace, podName)
+	}
+	return pod, nil
+}
+
+// GetPods retrieves all Pods matching 'options' within the client's namespace.
+func (client *Client) GetPods(ctx context.Context, options metaV1.ListOptions) ([]coreV1.Pod, error) {
+	var pods *coreV1.PodList
+	pods, err := client.Clientset.CoreV1().Pods(client.Namespace).List(ctx, options)
+	if err != nil {
+		return nil, apierror.Wrap(err, "listing pods in %s", client.Namespace)
+	}
+	return pods.Items, nil
+}
+
+// GetDeployments retrieves all Deployments within the client's namespace.
+func (client *Client) GetDeployments(ctx context.Context) (*v1.DeploymentList, error) {
+	var deployments *v1.DeploymentList
+	deployments, err := client.Clientset.AppsV1().Deployments(client.Namespace).List(ctx, metaV1.ListOptions{})
+	if err != nil {
+		return nil, apierror.Wrap(err, "listing deployments in %s", client.Namespace)
+	}
+	return deployments, nil
+}
+
+// GetStatefulSets retrieves all StatefulSets within the client's namespace.
+func (client *Client) GetStatefulSets(ctx context.Context) (*v1.StatefulSetList, error) {
+	var statefulSets *v1.StatefulSetList
+	statefulSets, err := client.Clientset.AppsV1().StatefulSets(client.Namespace).List(ctx, metaV1.ListOptions{})
+	if err != nil {
+		return nil, apierror.Wrap(err, "listing statefulsets in %s", client.Namespace)
+	}
+	return statefulSets, nil
+}
+
+// GetDaemonSets retrieves all DaemonSets within the client's namespace.
+func (client *Client) GetDaemonSets(ctx context.Context) (*v1.DaemonSetList, error) {
+	var daemonSets *v1.DaemonSetList
+	daemonSets, err := client.Clientset.AppsV1().DaemonSets(client.Namespace).List(ctx, metaV1.ListOptions{})
+	if err != nil {
+		return nil, apierror.Wrap(err, "listing daemonsets in %s", client.Namespace)
+	}
+	return daemonSets, nil
+}
+
+// GetReplicaSets retrieves all ReplicaSets within the client's namespace, including those managed by
+// Deployments, which keep the ReplicaSets of their previous revisions.
+func (client *Client) GetReplicaSets(ctx context.Context) (*v1.ReplicaSetList, error) {
+	replicaSets, err := client.Clientset.AppsV1().ReplicaSets(client.Namespace).List(ctx, metaV1.ListOptions{})
+	if err != nil {
+		return nil, apierror.Wrap(err, "listing replicasets in %s", client.Namespace)
+	}
+	return replicaSets, nil
+}
+
+// GetJobs retrieves all Jobs within the client's namespace, both those created directly and those created by
+// CronJobs, whether they are still running or have completed.
+func (client *Client) GetJobs(ctx context.Context) (*batchV1.JobList, error) {
+	jobs, err := client.Clientset.BatchV1().Jobs(client.Namespace).List(ctx, metaV1.ListOptions{})
+	if err != nil {
+		return nil, apierror.Wrap(err, "listing jobs in %s", client.Namespace)
+	}
+	return jobs, nil
+}
+
+// GetCronJobs retrieves all CronJobs within the client's namespace, using the batch/v1 API available since
+// Kubernetes 1.21.
+func (client *Client) GetCronJobs(ctx context.Context) (*batchV1.CronJobList, error) {
+	cronJobs, err := client.Clientset.BatchV1().CronJobs(client.Namespace).List(ctx, metaV1.ListOptions{})
+	if err != nil {
+		return nil, apierror.Wrap(err, "listing cronjobs in %s", client.Namespace)
+	}
+	return cronJobs, nil
+}
+
+// mapToLabelSelector takes a map containing key-value pairs and converts it into a Kubernetes label selector
+// string format. This utility function is essential for crafting label selectors used in Kubernetes API queries,
+// allowing for the filtering of resources based on specified labels. The resulting string is a concatenation of
+// the map's key-value pairs, formatted as 'key=value', and joined by commas for multiple pairs.
+// This conversion facilitates the dynamic selection of Kubernetes resources based on labels, enhancing
+// the flexibility and precision of resource queries within Kubernetes operations.
+func mapToLabelSelector(labels map[string]string) string {
+	var selectorParts []string
+	for key, value := range labels {
+		selectorParts = append(selectorParts, fmt.Sprintf("%s=%s", key, value))
+	}
+	return strings.Join(selectorParts, ",")
+}
+
+// GetUniquePods retrieves a unique list of Pods within the client's namespace: a representative pod of every
+// Deployment, StatefulSet and DaemonSet, and all standalone pods. It returns the total number of pods in
+// the namespace and the selected pods. The context of the selection, i.e. which workload each pod represents
+// and why it was selected, is available from DiscoverUniquePods.
+func (client *Client) GetUniquePods(ctx context.Context) (int, []coreV1.Pod, error) {
+	report, err := client.DiscoverUniquePods(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	return report.TotalPods, report.Pods(), nil
+}
+
+// Report describes the result of unique pod discovery in a namespace: for every workload the pod
+// selected to represent it, and the standalone pods not managed by any Deployment, StatefulSet or DaemonSet.
+type Report struct {
+	// Namespace is the namespace the discovery was run in.
+	Namespace string `json:"Namespace"`
+	// TotalPods is the number of all pods found in the namespace.
+	TotalPods int `json:"TotalPods"`
+	// Workloads lists Deployments, StatefulSets and DaemonSets, in this order.
+	Workloads []WorkloadReport `json:"Workloads"`
+	// StandalonePods lists pods not matched by the selector of any listed workload.
+	StandalonePods []coreV1.Pod `json:"StandalonePods,omitempty"`
+}
+
+// WorkloadReport describes a single workload found by DiscoverUniquePods.
+type WorkloadReport struct {
+	Workload WorkloadRef `json:"Workload"`
+	// Replicas is the number of pods the workload is supposed to run: the desired replica count
+	// of Deployments and StatefulSets, and the desired number of scheduled pods of DaemonSets.
+	Replicas int32 `json:"Replicas"`
+	// MatchedPods is the number of pods matching the workload's selector.
+	MatchedPods int `json:"MatchedPods"`
+	// Representative is the pod selected to represent the workload, or nil if no pod could be selected.
+	Representative *coreV1.Pod `json:"Representative,omitempty"`
+	// Representatives lists the pods selected with StrategyPerNode, one per node, ordered by node names;
+	// Representative is the first of them. It is empty for other strategies, which select a single pod.
+	Representatives []coreV1.Pod `json:"Representatives,omitempty"`
+	// Reason explains why the representative pod was selected, or why none was.
+	Reason string `json:"Reason"`
+}
+
+// Pods returns the representative pods of all workloads followed by the standalone pods.
+func (report *Report) Pods() []coreV1.Pod {
+	var pods []coreV1.Pod
+	for _, workload := range report.Workloads {
+		switch {
+		case len(workload.Representatives) > 0:
+			pods = append(pods, workload.Representatives...)
+		case workload.Representative != nil:
+			pods = append(pods, *workload.Representative)
+		}
+	}
+	return append(pods, report.StandalonePods...)
+}
+
+// DiscoverUniquePods finds a single representative pod for every Deployment, StatefulSet and DaemonSet of
+// the client's namespace, plus all standalone pods. Unlike GetUniquePods, it returns a structured report telling
+// which workload each pod represents, how many replicas the workload has and why the pod was selected, so
+// callers do not have to re-derive this context from the pods themselves. Representative pods are selected with
+// the client's strategy, the first pod of every workload by default; an unknown strategy is reported with
+// an error wrapping ErrNoSuchStrategy.
+func (client *Client) DiscoverUniquePods(ctx context.Context) (*Report, error) {
+	strategy, err := client.representativeStrategy()
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{Namespace: client.Namespace}
+	// workloadPods holds the names of all pods matched by the selector of any workload
+	workloadPods := make(map[string]bool)
+
+	deployments, err := client.GetDeployments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range deployments.Items {
+		replicas := int32(1)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		workload := WorkloadRef{Kind: KindDeployment, Name: deployment.Name}
+		report.Workloads = append(report.Workloads, client.reportWorkload(ctx, strategy, workload, replicas, deployment.Spec.Selector, workloadPods))
+	}
+
+	statefulSets, err := client.GetStatefulSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, statefulSet := range statefulSets.Items {
+		replicas := int32(1)
+		if statefulSet.Spec.Replicas != nil {
+			replicas = *statefulSet.Spec.Replicas
+		}
+		workload := WorkloadRef{Kind: KindStatefulSet, Name: statefulSet.Name}
+		report.Workloads = append(report.Workloads, client.reportWorkload(ctx, strategy, workload, replicas, statefulSet.Spec.Selector, workloadPods))
+	}
+
+	daemonSets, err := client.GetDaemonSets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, daemonSet := range daemonSets.Items {
+		workload := WorkloadRef{Kind: KindDaemonSet, Name: daemonSet.Name}
+		report.Workloads = append(report.Workloads, client.reportWorkload(ctx, strategy, workload, daemonSet.Status.DesiredNumberScheduled, daemonSet.Spec.Selector, workloadPods))
+	}
+
+	pods, err := client.GetPods(ctx, metaV1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	report.TotalPods = len(pods)
+	for _, pod := range pods {
+		if !workloadPods[pod.Name] {
+			report.StandalonePods = append(report.StandalonePods, pod)
+		}
+	}
+
+	return report, nil
+}
+
+// reportWorkload finds the pods of a workload and selects the ones representing it according to the strategy. Names of all pods matched
+// by the workload's selector are recorded in 'matched'. Failing to list the pods is not fatal; it is reported
+// as the reason of the missing representative pod.
+func (client *Client) reportWorkload(ctx context.Context, strategy RepresentativeStrategy, workload WorkloadRef, replicas int32, selector *metaV1.LabelSelector, matched map[string]bool) WorkloadReport {
+	report := WorkloadReport{Workload: workload, Replicas: replicas}
+
+	// to find all pods that are part of a given workload we need to use Spec.Selector.MatchLabels
+	// from the workload. This is essential.
+	var matchLabels map[string]string
+	if selector != nil {
+		matchLabels = selector.MatchLabels
+	}
+	pods, err := client.GetPods(ctx, metaV1.ListOptions{LabelSelector: mapToLabelSelector(matchLabels)})
+	if err != nil {
+		report.Reason = fmt.Sprintf("listing pods failed: %v", err)
+		return report
+	}
+
+	report.MatchedPods = len(pods)
+	for _, pod := range pods {
+		matched[pod.Name] = true
+	}
+
+	// we are interested only in one instance of a pod, or one per node
+	representatives, reason := selectRepresentatives(strategy, pods)
+	report.Reason = reason
+	if len(representatives) > 0 {
+		report.Representative = &representatives[0]
+	}
+	if strategy == StrategyPerNode {
+		report.Representatives = representatives
+	}
+	return report
+}
+
+// logger returns the client's logger, or a logger discarding all messages if none was set.
+func (client *Client) logger() *slog.Logger {
+	if client.Logger == nil {
+		return discardLogger
+	}
+	return client.Logger
+}
+
+// discardLogger is used when no logger is set.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
@@ -0,0 +1,19 @@
+package discovery
+
+import (
+	"errors"
+	"github.com/hhruszka/k8sexec/internal/apierror"
+)
+
+var (
+	// ErrNoSuchStrategy is returned when an unknown strategy of selecting representative pods is requested.
+	ErrNoSuchStrategy = errors.New("no such strategy")
+	// ErrPodNotFound is returned when the retrieved pod does not exist.
+	ErrPodNotFound = apierror.ErrPodNotFound
+	// ErrThrottled is returned when a request is rejected or cannot be sent because of rate limiting, either
+	// by the client-side rate limiter or by the Kubernetes API server (HTTP 429).
+	ErrThrottled = apierror.ErrThrottled
+	// ErrForbidden is returned when the API server denies a request because of missing permissions, e.g.
+	// when the user is not allowed to list the workloads of the namespace.
+	ErrForbidden = apierror.ErrForbidden
+)
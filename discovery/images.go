@@ -0,0 +1,218 @@
+package discovery
+
+import (
+	"context"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ImageRef describes a container image used in the namespace. Images are identified by the digest of the image
+// actually running, resolved from the containers' statuses, so several tags pointing to the same image
+// (e.g. "nginx:1.25" and "nginx:stable") are reported as a single ImageRef.
+type ImageRef struct {
+	// Repository is the image name without a tag or a digest, e.g. "docker.io/library/nginx".
+	Repository string `json:"Repository"`
+	// Digest is the digest of the running image, e.g. "sha256:4c0f…", as reported by the container runtime.
+	// It is empty if no container using the image has been started yet.
+	Digest string `json:"Digest,omitempty"`
+	// References lists all image references from pod specs that resolve to this image.
+	References []string `json:"References"`
+	// ImageID is the image ID reported by the container runtime in the container's status.
+	ImageID string `json:"ImageID,omitempty"`
+}
+
+// GetUniqueImages retrieves all images used by containers (including init containers) of the pods within
+// the client's namespace. The running image digest is resolved from the containers' statuses rather than taken
+// from the image tag in the pod spec, so the result reflects what is actually running. Images are sorted by
+// repository and digest.
+func (client *Client) GetUniqueImages(ctx context.Context) ([]ImageRef, error) {
+	pods, err := client.GetPods(ctx, metaV1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return uniqueImages(pods), nil
+}
+
+// uniqueImages groups images used by containers of the given pods by their running digest.
+func uniqueImages(pods []coreV1.Pod) []ImageRef {
+	images := make(map[string]*ImageRef)
+	for _, pod := range pods {
+		for _, container := range podImages(&pod) {
+			key := container.imageKey()
+			image, ok := images[key]
+			if !ok {
+				image = &ImageRef{Repository: imageRepository(container.image), Digest: container.digest, ImageID: container.imageID}
+				images[key] = image
+			}
+			if !slices.Contains(image.References, container.image) {
+				image.References = append(image.References, container.image)
+			}
+		}
+	}
+
+	result := make([]ImageRef, 0, len(images))
+	for _, image := range images {
+		sort.Strings(image.References)
+		result = append(result, *image)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Repository != result[j].Repository {
+			return result[i].Repository < result[j].Repository
+		}
+		return result[i].Digest < result[j].Digest
+	})
+	return result
+}
+
+// containerImage describes the image of a single container of a pod.
+type containerImage struct {
+	container string
+	image     string
+	imageID   string
+	digest    string
+}
+
+// imageKey identifies the image of a container: its digest if it is known, or the image reference otherwise.
+func (c containerImage) imageKey() string {
+	if c.digest != "" {
+		return imageRepository(c.image) + "@" + c.digest
+	}
+	return c.image
+}
+
+// podImages returns images of all containers and init containers of a pod, with image IDs and digests resolved
+// from the containers' statuses.
+func podImages(pod *coreV1.Pod) []containerImage {
+	imageIDs := make(map[string]string)
+	for _, statuses := range [][]coreV1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for _, status := range statuses {
+			imageIDs[status.Name] = status.ImageID
+		}
+	}
+
+	var images []containerImage
+	for _, containers := range [][]coreV1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for _, container := range containers {
+			imageID := imageIDs[container.Name]
+			images = append(images, containerImage{
+				container: container.Name,
+				image:     container.Image,
+				imageID:   imageID,
+				digest:    imageDigest(imageID),
+			})
+		}
+	}
+	return images
+}
+
+// imageDigest extracts the digest from an image ID reported by a container runtime. Image IDs come in various
+// forms, e.g. "docker-pullable://nginx@sha256:…", "docker.io/library/nginx@sha256:…", "docker://sha256:…"
+// or "sha256:…".
+func imageDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	if i := strings.Index(imageID, "://"); i >= 0 {
+		imageID = imageID[i+3:]
+	}
+	if strings.HasPrefix(imageID, "sha256:") {
+		return imageID
+	}
+	return ""
+}
+
+// imageRepository strips the tag and the digest from an image reference, e.g. "nginx:1.25@sha256:…" becomes
+// "nginx". A colon is a tag separator only if it follows the last slash, as it can also separate a registry port.
+func imageRepository(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// ImageUsage describes where an image is used: which workloads run it, in which of their pods and containers.
+type ImageUsage struct {
+	Image ImageRef `json:"Image"`
+	// Workloads lists workloads running the image; standalone pods are reported as workloads of KindPod.
+	Workloads []WorkloadImageUsage `json:"Workloads"`
+	// PodCount is the number of pods running the image.
+	PodCount int `json:"PodCount"`
+	// ContainerCount is the number of containers running the image.
+	ContainerCount int `json:"ContainerCount"`
+}
+
+// WorkloadImageUsage describes pods and containers of a single workload running an image.
+type WorkloadImageUsage struct {
+	Workload WorkloadRef     `json:"Workload"`
+	Pods     []PodImageUsage `json:"Pods"`
+}
+
+// PodImageUsage lists containers (and init containers) of a pod running an image.
+type PodImageUsage struct {
+	Pod        string   `json:"Pod"`
+	Containers []string `json:"Containers"`
+}
+
+// GetImageUsage returns, for every image used in the client's namespace, the full mapping of the image to
+// the workloads running it, their pods and containers, with counts. Pods are attributed to their top-level
+// workloads, e.g. to a Deployment rather than to its ReplicaSet, which allows to answer questions like "which
+// deployments still run the vulnerable image" without joining results of separate list calls.
+func (client *Client) GetImageUsage(ctx context.Context) ([]ImageUsage, error) {
+	pods, err := client.GetPods(ctx, metaV1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	owners := client.newOwnerResolver(ctx)
+
+	// containers maps an image key to workloads, their pods and containers running the image
+	containers := make(map[string]map[WorkloadRef]map[string][]string)
+	for i := range pods {
+		pod := &pods[i]
+		workload := owners.workloadOf(pod)
+		for _, container := range podImages(pod) {
+			key := container.imageKey()
+			if containers[key] == nil {
+				containers[key] = make(map[WorkloadRef]map[string][]string)
+			}
+			if containers[key][workload] == nil {
+				containers[key][workload] = make(map[string][]string)
+			}
+			containers[key][workload][pod.Name] = append(containers[key][workload][pod.Name], container.container)
+		}
+	}
+
+	var result []ImageUsage
+	for _, image := range uniqueImages(pods) {
+		usage := ImageUsage{Image: image}
+		for workload, pods := range containers[imageRefKey(image)] {
+			workloadUsage := WorkloadImageUsage{Workload: workload}
+			for pod, podContainers := range pods {
+				workloadUsage.Pods = append(workloadUsage.Pods, PodImageUsage{Pod: pod, Containers: podContainers})
+				usage.PodCount++
+				usage.ContainerCount += len(podContainers)
+			}
+			sort.Slice(workloadUsage.Pods, func(i, j int) bool { return workloadUsage.Pods[i].Pod < workloadUsage.Pods[j].Pod })
+			usage.Workloads = append(usage.Workloads, workloadUsage)
+		}
+		sort.Slice(usage.Workloads, func(i, j int) bool {
+			return usage.Workloads[i].Workload.String() < usage.Workloads[j].Workload.String()
+		})
+		result = append(result, usage)
+	}
+	return result, nil
+}
+
+// imageRefKey returns the key of an image returned by uniqueImages, the same as imageKey of its containers.
+func imageRefKey(image ImageRef) string {
+	if image.Digest != "" {
+		return image.Repository + "@" + image.Digest
+	}
+	return image.References[0]
+}
@@ -0,0 +1,77 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// defaultListPageSize is the number of items requested per page by Iterate when the caller does not set
+// ListOptions.Limit.
+const defaultListPageSize int64 = 500
+
+// ListFunc is the signature of the List method of the typed and dynamic client-go resource interfaces, e.g.
+// clientset.BatchV1().Jobs(namespace).List or dynamicClient.Resource(gvr).Namespace(ns).List.
+// It allows to pass any of them directly to List and Iterate.
+type ListFunc[L runtime.Object] func(ctx context.Context, options metaV1.ListOptions) (L, error)
+
+// List retrieves all resources returned by the given list function and returns them as a slice of T, where T is
+// the item type of the list (e.g. batchV1.Job for *batchV1.JobList, or unstructured.Unstructured for resources
+// listed through the dynamic client). Label and field selectors are provided through 'options'.
+// This generic helper makes it possible to list any resource kind, including custom resources, without adding
+// a dedicated GetX method for every kind:
+//
+//	jobs, err := discovery.List[batchV1.Job](ctx, clientset.BatchV1().Jobs(namespace).List, options)
+func List[T any, L runtime.Object](ctx context.Context, list ListFunc[L], options metaV1.ListOptions) ([]T, error) {
+	var items []T
+	err := Iterate(ctx, list, options, func(item *T) error {
+		items = append(items, *item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Iterate walks through all resources returned by the given list function and calls 'fn' for each of them.
+// Resources are retrieved page by page using the Limit and Continue fields of ListOptions, so large collections
+// are never held in memory at once. If options.Limit is not set, a default page size is used.
+// Iteration stops at the first error returned by 'fn' or by the Kubernetes API, and that error is returned.
+func Iterate[T any, L runtime.Object](ctx context.Context, list ListFunc[L], options metaV1.ListOptions, fn func(item *T) error) error {
+	if options.Limit == 0 {
+		options.Limit = defaultListPageSize
+	}
+
+	for {
+		page, err := list(ctx, options)
+		if err != nil {
+			return err
+		}
+
+		objects, err := meta.ExtractList(page)
+		if err != nil {
+			return err
+		}
+		for _, object := range objects {
+			item, ok := any(object).(*T)
+			if !ok {
+				return fmt.Errorf("unexpected list item type %T", object)
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		listMeta, err := meta.ListAccessor(page)
+		if err != nil {
+			return err
+		}
+		if listMeta.GetContinue() == "" {
+			return nil
+		}
+		options.Continue = listMeta.GetContinue()
+	}
+}
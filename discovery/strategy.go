@@ -0,0 +1,97 @@
+package discovery
+
+import (
+	"fmt"
+	coreV1 "k8s.io/api/core/v1"
+	"math/rand"
+	"slices"
+	"strings"
+)
+
+// RepresentativeStrategy decides which of the pods of a workload represent it in DiscoverUniquePods and
+// GetUniquePods.
+type RepresentativeStrategy string
+
+// Strategies of selecting representative pods.
+const (
+	// StrategyFirst selects the first pod listed by the API server. It is the default.
+	StrategyFirst RepresentativeStrategy = "first"
+	// StrategyRandom selects a random pod, spreading repeated audits over all replicas.
+	StrategyRandom RepresentativeStrategy = "random"
+	// StrategyNewest selects the most recently created pod, e.g. to check the latest rollout.
+	StrategyNewest RepresentativeStrategy = "newest"
+	// StrategyOldest selects the pod created first, e.g. the longest running replica.
+	StrategyOldest RepresentativeStrategy = "oldest"
+	// StrategyPerNode selects one pod on every node running the workload, for audits of node-dependent
+	// behavior. Pods not scheduled yet are not selected.
+	StrategyPerNode RepresentativeStrategy = "per-node"
+)
+
+// representativeStrategies lists all known strategies.
+var representativeStrategies = []RepresentativeStrategy{StrategyFirst, StrategyRandom, StrategyNewest, StrategyOldest, StrategyPerNode}
+
+// ParseRepresentativeStrategy returns the strategy named 'name', e.g. given as a command line flag, or an error
+// wrapping ErrNoSuchStrategy if there is no such strategy.
+func ParseRepresentativeStrategy(name string) (RepresentativeStrategy, error) {
+	strategy := RepresentativeStrategy(strings.ToLower(strings.TrimSpace(name)))
+	if !slices.Contains(representativeStrategies, strategy) {
+		return "", fmt.Errorf("%w: %q", ErrNoSuchStrategy, name)
+	}
+	return strategy, nil
+}
+
+// representativeStrategy returns the strategy configured for the client, or an error wrapping
+// ErrNoSuchStrategy if it is unknown.
+func (client *Client) representativeStrategy() (RepresentativeStrategy, error) {
+	if client.Strategy == "" {
+		return StrategyFirst, nil
+	}
+	if !slices.Contains(representativeStrategies, client.Strategy) {
+		return "", fmt.Errorf("%w: %q", ErrNoSuchStrategy, client.Strategy)
+	}
+	return client.Strategy, nil
+}
+
+// selectRepresentatives selects the pods representing a workload among its pods according to the strategy and
+// explains the selection. It returns no pods only if 'pods' is empty or, for StrategyPerNode, none of them is
+// scheduled. Ties of creation times are broken by names, so the selection is reproducible.
+func selectRepresentatives(strategy RepresentativeStrategy, pods []coreV1.Pod) ([]coreV1.Pod, string) {
+	if len(pods) == 0 {
+		return nil, "no pods match the workload's selector"
+	}
+	byAge := func(a, b coreV1.Pod) int {
+		if a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+			return strings.Compare(a.Name, b.Name)
+		}
+		if a.CreationTimestamp.Before(&b.CreationTimestamp) {
+			return -1
+		}
+		return 1
+	}
+
+	switch strategy {
+	case StrategyRandom:
+		return []coreV1.Pod{pods[rand.Intn(len(pods))]}, fmt.Sprintf("random of %d pods matching the workload's selector", len(pods))
+	case StrategyNewest:
+		return []coreV1.Pod{slices.MaxFunc(pods, byAge)}, fmt.Sprintf("newest of %d pods matching the workload's selector", len(pods))
+	case StrategyOldest:
+		return []coreV1.Pod{slices.MinFunc(pods, byAge)}, fmt.Sprintf("oldest of %d pods matching the workload's selector", len(pods))
+	case StrategyPerNode:
+		var selected []coreV1.Pod
+		nodes := make(map[string]bool)
+		for _, pod := range pods {
+			if pod.Spec.NodeName != "" && !nodes[pod.Spec.NodeName] {
+				nodes[pod.Spec.NodeName] = true
+				selected = append(selected, pod)
+			}
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Sprintf("none of %d pods matching the workload's selector is scheduled", len(pods))
+		}
+		slices.SortFunc(selected, func(a, b coreV1.Pod) int {
+			return strings.Compare(a.Spec.NodeName, b.Spec.NodeName)
+		})
+		return selected, fmt.Sprintf("one pod on each of %d nodes running %d pods matching the workload's selector", len(selected), len(pods))
+	}
+	return pods[:1], fmt.Sprintf("first of %d pods matching the workload's selector", len(pods))
+}
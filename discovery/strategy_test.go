@@ -0,0 +1,106 @@
+package discovery
+
+import (
+	"errors"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testPod returns a pod named 'name' created 'age' minutes after a fixed point in time and scheduled on 'node'.
+func testPod(name string, age int, node string) coreV1.Pod {
+	created := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC).Add(time.Duration(age) * time.Minute)
+	return coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: name, CreationTimestamp: metaV1.NewTime(created)},
+		Spec:       coreV1.PodSpec{NodeName: node},
+	}
+}
+
+func TestSelectRepresentatives(t *testing.T) {
+	pods := []coreV1.Pod{
+		testPod("web-b", 10, "node-2"),
+		testPod("web-a", 10, "node-1"),
+		testPod("web-c", 30, "node-2"),
+		testPod("web-d", 0, ""),
+		testPod("web-e", 30, "node-3"),
+	}
+
+	tests := []struct {
+		name     string
+		strategy RepresentativeStrategy
+		pods     []coreV1.Pod
+		want     []string
+	}{
+		{name: "first", strategy: StrategyFirst, pods: pods, want: []string{"web-b"}},
+		{name: "unset strategy", strategy: "", pods: pods, want: []string{"web-b"}},
+		{name: "newest with tie broken by name", strategy: StrategyNewest, pods: pods, want: []string{"web-e"}},
+		{name: "oldest", strategy: StrategyOldest, pods: pods, want: []string{"web-d"}},
+		{name: "oldest with tie broken by name", strategy: StrategyOldest, pods: pods[:2], want: []string{"web-a"}},
+		{name: "per node sorted by node", strategy: StrategyPerNode, pods: pods, want: []string{"web-a", "web-b", "web-e"}},
+		{name: "per node without scheduled pods", strategy: StrategyPerNode, pods: pods[3:4], want: nil},
+		{name: "single pod", strategy: StrategyNewest, pods: pods[2:3], want: []string{"web-c"}},
+		{name: "no pods", strategy: StrategyFirst, pods: nil, want: nil},
+		{name: "no pods per node", strategy: StrategyPerNode, pods: nil, want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			selected, reason := selectRepresentatives(test.strategy, test.pods)
+			var names []string
+			for _, pod := range selected {
+				names = append(names, pod.Name)
+			}
+			if !slices.Equal(names, test.want) {
+				t.Errorf("selected %v, want %v", names, test.want)
+			}
+			if reason == "" {
+				t.Error("the selection is not explained")
+			}
+		})
+	}
+}
+
+func TestSelectRepresentativesRandom(t *testing.T) {
+	pods := []coreV1.Pod{testPod("web-a", 0, "node-1"), testPod("web-b", 1, "node-1"), testPod("web-c", 2, "node-2")}
+	seen := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		selected, _ := selectRepresentatives(StrategyRandom, pods)
+		if len(selected) != 1 {
+			t.Fatalf("selected %d pods, want 1", len(selected))
+		}
+		seen[selected[0].Name] = true
+	}
+	if len(seen) != len(pods) {
+		t.Errorf("selected only %v of %d pods", seen, len(pods))
+	}
+}
+
+func TestParseRepresentativeStrategy(t *testing.T) {
+	tests := []struct {
+		name string
+		want RepresentativeStrategy
+		err  bool
+	}{
+		{name: "per-node", want: StrategyPerNode},
+		{name: " Newest ", want: StrategyNewest},
+		{name: "", err: true},
+		{name: "latest", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			strategy, err := ParseRepresentativeStrategy(test.name)
+			if test.err {
+				if !errors.Is(err, ErrNoSuchStrategy) {
+					t.Errorf("ParseRepresentativeStrategy(%q) = %q, %v, want an error wrapping ErrNoSuchStrategy", test.name, strategy, err)
+				}
+				return
+			}
+			if err != nil || strategy != test.want {
+				t.Errorf("ParseRepresentativeStrategy(%q) = %q, %v, want %q", test.name, strategy, err, test.want)
+			}
+		})
+	}
+}
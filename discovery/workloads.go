@@ -0,0 +1,84 @@
+package discovery
+
+import (
+	"context"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Kinds of workloads owning pods, as reported in WorkloadRef.Kind.
+const (
+	KindDeployment  = "Deployment"
+	KindStatefulSet = "StatefulSet"
+	KindDaemonSet   = "DaemonSet"
+	KindReplicaSet  = "ReplicaSet"
+	KindJob         = "Job"
+	KindCronJob     = "CronJob"
+	// KindPod is used for standalone pods, which are not managed by any workload.
+	KindPod = "Pod"
+)
+
+// WorkloadRef identifies a workload by its kind (e.g. KindDeployment) and name.
+type WorkloadRef struct {
+	Kind string `json:"Kind"`
+	Name string `json:"Name"`
+}
+
+// String returns the workload in the kubectl notation, e.g. "Deployment/web".
+func (workload WorkloadRef) String() string {
+	return workload.Kind + "/" + workload.Name
+}
+
+// ownerResolver resolves the top-level workload managing a pod, following owner references through
+// intermediate controllers: pods of Deployments are owned by ReplicaSets and pods of CronJobs by Jobs.
+type ownerResolver struct {
+	// owners maps ReplicaSets and Jobs to the workloads controlling them.
+	owners map[WorkloadRef]WorkloadRef
+}
+
+// newOwnerResolver lists ReplicaSets and Jobs of the namespace to learn their owners. Failing to list them,
+// e.g. because of missing permissions, is not fatal: pods are then attributed to the ReplicaSet or the Job.
+func (client *Client) newOwnerResolver(ctx context.Context) *ownerResolver {
+	resolver := &ownerResolver{owners: make(map[WorkloadRef]WorkloadRef)}
+
+	replicaSets, err := client.GetReplicaSets(ctx)
+	if err != nil {
+		client.logger().Warn("cannot resolve owners of replicasets", "error", err)
+	} else {
+		for _, replicaSet := range replicaSets.Items {
+			resolver.addOwner(KindReplicaSet, &replicaSet.ObjectMeta)
+		}
+	}
+
+	jobs, err := client.GetJobs(ctx)
+	if err != nil {
+		client.logger().Warn("cannot resolve owners of jobs", "error", err)
+	} else {
+		for _, job := range jobs.Items {
+			resolver.addOwner(KindJob, &job.ObjectMeta)
+		}
+	}
+
+	return resolver
+}
+
+// addOwner records the controller of an intermediate object.
+func (resolver *ownerResolver) addOwner(kind string, object *metaV1.ObjectMeta) {
+	if owner := metaV1.GetControllerOfNoCopy(object); owner != nil {
+		resolver.owners[WorkloadRef{Kind: kind, Name: object.Name}] = WorkloadRef{Kind: owner.Kind, Name: owner.Name}
+	}
+}
+
+// workloadOf returns the top-level workload managing the pod, or a WorkloadRef of KindPod for standalone pods.
+func (resolver *ownerResolver) workloadOf(pod *coreV1.Pod) WorkloadRef {
+	owner := metaV1.GetControllerOfNoCopy(pod)
+	if owner == nil {
+		return WorkloadRef{Kind: KindPod, Name: pod.Name}
+	}
+
+	workload := WorkloadRef{Kind: owner.Kind, Name: owner.Name}
+	if parent, ok := resolver.owners[workload]; ok {
+		return parent
+	}
+	return workload
+}
@@ -0,0 +1,185 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Filesystem describes a file system mounted in a container, as reported by 'df'.
+type Filesystem struct {
+	// Source is the source of the mount, e.g. a block device, "tmpfs", "overlay" or an NFS export.
+	Source string `json:"source"`
+	// Type is the type of the file system, e.g. "ext4" or "tmpfs". It is empty if the container does not expose
+	// /proc/mounts.
+	Type string `json:"type,omitempty"`
+	// MountPoint is the path the file system is mounted at in the container.
+	MountPoint string `json:"mountPoint"`
+	// Size, Used and Available are the total size of the file system, the space used and the space available to
+	// unprivileged users in bytes. Used and Available do not add up to Size if blocks are reserved for root.
+	Size      int64 `json:"size"`
+	Used      int64 `json:"used"`
+	Available int64 `json:"available"`
+}
+
+// UsedPercent returns the space used as a percentage of the space usable by unprivileged users, like the
+// "Use%" column of 'df'.
+func (filesystem *Filesystem) UsedPercent() float64 {
+	usable := filesystem.Used + filesystem.Available
+	if usable <= 0 {
+		return 0
+	}
+	return float64(filesystem.Used) * 100 / float64(usable)
+}
+
+// PathUsage is the disk usage of a path in a container, as reported by DiskUsage.
+type PathUsage struct {
+	// Path is the path whose usage was measured.
+	Path string `json:"path"`
+	// Used is the disk space used by the path, including all files below it if it is a directory, in bytes.
+	Used int64 `json:"used"`
+	// Filesystem is the file system holding the path.
+	Filesystem Filesystem `json:"filesystem"`
+}
+
+// filesystemsScript prints the output of 'df -kP' for the paths given as the parameters, or for all mounted file
+// systems if there are none, followed by a "mounts" line and the content of /proc/mounts, if available, to learn
+// the types of the file systems. File systems 'df' cannot inspect, e.g. for lack of permissions, are skipped.
+const filesystemsScript = `command -v df >/dev/null 2>&1 || { echo "no df available" >&2; exit 127; }
+d=$(df -kP "$@" 2>/dev/null)
+[ -n "$d" ] || { df -kP "$@" >/dev/null; exit; }
+printf '%s\nmounts\n' "$d"
+cat /proc/mounts 2>/dev/null
+exit 0`
+
+// diskUsageScript prints a "du" line with the disk usage of the path given as the first parameter in KiB, as
+// reported by 'du -sk', followed by the output of filesystemsScript for the path. Files below the path which
+// cannot be read are skipped.
+const diskUsageScript = `[ -e "$1" ] || [ -L "$1" ] || exit 66
+[ -r "$1" ] || exit 77
+command -v du >/dev/null 2>&1 || { echo "no du available" >&2; exit 127; }
+u=$(du -sk -- "$1" 2>/dev/null)
+[ -n "$u" ] || { du -sk -- "$1" >/dev/null; exit; }
+echo "du ${u%%[!0-9]*}"
+` + filesystemsScript
+
+// DiskUsage returns the disk space used by the path 'path' in a container, identified by the container's name
+// and the associated pod's name, along with the file system holding it: its source, type, mount point, size
+// and the space used and available. It serves capacity diagnostics of emptyDir volumes and persistent volume
+// mounts from inside the container, which see the file systems the way the application does. The usage of
+// directories is summed up by 'du', skipping files which cannot be read, so it may take a while for large
+// trees and underestimate directories with restricted subdirectories. Missing and unreadable paths are reported
+// with errors wrapping ErrFileNotFound and ErrFileNotReadable, and containers lacking 'du' or 'df' with errors
+// wrapping ErrUtilNotFound. The call is governed by the provided context.
+func (k8s *K8SExec) DiskUsage(ctx context.Context, podName string, containerName string, path string) (*PathUsage, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"sh", "-c", diskUsageScript, "sh", path}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if err := diskUsageError(podName, containerName, path, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+
+	line, output, _ := strings.Cut(stdout.String(), "\n")
+	used, err := strconv.ParseInt(strings.TrimPrefix(line, "du "), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting %s/%s:%s: unexpected output %q", podName, containerName, path, line)
+	}
+	filesystems := parseFilesystems(output)
+	if len(filesystems) == 0 {
+		return nil, fmt.Errorf("inspecting %s/%s:%s: unexpected output %q", podName, containerName, path, output)
+	}
+	return &PathUsage{Path: path, Used: used * 1024, Filesystem: filesystems[0]}, nil
+}
+
+// Filesystems returns the file systems mounted in a container, identified by the container's name and
+// the associated pod's name, with their sources, types, mount points, sizes and the space used and available,
+// as reported by 'df' in the container. Besides the volumes of the pod, the list includes the root file system
+// of the container and pseudo file systems like /dev and /proc. File systems which cannot be inspected are
+// skipped. Containers lacking 'df' are reported with an error wrapping ErrUtilNotFound. The call is governed by
+// the provided context.
+func (k8s *K8SExec) Filesystems(ctx context.Context, podName string, containerName string) ([]Filesystem, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"sh", "-c", filesystemsScript, "sh"}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if err := diskUsageError(podName, containerName, "", retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+	return parseFilesystems(stdout.String()), nil
+}
+
+// diskUsageError returns the error of a failed diskUsageScript or filesystemsScript, or nil if it succeeded.
+func diskUsageError(podName string, containerName string, path string, retCode ExitCode, err error, stderr *bytes.Buffer) error {
+	if retCode == CommandNotFound {
+		for _, util := range []string{"du", "df"} {
+			if strings.Contains(stderr.String(), "no "+util+" available") {
+				return fmt.Errorf("inspecting %s/%s:%s: %w: %s", podName, containerName, path, ErrUtilNotFound, util)
+			}
+		}
+	}
+	return fileError("inspecting", podName, containerName, path, retCode, err, stderr)
+}
+
+// parseFilesystems parses the output of filesystemsScript. Sizes are converted from KiB to bytes.
+func parseFilesystems(output string) []Filesystem {
+	dfOutput, mounts, _ := strings.Cut(output, "\nmounts\n")
+	types := mountTypes(mounts)
+
+	var filesystems []Filesystem
+	lines := strings.Split(dfOutput, "\n")
+	// the first line is the header of the table
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[1], 10, 64)
+		used, _ := strconv.ParseInt(fields[2], 10, 64)
+		available, _ := strconv.ParseInt(fields[3], 10, 64)
+		mountPoint := strings.Join(fields[5:], " ")
+		filesystems = append(filesystems, Filesystem{
+			Source:     fields[0],
+			Type:       types[mountPoint],
+			MountPoint: mountPoint,
+			Size:       size * 1024,
+			Used:       used * 1024,
+			Available:  available * 1024,
+		})
+	}
+	return filesystems
+}
+
+// mountTypes returns the types of file systems by their mount points, read from the content of /proc/mounts.
+// For mount points mounted over, the type of the last mount is returned.
+func mountTypes(mounts string) map[string]string {
+	types := make(map[string]string)
+	for _, line := range strings.Split(mounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		types[unescapeMountField(fields[1])] = fields[2]
+	}
+	return types
+}
+
+// unescapeMountField decodes the octal escapes of whitespace and backslashes in fields of /proc/mounts,
+// e.g. "\040" for a space.
+func unescapeMountField(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var unescaped strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+4 <= len(field) {
+			if c, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				unescaped.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		unescaped.WriteByte(field[i])
+	}
+	return unescaped.String()
+}
@@ -0,0 +1,57 @@
+package k8sexec
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// dryRunPlan holds the checks shared by all targets of a batch run in dry-run mode.
+type dryRunPlan struct {
+	// err is set if the user is not allowed to execute commands in the namespace.
+	err error
+	// namespace is the result of the check that the namespace exists.
+	namespace CheckResult
+}
+
+// newDryRunPlan performs the RBAC check and the namespace check of a batch run in dry-run mode once for all
+// targets. Failures of the review itself are only logged, since they do not prove that executions would fail.
+func (k8s *K8SExec) newDryRunPlan(ctx context.Context) *dryRunPlan {
+	plan := &dryRunPlan{namespace: k8s.checkNamespace(ctx)}
+	allowed, reason, err := k8s.CanExec(ctx, "")
+	switch {
+	case err != nil:
+		k8s.logger().Warn("cannot verify exec permission", "namespace", k8s.Namespace, "error", err)
+	case !allowed:
+		plan.err = fmt.Errorf("exec in namespace %s: %w: %s", k8s.Namespace, ErrForbidden, reason)
+	}
+	return plan
+}
+
+// dryRunStatus validates a target of a batch run in dry-run mode and returns a synthetic ExecutionStatus
+// describing what would be executed where. Targets passing all checks are reported with the ExecutionSkipped
+// exit code and the description in SkipReason; targets failing them are reported with InternalAppError and
+// the error, like failed executions.
+func (k8s *K8SExec) dryRunStatus(ctx context.Context, plan *dryRunPlan, target Target, args []string, options BatchOptions) *ExecutionStatus {
+	start := time.Now()
+	var status *ExecutionStatus
+	diagnosis := k8s.validate(ctx, target.Pod, target.Container, plan.namespace)
+	err := plan.err
+	if err == nil {
+		err = diagnosis.Err()
+	}
+	if err != nil {
+		status = NewExecutionStatus(target.Pod, diagnosis.Container, InternalAppError, err.Error(), "", "")
+		status.Err = err
+	} else {
+		status = NewExecutionStatus(target.Pod, diagnosis.Container, ExecutionSkipped, "", "", "")
+		status.SkipReason = fmt.Sprintf("dry run: would execute %s in %s/%s/%s", shellJoin(args),
+			k8s.Namespace, target.Pod, diagnosis.Container)
+		if options.Stdin != nil {
+			status.SkipReason += fmt.Sprintf(" with %d bytes of standard input", len(options.Stdin))
+		}
+	}
+	status.DryRun = true
+	status.recordExecution(k8s.Namespace, args, start)
+	return status
+}
@@ -0,0 +1,66 @@
+package k8sexec
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// TestDryRunChecksNamespaceOnce runs a dry run across many targets against an API server serving running pods,
+// and verifies that the namespace is retrieved once per run rather than once per target.
+func TestDryRunChecksNamespaceOnce(t *testing.T) {
+	var namespaceGets atomic.Int64
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/selfsubjectaccessreviews"):
+			fmt.Fprint(w, `{"kind":"SelfSubjectAccessReview","apiVersion":"authorization.k8s.io/v1","status":{"allowed":true}}`)
+		case r.URL.Path == "/api/v1/namespaces/default":
+			namespaceGets.Add(1)
+			fmt.Fprint(w, `{"kind":"Namespace","apiVersion":"v1","metadata":{"name":"default"}}`)
+		case strings.HasPrefix(r.URL.Path, "/api/v1/namespaces/default/pods/"):
+			name := strings.TrimPrefix(r.URL.Path, "/api/v1/namespaces/default/pods/")
+			fmt.Fprintf(w, `{"kind":"Pod","apiVersion":"v1","metadata":{"name":%q,"namespace":"default"},
+				"spec":{"containers":[{"name":"app"}]},
+				"status":{"phase":"Running","containerStatuses":[{"name":"app","ready":true,"state":{"running":{}}}]}}`, name)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	kubeconfig := filepath.Join(t.TempDir(), "kubeconfig")
+	config := strings.Replace(testKubeconfig, "https://127.0.0.1:6443", server.URL, 1)
+	if err := os.WriteFile(kubeconfig, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	backend := &fakeBackend{}
+	k8s, err := NewK8SExec(kubeconfig, "default", WithBackend(backend))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var targets []Target
+	for i := 0; i < 20; i++ {
+		targets = append(targets, Target{Pod: fmt.Sprintf("web-%d", i), Container: "app"})
+	}
+	statuses := k8s.BatchExec(context.Background(), targets, []string{"echo", "hello"}, BatchOptions{DryRun: true})
+
+	for _, status := range statuses {
+		if status.RetCode != ExecutionSkipped || !status.DryRun {
+			t.Errorf("%s/%s: exit code %d, error %v", status.Pod, status.Container, status.RetCode, status.Err)
+		}
+	}
+	if gets := namespaceGets.Load(); gets != 1 {
+		t.Errorf("namespace retrieved %d times, want once", gets)
+	}
+	if streams := backend.streams.Load(); streams != 0 {
+		t.Errorf("dry run executed %d commands", streams)
+	}
+}
@@ -0,0 +1,88 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hhruszka/k8sexec/discovery"
+	"github.com/hhruszka/k8sexec/internal/apierror"
+	"io"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"net"
+	"strings"
+	"syscall"
+)
+
+var (
+	// ErrNoShell is returned when an operation requires a shell in the container and none could be found.
+	ErrNoShell = errors.New("no shell available in the container")
+	// ErrUtilNotFound is returned when an operation requires utilities which are not available in the container.
+	ErrUtilNotFound = errors.New("required utility not found in the container")
+	// ErrNoSuchStrategy is returned when an unknown strategy is requested. It is discovery.ErrNoSuchStrategy.
+	ErrNoSuchStrategy = discovery.ErrNoSuchStrategy
+	// ErrStreamClosed is returned when the connection streaming a command's input and output is closed
+	// before the command completes.
+	ErrStreamClosed = errors.New("exec stream closed unexpectedly")
+	// ErrThrottled is returned when a request is rejected or cannot be sent because of rate limiting, either
+	// by the instance's rate limiter or by the Kubernetes API server (HTTP 429). It is discovery.ErrThrottled.
+	ErrThrottled = apierror.ErrThrottled
+	// ErrPodNotFound is returned when the pod a command is executed in, or which is retrieved, does not exist.
+	// It is discovery.ErrPodNotFound.
+	ErrPodNotFound = apierror.ErrPodNotFound
+	// ErrContainerNotFound is returned when the pod has no container with the requested name.
+	ErrContainerNotFound = errors.New("container not found")
+	// ErrExecTimeout is returned when a command execution is interrupted because its deadline was exceeded.
+	ErrExecTimeout = errors.New("command execution timed out")
+	// ErrForbidden is returned when the API server denies a request because of missing permissions, e.g.
+	// when the user is not allowed to create the pods/exec subresource. It is discovery.ErrForbidden.
+	ErrForbidden = apierror.ErrForbidden
+)
+
+// wrapStreamError wraps an error returned while streaming a command's input and outputs with a sentinel error
+// describing its cause, if it is known: ErrExecTimeout, ErrConnectionLost, ErrThrottled, ErrForbidden,
+// ErrPodNotFound, ErrContainerNotFound or ErrStreamClosed.
+func wrapStreamError(ctx context.Context, err error, podName string, containerName string) error {
+	var sentinel error
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		sentinel = ErrExecTimeout
+	case isConnectionLost(err):
+		sentinel = ErrConnectionLost
+	case apierror.IsThrottled(err):
+		sentinel = ErrThrottled
+	case apierror.IsForbidden(err):
+		sentinel = ErrForbidden
+	case isContainerNotFound(err):
+		sentinel = ErrContainerNotFound
+	case isPodNotFound(err):
+		sentinel = ErrPodNotFound
+	case isStreamClosed(err):
+		sentinel = ErrStreamClosed
+	default:
+		return fmt.Errorf("exec in %s/%s: %w", podName, containerName, err)
+	}
+	return fmt.Errorf("exec in %s/%s: %w: %w", podName, containerName, sentinel, err)
+}
+
+// isStreamClosed reports whether the error was caused by the underlying connection being closed.
+func isStreamClosed(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.ErrClosedPipe) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// isPodNotFound reports whether the error was caused by a missing pod.
+func isPodNotFound(err error) bool {
+	return apiErrors.IsNotFound(err) || strings.Contains(err.Error(), "pods \"") && strings.Contains(err.Error(), "not found")
+}
+
+// isContainerNotFound reports whether the error was caused by a container missing in the pod, as reported
+// either by the API server or by the kubelet.
+func isContainerNotFound(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "container not found") ||
+		strings.Contains(message, "container ") && strings.Contains(message, "is not valid for pod")
+}
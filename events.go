@@ -0,0 +1,55 @@
+package k8sexec
+
+import (
+	"context"
+	"github.com/hhruszka/k8sexec/internal/apierror"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"slices"
+	"time"
+)
+
+// GetPodEvents retrieves the Events associated with the pod of the given name within the namespace specified
+// by the 'k8s' context, such as scheduling failures, OOM kills, probe failures or image pull errors, sorted from
+// the oldest to the most recent. It allows to correlate failures of executions with their cluster-side causes in
+// a single call. Events are kept by the API server only for a limited time (one hour by default), and events of
+// an earlier pod with the same name are included. The call is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) GetPodEvents(podName string) ([]coreV1.Event, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.GetPodEventsWithContext(ctx, podName)
+}
+
+// GetPodEventsWithContext retrieves the Events associated with the pod like GetPodEvents. The call is governed
+// by the provided context, which allows callers to cancel it or to set its deadline.
+func (k8s *K8SExec) GetPodEventsWithContext(ctx context.Context, podName string) ([]coreV1.Event, error) {
+	selector := fields.Set{
+		"involvedObject.kind": "Pod",
+		"involvedObject.name": podName,
+	}.AsSelector().String()
+	events, err := k8s.Clientset.CoreV1().Events(k8s.Namespace).List(ctx, metaV1.ListOptions{FieldSelector: selector})
+	if err != nil {
+		return nil, apierror.Wrap(err, "listing events of pod %s/%s", k8s.Namespace, podName)
+	}
+
+	slices.SortStableFunc(events.Items, func(a, b coreV1.Event) int {
+		return eventTime(a).Compare(eventTime(b))
+	})
+	return events.Items, nil
+}
+
+// eventTime returns the time of the most recent occurrence of the event. Depending on the component reporting
+// the event, it is set in different fields.
+func eventTime(event coreV1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	}
+	return event.CreationTimestamp.Time
+}
@@ -0,0 +1,273 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"k8s.io/client-go/tools/remotecommand"
+	exec2 "k8s.io/client-go/util/exec"
+	"sync/atomic"
+	"time"
+)
+
+// CheckUtilInContainer verifies the existence of a specified 'util' binary within a container, identified
+// by the container's name and the associated pod's name. The check is bounded by the instance's file-op timeout.
+func (k8s *K8SExec) CheckUtilInContainer(podName, containerName string, util string) bool {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), k8s.fileOpTimeout())
+	defer cancelFunc()
+
+	return k8s.CheckUtilInContainerWithContext(ctx, podName, containerName, util)
+}
+
+// CheckUtilInContainerWithContext verifies the existence of a specified 'util' binary within a container,
+// identified by the container's name and the associated pod's name. The check is governed by the provided context,
+// which allows callers to cancel it or to bound a whole scan with a single deadline.
+// In Windows mode the utility is looked up with where.exe instead of being executed.
+func (k8s *K8SExec) CheckUtilInContainerWithContext(ctx context.Context, podName, containerName string, util string) bool {
+	if k8s.windows {
+		return k8s.checkUtilInWindowsContainer(ctx, podName, containerName, util)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	retCode, _ := k8s.exec(ctx, podName, containerName, []string{util}, nil, &stdout, &stderr, false)
+	// TODO: Maybe it would make sense to make it a positive check for successful execution instead of a negative one
+	return retCode != CommandNotFound && retCode != CommandCannotExecute && retCode != InternalAppError
+}
+
+// exec executes a command provided via standard input ('stdin'), command-line arguments ('cmd'),
+// or both, offering a versatile interface for command execution. Upon completion, it returns a POSIX
+// execution code to indicate the success or failure of the operation, alongside any error encountered
+// during execution for detailed diagnostics. Additionally, the function captures and returns both
+// the standard output ('stdout') and standard error ('stderr') streams, providing details of the command's execution.
+// Standard input is streamed as the command consumes it and never buffered as a whole.
+// Executions failing because of transport problems are retried according to the instance's RetryPolicy,
+// as long as nothing has been streamed from the container yet. Standard input is only replayed if it
+// implements io.Seeker; otherwise executions which already consumed some of it are not retried.
+// Executions interrupted by a restart of the container are re-executed if WithRestartRecovery is enabled.
+// An empty container name selects the pod's default container, see DefaultContainerOf.
+func (k8s *K8SExec) exec(ctx context.Context, podName string, containerName string, cmd []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, tty bool) (ExitCode, error) {
+	return k8s.execTraced(ctx, nil, podName, containerName, cmd, stdin, stdout, stderr, tty, nil)
+}
+
+// execTraced is exec recording details of the execution, such as container restarts it recovered from, in
+// 'trace', and delivering terminal size changes of TTY executions from 'sizes'. Both may be nil.
+func (k8s *K8SExec) execTraced(ctx context.Context, trace *execTrace, podName string, containerName string, cmd []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, tty bool, sizes remotecommand.TerminalSizeQueue) (ExitCode, error) {
+	if containerName == "" {
+		var err error
+		if containerName, err = k8s.DefaultContainer(ctx, podName); err != nil {
+			return InternalAppError, err
+		}
+	}
+
+	if k8s.containerCheck {
+		if err := k8s.checkContainer(ctx, podName, containerName); err != nil {
+			return InternalAppError, err
+		}
+	}
+
+	var streamedIn, streamedOut streamCounter
+	outputs := []io.Writer{stdout, stderr}
+	input := stdin
+	if stdin != nil {
+		input = &countingReader{reader: stdin, counter: &streamedIn}
+	}
+	if stdout != nil {
+		stdout = &countingWriter{writer: stdout, counter: &streamedOut}
+	}
+	if stderr != nil {
+		stderr = &countingWriter{writer: stderr, counter: &streamedOut}
+	}
+	rewind := newRewinder(stdin)
+
+	policy := k8s.retryPolicy
+	restarts := 0
+	for attempt := 1; ; attempt++ {
+		started := time.Now()
+		retCode, err := k8s.stream(ctx, podName, containerName, cmd, input, stdout, stderr, tty, sizes)
+		if err != nil && restarts < k8s.restartRecovery && ctx.Err() == nil && isContainerRestart(err) &&
+			(streamedIn.bytes() == 0 || isSeekable(stdin)) && (streamedOut.bytes() == 0 || resettable(outputs)) &&
+			k8s.waitForContainerRestart(ctx, podName, containerName, started, err) {
+			if streamedIn.bytes() > 0 && !rewind() {
+				return retCode, err
+			}
+			resetOutputs(outputs)
+			streamedIn.count.Store(0)
+			streamedOut.count.Store(0)
+			restarts++
+			if trace != nil {
+				trace.restarts = restarts
+			}
+			attempt = 0
+			continue
+		}
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil || streamedOut.bytes() > 0 || !policy.retryable(retCode, err) {
+			return retCode, err
+		}
+		if streamedIn.bytes() > 0 {
+			if !rewind() {
+				return retCode, err
+			}
+			streamedIn.count.Store(0)
+		}
+
+		delay := policy.delay(attempt)
+		k8s.logger().Warn("retrying command execution", "pod", podName, "container", containerName,
+			"attempt", attempt, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return retCode, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// stream performs a single attempt to execute a command in a container and streams its input and outputs.
+func (k8s *K8SExec) stream(ctx context.Context, podName string, containerName string, cmd []string, stdin io.Reader, stdout io.Writer, stderr io.Writer, tty bool, sizes remotecommand.TerminalSizeQueue) (ExitCode, error) {
+	if k8s.rateLimiter != nil {
+		if err := k8s.rateLimiter.Wait(ctx); err != nil {
+			return InternalAppError, fmt.Errorf("%w: %w", ErrThrottled, err)
+		}
+	}
+
+	k8s.logger().Debug("executing command", "pod", podName, "container", containerName, "command", cmd)
+	err := k8s.execBackend().Stream(ctx, k8s, ExecRequest{
+		Namespace:         k8s.Namespace,
+		Pod:               podName,
+		Container:         containerName,
+		Command:           cmd,
+		Stdin:             stdin,
+		Stdout:            stdout,
+		Stderr:            stderr,
+		TTY:               tty,
+		TerminalSizeQueue: sizes,
+	})
+	if err != nil {
+		exitError := exec2.CodeExitError{}
+		if errors.As(err, &exitError) {
+			return ExitCode(exitError.Code), exitError
+		}
+
+		return InternalAppError, wrapStreamError(ctx, err, podName, containerName)
+	}
+
+	return Success, nil
+}
+
+// streamCounter counts bytes streamed to and from a container during a single command execution.
+type streamCounter struct {
+	count atomic.Int64
+}
+
+func (c *streamCounter) bytes() int64 {
+	return c.count.Load()
+}
+
+// countingReader is an io.Reader counting bytes read from the wrapped reader.
+type countingReader struct {
+	reader  io.Reader
+	counter *streamCounter
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.counter.count.Add(int64(n))
+	return n, err
+}
+
+// countingWriter is an io.Writer counting bytes written to the wrapped writer.
+type countingWriter struct {
+	writer  io.Writer
+	counter *streamCounter
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.counter.count.Add(int64(n))
+	return n, err
+}
+
+// Exec executes a command provided through standard input ('stdin') or as arguments ('args'),
+// or a combination of both. This function returns a pointer to an instance of ExecutionStatus,
+// which encapsulates the results of the command execution. This includes details such as the exit code,
+// error messages, and the outputs captured from both the standard output and standard error streams.
+// timeout has to be provided as time.Duration. A zero timeout selects the instance's default timeout.
+func (k8s *K8SExec) Exec(podName string, containerName string, args []string, stdin io.Reader, timeout time.Duration) *ExecutionStatus {
+	var stdout, stderr bytes.Buffer
+	var errMessage string
+
+	ctx, cancel := context.WithTimeout(context.Background(), k8s.ExecTimeout(timeout))
+	defer cancel()
+
+	// ----- debug ----
+	//var buffer bytes.Buffer
+	//tee := io.TeeReader(stdin, &buffer)
+	//_, _ = io.ReadAll(tee)
+	//fmt.Println(buffer.String())
+	//stdin = bytes.NewReader(buffer.Bytes())
+	// ----- debug ----
+
+	start := time.Now()
+	var trace execTrace
+	retCode, err := k8s.execTraced(ctx, &trace, podName, containerName, args, stdin, &stdout, &stderr, false, nil)
+	if err != nil {
+		errMessage = err.Error()
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrExecTimeout) {
+		retCode = ExecutionTimeOut
+	}
+	status := NewExecutionStatus(podName, containerName, retCode, errMessage, stdout.String(), stderr.String())
+	status.Err = err
+	status.Restarts = trace.restarts
+	status.recordExecution(k8s.Namespace, args, start)
+	return status
+}
+
+// ExecWithContext executes a command provided through standard input ('stdin') or as arguments ('args'),
+// or a combination of both. This function returns a pointer to an instance of ExecutionStatus,
+// which encapsulates the results of the command execution. This includes details such as the exit code,
+// error messages, and the outputs captured from both the standard output and standard error streams.
+// The use of this function must provide a context that will govern the command exeuction. Executions interrupted
+// by the context are reported with the ExecutionTimeOut or ExecutionCancelled exit code.
+func (k8s *K8SExec) ExecWithContext(ctx context.Context, podName string, containerName string, args []string, stdin io.Reader) *ExecutionStatus {
+	var stdout, stderr bytes.Buffer
+	var errMessage string
+
+	start := time.Now()
+	var trace execTrace
+	retCode, err := k8s.execTraced(ctx, &trace, podName, containerName, args, stdin, &stdout, &stderr, false, nil)
+	if err != nil {
+		errMessage = err.Error()
+	}
+	if retCode == InternalAppError && ctx.Err() != nil {
+		retCode = ContextExitCode(ctx.Err())
+	}
+	status := NewExecutionStatus(podName, containerName, retCode, errMessage, stdout.String(), stderr.String())
+	status.Err = err
+	status.Restarts = trace.restarts
+	status.recordExecution(k8s.Namespace, args, start)
+	return status
+}
+
+// ExecStream executes a command provided through standard input ('stdin') or as arguments ('args'),
+// or a combination of both, and streams its outputs directly to the caller-provided 'stdout' and 'stderr'
+// writers instead of buffering them in an ExecutionStatus. This makes it suitable for long-running commands
+// producing large amounts of output, e.g. dumping a database or archiving a directory. Either writer may be
+// nil, in which case the corresponding output is not requested. Since the API server requires at least one
+// stream, standard output is requested and dropped if 'stdin', 'stdout' and 'stderr' are all nil. It returns
+// the exit code of the command and any error encountered; ExecutionTimeOut is returned when the context's
+// deadline was exceeded, and ExecutionCancelled when the context was cancelled.
+// The use of this function must provide a context that will govern the command execution.
+func (k8s *K8SExec) ExecStream(ctx context.Context, podName string, containerName string, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (ExitCode, error) {
+	if stdin == nil && stdout == nil && stderr == nil {
+		stdout = io.Discard
+	}
+	retCode, err := k8s.exec(ctx, podName, containerName, args, stdin, stdout, stderr, false)
+	if retCode == InternalAppError && ctx.Err() != nil {
+		retCode = ContextExitCode(ctx.Err())
+	}
+	return retCode, err
+}
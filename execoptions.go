@@ -0,0 +1,321 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"k8s.io/client-go/tools/remotecommand"
+	"time"
+)
+
+// ExecOption customizes a single command execution performed with ExecWithOptions. New per-call settings are
+// added as new options, so the signature of ExecWithOptions never has to change.
+type ExecOption func(config *execConfig)
+
+// execConfig collects the settings of a single command execution.
+type execConfig struct {
+	tty         bool
+	timeout     time.Duration
+	stdin       io.Reader
+	outputLimit int64
+	capture     CapturePolicy
+	raw         bool
+	user        string
+
+	remoteTimeout time.Duration
+	idleTimeout   time.Duration
+	killOnCancel  bool
+	combined      bool
+	pidFile       string
+	terminalSizes remotecommand.TerminalSizeQueue
+	recorder      *CastRecorder
+	compress      bool
+
+	truncationMarker *string
+}
+
+// WithTTY allocates a pseudo-terminal for the command. Standard error of commands running in a terminal is
+// merged into standard output by the container runtime, so it is reported as part of Stdout.
+func WithTTY() ExecOption {
+	return func(config *execConfig) {
+		config.tty = true
+	}
+}
+
+// WithTimeout bounds the execution with a timeout, in addition to the deadline of the context. Executions
+// exceeding it are reported with the ExecutionTimeOut exit code.
+func WithTimeout(timeout time.Duration) ExecOption {
+	return func(config *execConfig) {
+		config.timeout = timeout
+	}
+}
+
+// WithStdin delivers the content of 'stdin' to the command's standard input. The reader is streamed to
+// the container in chunks as the command consumes it and is never read into memory as a whole, so it can
+// deliver inputs of any size, see ExecWithStdinFile.
+func WithStdin(stdin io.Reader) ExecOption {
+	return func(config *execConfig) {
+		config.stdin = stdin
+	}
+}
+
+// DefaultTruncationMarker is appended to outputs truncated because of WithMaxOutputBytes.
+const DefaultTruncationMarker = "\n[... output truncated ...]\n"
+
+// WithMaxOutputBytes limits the number of bytes of each output kept in the ExecutionStatus, protecting the client
+// from running out of memory when a command produces runaway output. Output beyond the limit is still read from
+// the container, so the command is not blocked, but it is discarded; the kept output is followed by a truncation
+// marker (DefaultTruncationMarker unless set with WithTruncationMarker) and the truncation is recorded in
+// StdoutTruncated and StderrTruncated.
+func WithMaxOutputBytes(limit int64) ExecOption {
+	return func(config *execConfig) {
+		config.outputLimit = limit
+	}
+}
+
+// WithOutputLimit is an alias of WithMaxOutputBytes.
+func WithOutputLimit(limit int64) ExecOption {
+	return WithMaxOutputBytes(limit)
+}
+
+// WithTruncationMarker sets the marker appended to outputs truncated because of WithMaxOutputBytes.
+// An empty marker truncates outputs silently, still recording the truncation in the ExecutionStatus.
+func WithTruncationMarker(marker string) ExecOption {
+	return func(config *execConfig) {
+		config.truncationMarker = &marker
+	}
+}
+
+// WithCapture selects the outputs kept in the ExecutionStatus, see CapturePolicy.
+func WithCapture(policy CapturePolicy) ExecOption {
+	return func(config *execConfig) {
+		config.capture = policy
+	}
+}
+
+// WithRawOutput keeps the exact bytes of the outputs in StdoutRaw and StderrRaw instead of splitting them into
+// lines in Stdout and Stderr, which corrupts binary output and trailing whitespace.
+func WithRawOutput() ExecOption {
+	return func(config *execConfig) {
+		config.raw = true
+	}
+}
+
+// ExecWithOptions executes a command provided as arguments ('args') and returns a pointer to an instance of
+// ExecutionStatus, which encapsulates the results of the command execution: the exit code, error messages,
+// and the outputs captured from both the standard output and standard error streams. The execution can be
+// customized with options, e.g. WithStdin, WithTimeout or WithOutputLimit.
+// The use of this function must provide a context that will govern the command execution.
+func (k8s *K8SExec) ExecWithOptions(ctx context.Context, podName string, containerName string, args []string, opts ...ExecOption) *ExecutionStatus {
+	var config execConfig
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if config.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.timeout)
+		defer cancel()
+	}
+
+	if config.killOnCancel {
+		config.pidFile = tempPath(".pid")
+	}
+	start := time.Now()
+	cmd, err := k8s.prepareCommand(ctx, podName, containerName, args, config)
+	if err != nil {
+		status := NewExecutionStatus(podName, containerName, InternalAppError, err.Error(), "", "")
+		status.Err = err
+		status.recordExecution(k8s.Namespace, args, start)
+		return status
+	}
+
+	var stdoutBuffer, stderrBuffer limitedBuffer
+	stdoutBuffer.limit, stderrBuffer.limit = config.outputLimit, config.outputLimit
+	var stdoutHash, stderrHash hash.Hash
+	var stdout, stderr io.Writer
+
+	switch config.capture {
+	case CaptureStderr:
+		stderr = &stderrBuffer
+	case CaptureExitCode:
+		// the API server rejects executions requesting no streams, so without standard input the output is
+		// requested and dropped
+		if config.stdin == nil {
+			stdout = io.Discard
+		}
+	case CaptureHashes:
+		stdoutHash, stderrHash = sha256.New(), sha256.New()
+		stdout, stderr = stdoutHash, stderrHash
+	default:
+		stdout, stderr = &stdoutBuffer, &stderrBuffer
+	}
+	if config.combined && stdout != nil && stderr != nil {
+		combined := &syncWriter{writer: stdout}
+		stdout, stderr = combined, combined
+	}
+	if config.tty {
+		// the container runtime merges standard error into standard output of terminals
+		stderr = nil
+	}
+
+	if config.recorder != nil {
+		stdout = config.recorder.Output(stdout)
+		if !config.tty {
+			stderr = config.recorder.Output(stderr)
+		}
+		if config.stdin != nil {
+			config.stdin = config.recorder.Input(config.stdin)
+		}
+	}
+
+	if config.idleTimeout > 0 {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		watchdog := newIdleWatchdog(config.idleTimeout, cancel)
+		defer watchdog.stop()
+
+		stdout = watchdog.writer(stdout)
+		if !config.tty {
+			stderr = watchdog.writer(stderr)
+		}
+	}
+
+	var decompressor *decompressingWriter
+	if config.compress && !config.tty {
+		// the command's output is preceded by a flag even if the output itself is not kept
+		decompressor = &decompressingWriter{writer: io.Discard}
+		if stdout != nil {
+			decompressor.writer = stdout
+		}
+		stdout = decompressor
+	}
+
+	var errMessage string
+	var trace execTrace
+	retCode, err := k8s.execTraced(ctx, &trace, podName, containerName, cmd, config.stdin, stdout, stderr, config.tty, config.terminalSizes)
+	if decompressor != nil {
+		if decompressErr := decompressor.finish(); decompressErr != nil && err == nil {
+			retCode = InternalAppError
+			err = fmt.Errorf("exec in %s/%s: decompressing output: %w", podName, containerName, decompressErr)
+		}
+	}
+	if err != nil {
+		errMessage = err.Error()
+	}
+	if config.killOnCancel && ctx.Err() != nil {
+		k8s.killRemote(ctx, podName, containerName, config.pidFile)
+	}
+	if retCode == InternalAppError && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		retCode = ExecutionTimeOut
+	}
+	if retCode == InternalAppError && errors.Is(context.Cause(ctx), ErrIdleTimeout) {
+		retCode = ExecutionTimeOut
+		err = fmt.Errorf("exec in %s/%s: %w (%s)", podName, containerName, ErrIdleTimeout, config.idleTimeout)
+		errMessage = err.Error()
+	}
+	if retCode == InternalAppError && errors.Is(ctx.Err(), context.Canceled) {
+		retCode = ExecutionCancelled
+	}
+	if retCode == remoteTimeoutExitCode && config.remoteTimeout > 0 {
+		retCode = ExecutionTimeOut
+		err = fmt.Errorf("exec in %s/%s: %w: terminated in the container after %s", podName, containerName, ErrExecTimeout, config.remoteTimeout)
+		errMessage = err.Error()
+	}
+
+	marker := DefaultTruncationMarker
+	if config.truncationMarker != nil {
+		marker = *config.truncationMarker
+	}
+	stdoutBuffer.markTruncation(marker)
+	stderrBuffer.markTruncation(marker)
+
+	var status *ExecutionStatus
+	if config.raw {
+		status = NewExecutionStatus(podName, containerName, retCode, errMessage, "", "")
+		status.Stdout, status.Stderr = nil, nil
+		if stdout != nil && config.capture != CaptureHashes {
+			status.StdoutRaw = stdoutBuffer.Bytes()
+		}
+		if stderr != nil && config.capture != CaptureHashes {
+			status.StderrRaw = stderrBuffer.Bytes()
+		}
+	} else {
+		status = NewExecutionStatus(podName, containerName, retCode, errMessage, stdoutBuffer.String(), stderrBuffer.String())
+	}
+	status.Err = err
+	status.StdoutTruncated = stdoutBuffer.truncated
+	status.StderrTruncated = stderrBuffer.truncated
+	status.Restarts = trace.restarts
+	status.recordExecution(k8s.Namespace, args, start)
+	if config.capture == CaptureHashes {
+		status.StdoutSHA256 = hex.EncodeToString(stdoutHash.Sum(nil))
+		if stderrHash != nil && !config.tty {
+			status.StderrSHA256 = hex.EncodeToString(stderrHash.Sum(nil))
+		}
+	}
+	return status
+}
+
+// prepareCommand returns the command actually executed in the container: 'args' wrapped according to
+// the options of the execution, e.g. to run it as another user. The remote timeout wraps the command itself,
+// so that switching users does not prevent the timeout utility from terminating it, while the PID recording
+// for WithKillOnCancel wraps everything, so the whole process tree can be terminated.
+func (k8s *K8SExec) prepareCommand(ctx context.Context, podName string, containerName string, args []string, config execConfig) ([]string, error) {
+	cmd := args
+	if config.remoteTimeout > 0 {
+		cmd = remoteTimeoutCommand(config.remoteTimeout, cmd)
+	}
+	if config.compress && !config.tty {
+		cmd = compressOutputCommand(cmd)
+	}
+	if config.user != "" {
+		var err error
+		if cmd, err = k8s.runAsCommand(ctx, podName, containerName, config.user, cmd); err != nil {
+			return nil, err
+		}
+	}
+	if config.killOnCancel {
+		cmd = killOnCancelCommand(config.pidFile, cmd)
+	}
+	return cmd, nil
+}
+
+// limitedBuffer is a bytes.Buffer keeping at most 'limit' bytes and silently discarding the rest.
+// A limit lower than one means no limit.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int64
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.limit > 0 {
+		if remaining := b.limit - int64(b.Len()); remaining < int64(len(p)) {
+			_, _ = b.Buffer.Write(p[:max(remaining, 0)])
+			b.truncated = true
+			return len(p), nil
+		}
+	}
+	return b.Buffer.Write(p)
+}
+
+// Reset discards the buffered content, allowing to collect the output of a re-executed command.
+func (b *limitedBuffer) Reset() {
+	b.Buffer.Reset()
+	b.truncated = false
+}
+
+// markTruncation appends the marker to the buffer if any output was discarded. Writes following it are discarded.
+func (b *limitedBuffer) markTruncation(marker string) {
+	if b.truncated {
+		b.Buffer.WriteString(marker)
+		b.limit = int64(b.Len())
+	}
+}
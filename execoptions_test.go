@@ -0,0 +1,60 @@
+package k8sexec
+
+import (
+	"testing"
+)
+
+func TestLimitedBufferMarkTruncation(t *testing.T) {
+	const marker = "\n[truncated]"
+
+	tests := []struct {
+		name      string
+		limit     int64
+		writes    []string
+		want      string
+		truncated bool
+	}{
+		{name: "no limit", limit: 0, writes: []string{"hello ", "world"}, want: "hello world"},
+		{name: "below the limit", limit: 20, writes: []string{"hello ", "world"}, want: "hello world"},
+		{name: "exactly the limit", limit: 11, writes: []string{"hello ", "world"}, want: "hello world"},
+		{name: "single write above the limit", limit: 5, writes: []string{"hello world"}, want: "hello" + marker, truncated: true},
+		{name: "write crossing the limit", limit: 8, writes: []string{"hello ", "world"}, want: "hello wo" + marker, truncated: true},
+		{name: "writes after the limit was reached", limit: 5, writes: []string{"hello", " ", "world"}, want: "hello" + marker, truncated: true},
+		{name: "empty output", limit: 5, writes: nil, want: ""},
+		{name: "empty writes", limit: 1, writes: []string{"", "", "x"}, want: "x"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buffer := &limitedBuffer{limit: test.limit}
+			for _, write := range test.writes {
+				if n, err := buffer.Write([]byte(write)); n != len(write) || err != nil {
+					t.Fatalf("Write(%q) = %d, %v", write, n, err)
+				}
+			}
+			buffer.markTruncation(marker)
+			if buffer.truncated != test.truncated {
+				t.Errorf("truncated = %t, want %t", buffer.truncated, test.truncated)
+			}
+			if test.truncated {
+				// output following the marker, e.g. of a command still running, is discarded
+				_, _ = buffer.Write([]byte("late"))
+			}
+			if got := buffer.String(); got != test.want {
+				t.Errorf("buffer holds %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLimitedBufferReset(t *testing.T) {
+	buffer := &limitedBuffer{limit: 4}
+	_, _ = buffer.Write([]byte("discarded output"))
+	buffer.Reset()
+	_, _ = buffer.Write([]byte("new"))
+	buffer.markTruncation("[truncated]")
+
+	if buffer.truncated || buffer.String() != "new" {
+		t.Errorf("buffer holds %q, truncated %t after a reset", buffer.String(), buffer.truncated)
+	}
+}
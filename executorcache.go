@@ -0,0 +1,103 @@
+package k8sexec
+
+import (
+	"container/list"
+	"k8s.io/client-go/tools/remotecommand"
+	"net/url"
+	"sync"
+)
+
+// DefaultExecutorCacheSize is the number of executors kept by the executor cache when no size is given.
+const DefaultExecutorCacheSize = 256
+
+// WithExecutorCache enables caching of executors, including their SPDY transports, per pod, container and
+// command, so batch runs executing the same commands in the same containers many times do not rebuild them for
+// every execution. The cache implies the transport cache (see WithTransportCache), so TLS sessions are resumed
+// instead of fully renegotiated. Exec connections are upgraded and hijacked by the exec protocol, so each
+// execution still opens its own connection. At most 'size' executors are kept (DefaultExecutorCacheSize if it is
+// not positive), the least recently used ones are evicted first, and all executors of a container are evicted
+// when an execution fails because the pod or the container was restarted or deleted. The cache applies to
+// the default SPDY backend.
+func WithExecutorCache(size int) Option {
+	return func(k8s *K8SExec) {
+		if size <= 0 {
+			size = DefaultExecutorCacheSize
+		}
+		k8s.executors = newExecutorCache(size)
+		if k8s.transports == nil {
+			k8s.transports = &transportCache{}
+		}
+	}
+}
+
+// executorCache keeps idle executors keyed by their exec URL. An executor is taken out of the cache while
+// it streams, since SPDY executors must not be used by several streams at the same time.
+type executorCache struct {
+	mu      sync.Mutex
+	size    int
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+// executorCacheEntry is an idle executor for an exec URL of a container identified by 'container'.
+type executorCacheEntry struct {
+	key       string
+	container string
+	executor  remotecommand.Executor
+}
+
+func newExecutorCache(size int) *executorCache {
+	return &executorCache{size: size, lru: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// executorFor returns an idle executor for the exec URL, creating a new one if there is none. The executor
+// must be handed back with release or discard once its stream is finished.
+func (k8s *K8SExec) executorFor(execURL *url.URL) (remotecommand.Executor, error) {
+	cache := k8s.executors
+	key := execURL.String()
+
+	cache.mu.Lock()
+	element, ok := cache.entries[key]
+	if ok {
+		cache.lru.Remove(element)
+		delete(cache.entries, key)
+	}
+	cache.mu.Unlock()
+
+	if ok {
+		return element.Value.(*executorCacheEntry).executor, nil
+	}
+	return k8s.newExecutor(execURL)
+}
+
+// release returns an idle executor to the cache, evicting the least recently used one if the cache is full.
+func (cache *executorCache) release(execURL *url.URL, container string, executor remotecommand.Executor) {
+	key := execURL.String()
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if _, ok := cache.entries[key]; ok {
+		// another execution of the same command released its executor first
+		return
+	}
+	cache.entries[key] = cache.lru.PushFront(&executorCacheEntry{key: key, container: container, executor: executor})
+	for cache.lru.Len() > cache.size {
+		oldest := cache.lru.Back()
+		cache.lru.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*executorCacheEntry).key)
+	}
+}
+
+// evictContainer removes all executors of a container, e.g. after it was restarted.
+func (cache *executorCache) evictContainer(container string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	for element := cache.lru.Front(); element != nil; {
+		next := element.Next()
+		if entry := element.Value.(*executorCacheEntry); entry.container == container {
+			cache.lru.Remove(element)
+			delete(cache.entries, entry.key)
+		}
+		element = next
+	}
+}
@@ -0,0 +1,147 @@
+package k8sexec
+
+import (
+	"errors"
+	"fmt"
+	exec2 "k8s.io/client-go/util/exec"
+	"maps"
+	"sync"
+)
+
+// ExitCode is an enumeration of possible exit codes with descriptive names.
+// It provides a more idiomatic way to refer to exit codes within the Go application.
+type ExitCode int
+
+const (
+	ExecutionTimeOut ExitCode = iota - 2
+	InternalAppError
+	Success
+	GeneralError
+	IncorrectUsage
+	CommandCannotExecute  = 126
+	CommandNotFound       = 127
+	InvalidArgumentToExit = 128
+	// Skips to specific values after the iota increment
+	ScriptTerminatedByControlC ExitCode = 130
+	ExitStatusOutOfRange       ExitCode = 255
+	// Signal based exit codes (128+n)
+	FatalErrorSignal1 ExitCode = 129
+	// FatalErrorSignal2 is omitted as it overlaps with ScriptTerminatedByControlC
+	FatalErrorSignal3  ExitCode = 131
+	FatalErrorSignal4  ExitCode = 132
+	FatalErrorSignal5  ExitCode = 133
+	FatalErrorSignal6  ExitCode = 134
+	FatalErrorSignal7  ExitCode = 135
+	FatalErrorSignal8  ExitCode = 136
+	FatalErrorSignal9  ExitCode = 137
+	FatalErrorSignal10 ExitCode = 138
+	FatalErrorSignal11 ExitCode = 139
+	FatalErrorSignal12 ExitCode = 140
+	FatalErrorSignal13 ExitCode = 141
+	FatalErrorSignal14 ExitCode = 142
+	FatalErrorSignal15 ExitCode = 143
+)
+
+// ExecutionSkipped is reported for targets of batch runs that were not executed at all, e.g. because
+// the health gate found the pod unhealthy. The reason is provided in ExecutionStatus.SkipReason.
+const ExecutionSkipped ExitCode = -3
+
+// ExecutionCancelled is reported for executions interrupted because their context was cancelled, e.g. by
+// the user pressing Control-C, as opposed to ExecutionTimeOut reported when the context's deadline was exceeded.
+const ExecutionCancelled ExitCode = -4
+
+// exitCodeDescriptionsLock guards exitCodeDescriptions, which can be modified with RegisterExitCode.
+var exitCodeDescriptionsLock sync.RWMutex
+
+// exitCodeDescriptions maps possible exit codes with descriptive names.
+var exitCodeDescriptions map[ExitCode]string = map[ExitCode]string{
+	-4:  "Execution cancelled",
+	-3:  "Execution skipped",
+	-2:  "Execution timed out",
+	-1:  "Internal app error",
+	0:   "Success",
+	1:   "General error, unspecified error",
+	2:   "Incorrect usage or syntax of the command",
+	126: "Command cannot execute",
+	127: "Command not found",
+	128: "Invalid argument to exit",
+	130: "Script terminated by Control-C (SIGINT)",
+	255: "Exit status out of range",
+	// Signal based exit codes (128+n)
+	129: "Fatal error signal 1 (SIGHUP)",
+	//130: "Fatal error signal 2 (SIGINT)",
+	131: "Fatal error signal 3 (SIGQUIT)",
+	132: "Fatal error signal 4 (SIGILL)",
+	133: "Fatal error signal 5 (SIGTRAP)",
+	134: "Fatal error signal 6 (SIGABRT/SIGIOT)",
+	135: "Fatal error signal 7 (SIGBUS)",
+	136: "Fatal error signal 8 (SIGFPE)",
+	137: "Fatal error signal 9 (SIGKILL)",
+	138: "Fatal error signal 10 (SIGUSR1)",
+	139: "Fatal error signal 11 (SIGSEGV)",
+	140: "Fatal error signal 12 (SIGUSR2)",
+	141: "Fatal error signal 13 (SIGPIPE)",
+	142: "Fatal error signal 14 (SIGALRM)",
+	143: "Fatal error signal 15 (SIGTERM)",
+	// Add more signal based codes as needed
+}
+
+// RegisterExitCode registers a description of an exit code, or overrides the predefined one. It allows
+// applications to describe their specific exit codes, e.g. RegisterExitCode(42, "License check failed"),
+// so they are reported by GetExitCode, GetExitCodeDescription and ExecutionStatus.ExitDescription.
+// An empty description removes the entry. RegisterExitCode is safe for concurrent use, but descriptions are
+// global to the package and are best registered once during initialization.
+func RegisterExitCode(code ExitCode, description string) {
+	exitCodeDescriptionsLock.Lock()
+	defer exitCodeDescriptionsLock.Unlock()
+
+	if description == "" {
+		delete(exitCodeDescriptions, code)
+		return
+	}
+	exitCodeDescriptions[code] = description
+}
+
+// ExitCodeDescriptions returns a copy of the exit-code description table, including registered descriptions.
+func ExitCodeDescriptions() map[ExitCode]string {
+	exitCodeDescriptionsLock.RLock()
+	defer exitCodeDescriptionsLock.RUnlock()
+
+	return maps.Clone(exitCodeDescriptions)
+}
+
+// lookupExitCodeDescription returns the description of the exit code and whether there is one. Codes unknown
+// on POSIX systems are looked up among Windows-specific codes, which do not overlap with them.
+func lookupExitCodeDescription(code ExitCode) (string, bool) {
+	exitCodeDescriptionsLock.RLock()
+	defer exitCodeDescriptionsLock.RUnlock()
+
+	if description, ok := exitCodeDescriptions[code]; ok {
+		return description, ok
+	}
+	description, ok := windowsExitCodeDescriptions[code]
+	return description, ok
+}
+
+// GetExitCode returns an ExitCode retrieved from CodeExitError type returned by k8s.io/client-go/util/exec and
+// a corresponding description from exitCodeDescriptions map.
+func GetExitCode(err error) (ExitCode, string) {
+	var e exec2.CodeExitError
+	if !errors.As(err, &e) {
+		return InternalAppError, ""
+	}
+	description, ok := lookupExitCodeDescription(ExitCode(e.Code))
+	if !ok {
+		return ExitCode(e.Code), fmt.Sprintf("Exit code %d description not found!", e.Code)
+	}
+	return ExitCode(e.Code), description
+}
+
+// GetExitCodeDescription returns a string description for a given exit code.
+// It looks up the code in the predefined exitCodeDescriptions map, including descriptions registered with
+// RegisterExitCode. If the code is found, it returns the corresponding description. If not, it returns
+// an empty string.
+func GetExitCodeDescription(code ExitCode) string {
+	description, _ := lookupExitCodeDescription(code)
+	return description
+}
@@ -0,0 +1,82 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+)
+
+// Responder answers a prompt whenever it appears in the output of a command driven by Expect.
+type Responder struct {
+	// Pattern is matched against the command's output (standard output and standard error) not matched yet.
+	Pattern *regexp.Regexp
+	// Response is written to the command's standard input whenever Pattern matches, e.g. "y\n".
+	Response string
+	// MaxTimes limits how many times the responder answers. Zero means no limit.
+	MaxTimes int
+}
+
+// Expect executes a command provided as arguments ('args') and answers its prompts with 'responders' for as long
+// as it runs: whenever a responder's pattern appears in the command's output, its response is written to
+// the command's standard input. Unlike Interact, which follows a fixed sequence of steps, responders react to
+// prompts appearing in any order and any number of times, e.g. password prompts repeated for several
+// accounts or y/n confirmations of a maintenance script. When several patterns match, the one matching earliest
+// in the output wins. Standard input stays open until the command exits, so the command must terminate on its
+// own or be bounded by the context. The returned ExecutionStatus holds the complete outputs of the command;
+// the error describes a response which could not be delivered.
+func (k8s *K8SExec) Expect(ctx context.Context, podName string, containerName string, args []string, responders []Responder) (*ExecutionStatus, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	d := newDialog()
+	result := d.start(ctx, k8s, podName, containerName, args)
+
+	respondErr := d.respond(ctx, responders)
+	if respondErr != nil {
+		cancel()
+	}
+
+	status := d.status(podName, containerName, <-result)
+	status.recordExecution(k8s.Namespace, args, start)
+	return status, respondErr
+}
+
+// respond answers prompts appearing in the output with the responders until the command finishes.
+func (d *dialog) respond(ctx context.Context, responders []Responder) error {
+	answered := make([]int, len(responders))
+	for {
+		d.mu.Lock()
+		output := d.combined.Bytes()[d.offset:]
+		chosen, begin, end := -1, 0, 0
+		for i, responder := range responders {
+			if responder.MaxTimes > 0 && answered[i] >= responder.MaxTimes {
+				continue
+			}
+			if match := responder.Pattern.FindIndex(output); match != nil && (chosen < 0 || match[0] < begin) {
+				chosen, begin, end = i, match[0], match[1]
+			}
+		}
+		if chosen >= 0 {
+			d.offset += end
+			d.mu.Unlock()
+
+			answered[chosen]++
+			// standard input is closed once the command exits, which makes late responses pointless but harmless
+			if err := d.send(ctx, responders[chosen].Response, nil); err != nil && !errors.Is(err, io.ErrClosedPipe) {
+				return fmt.Errorf("responding to %q: %w", responders[chosen].Pattern, err)
+			}
+			continue
+		}
+		finished, changed := d.finished, d.changed
+		d.mu.Unlock()
+
+		if finished {
+			return nil
+		}
+		<-changed
+	}
+}
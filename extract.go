@@ -0,0 +1,150 @@
+package k8sexec
+
+import (
+	"context"
+	"fmt"
+	"github.com/hhruszka/k8sexec/internal/apierror"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchTools "k8s.io/client-go/tools/watch"
+)
+
+// extractContainerName is the name of the container of pods created by ExtractFromImage.
+const extractContainerName = "extract"
+
+// DefaultExtractLifetime bounds the lifetime of pods created by ExtractFromImage when ExtractOptions.Lifetime is
+// not set, so they are removed by the cluster even if the extraction is interrupted before deleting them.
+const DefaultExtractLifetime = 3600
+
+// ExtractOptions configures ExtractFromImage.
+type ExtractOptions struct {
+	// Command replaces the image's entrypoint, keeping the container running while files are copied out of it.
+	// It defaults to "sleep" for the pod's lifetime, so the image must provide sleep unless it is set.
+	Command []string
+	// Lifetime is the maximum lifetime of the pod in seconds (its activeDeadlineSeconds). DefaultExtractLifetime
+	// is used when it is not set.
+	Lifetime int64
+	// ImagePullSecrets are the names of secrets used to pull the image.
+	ImagePullSecrets []string
+	// NodeName, if set, schedules the pod on the node, e.g. one which already has the image.
+	NodeName string
+}
+
+// ExtractFromImage copies the file or directory 'remotePath' out of the container image 'image' to 'localPath',
+// like CopyFromPod, without exec'ing into a workload pod, e.g. to inspect images whose pods crash-loop. A short-lived
+// pod running the image with its entrypoint replaced by options.Command is created in the instance's namespace,
+// the path is copied once the pod runs, and the pod is deleted afterwards, even if the copy fails or 'ctx' is
+// cancelled. Pods which cannot start, e.g. because the image cannot be pulled, are reported with their waiting
+// reason. The image must provide 'tar' for the copy. The extraction is governed by the provided context.
+func (k8s *K8SExec) ExtractFromImage(ctx context.Context, image string, remotePath string, localPath string, options ExtractOptions) error {
+	lifetime := options.Lifetime
+	if lifetime <= 0 {
+		lifetime = DefaultExtractLifetime
+	}
+	command := options.Command
+	if len(command) == 0 {
+		command = []string{"sleep", fmt.Sprint(lifetime)}
+	}
+	var gracePeriod int64
+	pod := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{
+			GenerateName: "k8sexec-extract-",
+			Labels:       map[string]string{"app.kubernetes.io/managed-by": "k8sexec"},
+		},
+		Spec: coreV1.PodSpec{
+			Containers:                    []coreV1.Container{{Name: extractContainerName, Image: image, Command: command}},
+			RestartPolicy:                 coreV1.RestartPolicyNever,
+			ActiveDeadlineSeconds:         &lifetime,
+			TerminationGracePeriodSeconds: &gracePeriod,
+			NodeName:                      options.NodeName,
+			AutomountServiceAccountToken:  new(bool),
+		},
+	}
+	for _, secret := range options.ImagePullSecrets {
+		pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, coreV1.LocalObjectReference{Name: secret})
+	}
+
+	created, err := k8s.Clientset.CoreV1().Pods(k8s.Namespace).Create(ctx, pod, metaV1.CreateOptions{})
+	if err != nil {
+		return apierror.Wrap(err, "creating pod for image %s in %s", image, k8s.Namespace)
+	}
+	k8s.logger().Debug("extraction pod created", "pod", created.Name, "image", image)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), k8s.fileOpTimeout())
+		defer cancel()
+		err := k8s.Clientset.CoreV1().Pods(k8s.Namespace).Delete(ctx, created.Name, metaV1.DeleteOptions{GracePeriodSeconds: &gracePeriod})
+		if err != nil {
+			k8s.logger().Warn("cannot delete extraction pod", "pod", created.Name, "error", err)
+		}
+	}()
+
+	if err := k8s.waitForPodRunning(ctx, created.Name); err != nil {
+		return fmt.Errorf("extracting %s from image %s: %w", remotePath, image, err)
+	}
+	return k8s.CopyFromPod(ctx, created.Name, extractContainerName, remotePath, localPath)
+}
+
+// waitForPodRunning waits until all containers of the pod with the given name are running. It fails if the pod
+// terminates or a container cannot be started, e.g. because its image cannot be pulled.
+func (k8s *K8SExec) waitForPodRunning(ctx context.Context, podName string) error {
+	running := func(obj interface{}) (bool, error) {
+		pod, ok := obj.(*coreV1.Pod)
+		if !ok {
+			return false, nil
+		}
+		switch pod.Status.Phase {
+		case coreV1.PodFailed, coreV1.PodSucceeded:
+			return false, fmt.Errorf("pod %s terminated: %s %s", podName, pod.Status.Reason, pod.Status.Message)
+		case coreV1.PodRunning:
+			for _, status := range pod.Status.ContainerStatuses {
+				if status.State.Running == nil {
+					return false, nil
+				}
+			}
+			return true, nil
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if waiting := status.State.Waiting; waiting != nil && isStartFailure(waiting.Reason) {
+				return false, fmt.Errorf("container %s of pod %s cannot start: %s: %s", status.Name, podName, waiting.Reason, waiting.Message)
+			}
+		}
+		return false, nil
+	}
+
+	precondition := func(store cache.Store) (bool, error) {
+		obj, exists, err := store.GetByKey(k8s.Namespace + "/" + podName)
+		if err != nil || !exists {
+			return false, err
+		}
+		return running(obj)
+	}
+	condition := func(event watch.Event) (bool, error) {
+		switch event.Type {
+		case watch.Deleted:
+			return false, fmt.Errorf("pod %s was deleted", podName)
+		case watch.Added, watch.Modified:
+			return running(event.Object)
+		}
+		return false, nil
+	}
+
+	lw := cache.NewListWatchFromClient(k8s.Clientset.CoreV1().RESTClient(), "pods", k8s.Namespace,
+		fields.OneTermEqualSelector("metadata.name", podName))
+	if _, err := watchTools.UntilWithSync(ctx, lw, &coreV1.Pod{}, precondition, condition); err != nil {
+		return fmt.Errorf("waiting for pod %s/%s to run: %w", k8s.Namespace, podName, err)
+	}
+	return nil
+}
+
+// isStartFailure reports whether the reason of a waiting container means that it cannot be started without
+// intervention.
+func isStartFailure(reason string) bool {
+	switch reason {
+	case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "CreateContainerConfigError", "CreateContainerError":
+		return true
+	}
+	return false
+}
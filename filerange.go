@@ -0,0 +1,52 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// readFileRangeScript writes the range of the file given as the first parameter starting at the offset given
+// as the second parameter and of the length given as the third parameter, zero meaning the rest of the file, to
+// standard output. It uses 'tail' and 'head', which seek to the offset, if the container provides them, and
+// 'dd' otherwise, falling back to byte-sized blocks if dd does not support byte offsets.
+const readFileRangeScript = checkReadableScript + `f=$1 off=$2 len=$3
+if command -v tail >/dev/null 2>&1 && { [ "$len" -eq 0 ] || command -v head >/dev/null 2>&1; }; then
+	if [ "$len" -eq 0 ]; then
+		exec tail -c +$((off + 1)) -- "$f"
+	fi
+	tail -c +$((off + 1)) -- "$f" | head -c "$len"
+	exit 0
+fi
+command -v dd >/dev/null 2>&1 || { echo "no reader available" >&2; exit 127; }
+count=
+[ "$len" -eq 0 ] || count="count=$len"
+if dd if=/dev/null iflag=skip_bytes,count_bytes count=0 2>/dev/null; then
+	exec dd if="$f" bs=65536 iflag=skip_bytes,count_bytes skip="$off" $count 2>/dev/null
+fi
+exec dd if="$f" bs=1 skip="$off" $count 2>/dev/null`
+
+// ReadFileRange reads 'length' bytes of the file 'path' in a container, identified by the container's name and
+// the associated pod's name, starting at 'offset', so only the needed part of huge log or data files is
+// transferred. A 'length' of zero reads the rest of the file. Fewer bytes are returned if the file ends
+// before the range does. The range is read with 'tail' and 'head', or with 'dd' in containers lacking them;
+// containers lacking all of them are reported with an error wrapping ErrUtilNotFound. Missing and unreadable
+// files are reported with errors wrapping ErrFileNotFound and ErrFileNotReadable. The read is governed by
+// the provided context.
+func (k8s *K8SExec) ReadFileRange(ctx context.Context, podName string, containerName string, path string, offset int64, length int64) ([]byte, error) {
+	if offset < 0 || length < 0 {
+		return nil, fmt.Errorf("reading %s/%s:%s: invalid range %d+%d", podName, containerName, path, offset, length)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"sh", "-c", readFileRangeScript, "sh", path, strconv.FormatInt(offset, 10), strconv.FormatInt(length, 10)}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if retCode == CommandNotFound && bytes.Contains(stderr.Bytes(), []byte("no reader available")) {
+		return nil, fmt.Errorf("reading %s/%s:%s: %w: tail, head or dd", podName, containerName, path, ErrUtilNotFound)
+	}
+	if err := fileError("reading", podName, containerName, path, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,145 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// findFilesScript prints paths under the directory given as the first parameter matching the predicates given
+// as the remaining parameters: a name or path pattern, a find type letter, minimum and maximum sizes in bytes,
+// maximum and minimum ages in minutes, a depth limit and a result limit, empty or zero values disabling
+// the predicates. It uses find if the container provides it and walks the tree with the shell otherwise.
+// Unreadable directories are skipped.
+const findFilesScript = `[ -e "$1" ] || [ -L "$1" ] || exit 66
+[ -d "$1" ] || exit 65
+root=$1 pattern=$2 type=$3 min=$4 max=$5 newer=$6 older=$7 depth=$8 limit=$9
+search() {
+	if command -v find >/dev/null 2>&1; then
+		set -- "$root"
+		[ "$depth" -gt 0 ] && set -- "$@" -maxdepth "$depth"
+		set -- "$@" -mindepth 1
+		case $pattern in
+		*/*) set -- "$@" -path "$pattern" ;;
+		?*) set -- "$@" -name "$pattern" ;;
+		esac
+		[ -n "$type" ] && set -- "$@" -type "$type"
+		[ -n "$min" ] && set -- "$@" -size +$((min - 1))c
+		[ -n "$max" ] && set -- "$@" -size -$((max + 1))c
+		[ -n "$newer" ] && set -- "$@" -mmin -"$newer"
+		[ -n "$older" ] && set -- "$@" -mmin +"$older"
+		find "$@" -print 2>/dev/null
+		return 0
+	fi
+	now=$(date +%s)
+	walk "$root" 1
+}
+matches() {
+	case $pattern in
+	*/*) case $1 in $pattern) ;; *) return 1 ;; esac ;;
+	?*) case ${1##*/} in $pattern) ;; *) return 1 ;; esac ;;
+	esac
+	case $type in
+	f) [ -f "$1" ] && [ ! -L "$1" ] ;;
+	d) [ -d "$1" ] && [ ! -L "$1" ] ;;
+	l) [ -L "$1" ] ;;
+	p) [ -p "$1" ] ;;
+	s) [ -S "$1" ] ;;
+	c) [ -c "$1" ] ;;
+	b) [ -b "$1" ] ;;
+	esac || return 1
+	if [ -n "$min$max" ]; then
+		size=$(wc -c < "$1" 2>/dev/null) || return 1
+		[ -z "$min" ] || [ "$size" -ge "$min" ] || return 1
+		[ -z "$max" ] || [ "$size" -le "$max" ] || return 1
+	fi
+	if [ -n "$newer$older" ]; then
+		age=$(( (now - $(date -r "$1" +%s 2>/dev/null || echo "$now")) / 60 ))
+		[ -z "$newer" ] || [ "$age" -lt "$newer" ] || return 1
+		[ -z "$older" ] || [ "$age" -gt "$older" ] || return 1
+	fi
+}
+walk() {
+	for f in "$1"/* "$1"/.*; do
+		case ${f##*/} in .|..) continue ;; esac
+		[ -e "$f" ] || [ -L "$f" ] || continue
+		matches "$f" && printf '%s\n' "$f"
+		if [ -d "$f" ] && [ ! -L "$f" ] && [ -r "$f" ] && { [ "$depth" -eq 0 ] || [ "$2" -lt "$depth" ]; }; then
+			walk "$f" $(($2 + 1))
+		fi
+	done
+}
+search | {
+	n=0
+	while IFS= read -r f; do
+		printf '%s\n' "$f"
+		n=$((n + 1))
+		[ "$limit" -gt 0 ] && [ "$n" -ge "$limit" ] && exit 0
+	done
+	exit 0
+}`
+
+// FindOptions configures FindFiles. Zero values disable the respective predicates.
+type FindOptions struct {
+	// Type restricts the results to file system objects of the type, e.g. FileTypeRegular or FileTypeDirectory.
+	Type FileType
+	// MinSize and MaxSize restrict the results to objects of at least and at most the sizes in bytes.
+	MinSize int64
+	MaxSize int64
+	// ModifiedWithin restricts the results to objects modified within the duration before the search, and
+	// NotModifiedWithin to objects modified earlier. Both have a precision of minutes.
+	ModifiedWithin    time.Duration
+	NotModifiedWithin time.Duration
+	// MaxDepth limits the depth of the search below the root, 1 searching only the root's entries.
+	MaxDepth int
+	// Limit is the maximum number of paths returned. The search stops when it is reached.
+	Limit int
+}
+
+// findTypes maps file types to the type letters of find.
+var findTypes = map[FileType]string{
+	FileTypeRegular:     "f",
+	FileTypeDirectory:   "d",
+	FileTypeSymlink:     "l",
+	FileTypeFIFO:        "p",
+	FileTypeSocket:      "s",
+	FileTypeCharDevice:  "c",
+	FileTypeBlockDevice: "b",
+}
+
+// FindFiles searches the directory tree 'root' in a container, identified by the container's name and
+// the associated pod's name, and returns the paths of the file system objects matching 'pattern' and
+// the predicates of 'options', e.g. all "*.pem" files under "/etc". Patterns containing a slash, such as
+// "/etc/*/private/*", are matched against whole paths, other patterns against names; an empty pattern matches
+// everything. Symbolic links are not followed and unreadable directories are skipped. The search uses find,
+// or walks the tree with the shell in containers lacking it. Missing roots are reported with errors wrapping
+// ErrFileNotFound, and roots which are not directories with errors wrapping ErrNotDirectory. The search is
+// governed by the provided context.
+func (k8s *K8SExec) FindFiles(ctx context.Context, podName string, containerName string, root string, pattern string, options FindOptions) ([]string, error) {
+	findType, ok := findTypes[options.Type]
+	if options.Type != "" && !ok {
+		return nil, fmt.Errorf("finding files in %s/%s:%s: unsupported file type %q", podName, containerName, root, options.Type)
+	}
+
+	cmd := []string{"sh", "-c", findFilesScript, "sh", root, pattern, findType,
+		optionalInt(options.MinSize), optionalInt(options.MaxSize),
+		optionalInt(int64(options.ModifiedWithin / time.Minute)), optionalInt(int64(options.NotModifiedWithin / time.Minute)),
+		strconv.Itoa(max(options.MaxDepth, 0)), strconv.Itoa(max(options.Limit, 0))}
+	var stdout, stderr bytes.Buffer
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if err := fileError("finding files in", podName, containerName, root, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+	return outputLines(strings.Split(stdout.String(), "\n")), nil
+}
+
+// optionalInt formats a positive number as a script parameter, which is empty for non-positive numbers.
+func optionalInt(n int64) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(n, 10)
+}
@@ -0,0 +1,148 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// grepScript prints the lines matching the pattern given as the first parameter in files and directories given
+// as the parameters following the flags, "-F" for fixed strings or "-E" for extended regular expressions,
+// "-i" for case-insensitive matching, and the match limit, zero meaning no limit. Lines are printed as
+// "path\0line:text", so file names may contain colons and newlines. Greps supporting -Z search recursively
+// and skip binary files; other greps, e.g. busybox's, are run for every file found by a shell loop, which
+// searches the files itself, supporting only case-sensitive fixed strings, in containers lacking grep.
+const grepScript = `pattern=$1 syntax=$2 icase=$3 limit=$4
+shift 4
+search() {
+	if [ -n "$null" ]; then
+		grep -rsnHIZ $syntax $icase -e "$pattern" -- "$@"
+		return 0
+	fi
+	for f in "$@"; do
+		if [ -d "$f" ] && [ ! -L "$f" ]; then
+			set -- "$f"/* "$f"/.*
+			for e in "$@"; do
+				case ${e##*/} in .|..) continue ;; esac
+				[ -e "$e" ] && search "$e"
+			done
+		elif [ -f "$f" ] && [ -r "$f" ] && [ -n "$grep" ]; then
+			grep -sn $syntax $icase -e "$pattern" -- "$f" | while IFS= read -r l; do
+				printf '%s\0%s\n' "$f" "$l"
+			done
+		elif [ -f "$f" ] && [ -r "$f" ]; then
+			n=0
+			while IFS= read -r l || [ -n "$l" ]; do
+				n=$((n + 1))
+				case $l in *"$pattern"*) printf '%s\0%s:%s\n' "$f" "$n" "$l" ;; esac
+			done < "$f"
+		fi
+	done
+}
+grep= null=
+if command -v grep >/dev/null 2>&1; then
+	grep=1
+	# grep exits with 2 for unreadable files as well, so the pattern is checked on its own
+	grep -q $syntax $icase -e "$pattern" /dev/null
+	[ $? -gt 1 ] && exit 2
+	echo x | grep -qIZ -e x 2>/dev/null && null=1
+elif [ "$syntax" != -F ] || [ -n "$icase" ]; then
+	echo "no grep available" >&2
+	exit 127
+fi
+if [ "$limit" -gt 0 ] && command -v head >/dev/null 2>&1; then
+	search "$@" | head -n "$limit"
+else
+	search "$@"
+fi
+exit 0`
+
+// ErrInvalidPattern is returned by Grep when grep in the container rejects the pattern, e.g. because it is not
+// a valid regular expression.
+var ErrInvalidPattern = errors.New("invalid search pattern")
+
+// GrepOptions configures Grep.
+type GrepOptions struct {
+	// FixedString makes the pattern match as a plain string instead of a basic regular expression.
+	FixedString bool
+	// Extended makes the pattern match as an extended regular expression, like 'grep -E' does. It is ignored
+	// if FixedString is set.
+	Extended bool
+	// IgnoreCase makes the pattern match regardless of case.
+	IgnoreCase bool
+	// MaxMatches is the maximum number of matches returned. The search stops when it is reached.
+	MaxMatches int
+}
+
+// GrepMatch is a line matched by Grep.
+type GrepMatch struct {
+	Path string `json:"path"`
+	// Line is the number of the matched line, starting at 1.
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// Grep searches the files 'paths' in a container, identified by the container's name and the associated pod's
+// name, for lines matching 'pattern' and returns the matches with their file names, line numbers and texts, so
+// configuration checks and secret scans do not need to download whole files. Directories are searched
+// recursively; missing and unreadable files are skipped, as are binary files where grep detects them. It uses
+// 'grep -rn', with 'pattern' being a basic regular expression unless options.FixedString or options.Extended is
+// set. In containers lacking grep, files are searched with a shell loop, which supports only case-sensitive fixed
+// strings; other searches in such containers are reported with errors wrapping ErrUtilNotFound. Patterns rejected
+// by grep are reported with errors wrapping ErrInvalidPattern. The search is governed by the provided context.
+func (k8s *K8SExec) Grep(ctx context.Context, podName string, containerName string, pattern string, paths []string, options GrepOptions) ([]GrepMatch, error) {
+	syntax, icase := "", ""
+	switch {
+	case options.FixedString:
+		syntax = "-F"
+	case options.Extended:
+		syntax = "-E"
+	}
+	if options.IgnoreCase {
+		icase = "-i"
+	}
+	cmd := append([]string{"sh", "-c", grepScript, "sh", pattern, syntax, icase, strconv.Itoa(max(options.MaxMatches, 0))}, paths...)
+
+	var stdout, stderr bytes.Buffer
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	target := strings.Join(paths, ",")
+	if retCode == CommandNotFound && strings.Contains(stderr.String(), "no grep available") {
+		return nil, fmt.Errorf("searching %s/%s:%s: %w: grep", podName, containerName, target, ErrUtilNotFound)
+	}
+	if retCode == IncorrectUsage {
+		return nil, fmt.Errorf("searching %s/%s:%s: %w %q: %s", podName, containerName, target, ErrInvalidPattern, pattern, strings.TrimSpace(stderr.String()))
+	}
+	if err := fileError("searching", podName, containerName, target, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+
+	matches := parseGrepOutput(stdout.Bytes())
+	if options.MaxMatches > 0 && len(matches) > options.MaxMatches {
+		matches = matches[:options.MaxMatches]
+	}
+	return matches, nil
+}
+
+// parseGrepOutput parses matches printed by grepScript as "path\0line:text" records, one per line. The file name
+// ends at the first NUL byte, so it may contain colons and newlines. Malformed records are skipped.
+func parseGrepOutput(output []byte) []GrepMatch {
+	var matches []GrepMatch
+	for len(output) > 0 {
+		name, rest, found := bytes.Cut(output, []byte{0})
+		if !found {
+			break
+		}
+		var record []byte
+		record, output, _ = bytes.Cut(rest, []byte{'\n'})
+		number, text, found := strings.Cut(string(record), ":")
+		line, err := strconv.Atoi(number)
+		if !found || err != nil {
+			continue
+		}
+		matches = append(matches, GrepMatch{Path: string(name), Line: line, Text: text})
+	}
+	return matches
+}
@@ -0,0 +1,62 @@
+package k8sexec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGrepOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []GrepMatch
+	}{
+		{
+			name: "empty output",
+		},
+		{
+			name:   "matches",
+			output: "/etc/app.conf\x003:password=secret\n/etc/app.conf\x0010:user=admin\n",
+			want: []GrepMatch{
+				{Path: "/etc/app.conf", Line: 3, Text: "password=secret"},
+				{Path: "/etc/app.conf", Line: 10, Text: "user=admin"},
+			},
+		},
+		{
+			name:   "colons in names and texts",
+			output: "/srv/c:d/10:20.log\x007:time=12:30:00\n",
+			want:   []GrepMatch{{Path: "/srv/c:d/10:20.log", Line: 7, Text: "time=12:30:00"}},
+		},
+		{
+			name:   "spaces and newlines in names",
+			output: "/data/my file\x001:a\n/data/two\nlines\x002:b\n",
+			want: []GrepMatch{
+				{Path: "/data/my file", Line: 1, Text: "a"},
+				{Path: "/data/two\nlines", Line: 2, Text: "b"},
+			},
+		},
+		{
+			name:   "empty matched line",
+			output: "/etc/empty\x004:\n",
+			want:   []GrepMatch{{Path: "/etc/empty", Line: 4, Text: ""}},
+		},
+		{
+			name:   "missing final newline",
+			output: "/etc/hosts\x001:127.0.0.1 localhost",
+			want:   []GrepMatch{{Path: "/etc/hosts", Line: 1, Text: "127.0.0.1 localhost"}},
+		},
+		{
+			name:   "binary file notices and malformed records",
+			output: "/bin/ls\x00Binary file /bin/ls matches\n/etc/a\x00x:y\n/etc/b\x002:ok\ntruncated",
+			want:   []GrepMatch{{Path: "/etc/b", Line: 2, Text: "ok"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := parseGrepOutput([]byte(test.output)); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseGrepOutput(%q) = %#v, want %#v", test.output, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package k8sexec
+
+import (
+	"context"
+	"fmt"
+	coreV1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Waiting reasons of containers that cannot run commands, checked by UnhealthyReason.
+var unhealthyWaitingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"CreateContainerConfigError": true,
+}
+
+// UnhealthyReason returns the reason why executing a command in the named container of the pod is pointless,
+// or an empty string if the container looks healthy. Evicted pods, containers in CrashLoopBackOff or
+// ImagePullBackOff and pods which are not ready are considered unhealthy.
+func UnhealthyReason(pod *coreV1.Pod, containerName string) string {
+	if pod.Status.Reason == "Evicted" {
+		return "pod evicted"
+	}
+	for _, container := range ContainersOf(pod) {
+		if container.Init || container.Name != containerName {
+			continue
+		}
+		if container.State == ContainerWaiting && unhealthyWaitingReasons[container.Reason] {
+			return fmt.Sprintf("container %s is in %s", containerName, container.Reason)
+		}
+	}
+	if !isPodReady(pod) {
+		return "pod not ready"
+	}
+	return ""
+}
+
+// healthGate retrieves pods of batch targets and decides which targets are skipped.
+type healthGate struct {
+	reasons map[Target]string
+}
+
+// newHealthGate retrieves every pod referenced by 'targets' once and records skip reasons of unhealthy targets.
+// Pods that cannot be retrieved for reasons other than not existing are not skipped; executing the command
+// reports the actual problem.
+func (k8s *K8SExec) newHealthGate(ctx context.Context, targets []Target) *healthGate {
+	gate := &healthGate{reasons: make(map[Target]string)}
+
+	pods := make(map[string]*coreV1.Pod)
+	missing := make(map[string]bool)
+	for _, target := range targets {
+		if _, ok := pods[target.Pod]; ok || missing[target.Pod] {
+			continue
+		}
+		pod, err := k8s.Clientset.CoreV1().Pods(k8s.Namespace).Get(ctx, target.Pod, metaV1.GetOptions{})
+		switch {
+		case apiErrors.IsNotFound(err):
+			missing[target.Pod] = true
+		case err != nil:
+			k8s.logger().Warn("cannot check pod health", "pod", target.Pod, "error", err)
+			pods[target.Pod] = nil
+		default:
+			pods[target.Pod] = pod
+		}
+	}
+
+	for _, target := range targets {
+		if missing[target.Pod] {
+			gate.reasons[target] = "pod not found"
+			continue
+		}
+		if pod := pods[target.Pod]; pod != nil {
+			if reason := UnhealthyReason(pod, target.Container); reason != "" {
+				gate.reasons[target] = reason
+			}
+		}
+	}
+	return gate
+}
+
+// skipReason returns the reason why the target is skipped, or an empty string if it is not.
+func (gate *healthGate) skipReason(target Target) string {
+	if gate == nil {
+		return ""
+	}
+	return gate.reasons[target]
+}
+
+// skippedStatus returns the ExecutionStatus reporting a skipped target.
+func skippedStatus(target Target, reason string) *ExecutionStatus {
+	status := NewExecutionStatus(target.Pod, target.Container, ExecutionSkipped, "", "", "")
+	status.SkipReason = reason
+	return status
+}
@@ -0,0 +1,47 @@
+package k8sexec
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrConnectionLost is returned when an execution is aborted because its connection to the API server stopped
+// responding, e.g. after a NAT timeout or a node failure, as detected by the heartbeat set with WithHeartbeat.
+var ErrConnectionLost = errors.New("connection to the API server lost")
+
+// heartbeat configures detection of dead exec connections.
+type heartbeat struct {
+	interval  time.Duration
+	deadAfter time.Duration
+}
+
+// WithHeartbeat makes the instance send SPDY pings every 'interval' on exec connections and consider
+// a connection dead once it stops acknowledging traffic for 'deadAfter'. An execution whose connection silently
+// dies then fails quickly with an error wrapping ErrConnectionLost, instead of hanging until its deadline.
+// On Linux the threshold is enforced with the TCP_USER_TIMEOUT socket option; on other platforms TCP keepalives
+// probing every 'interval' are used, and the threshold depends on the system's keepalive settings.
+// The heartbeat implies the transport cache (see WithTransportCache) and applies to the default SPDY backend.
+func WithHeartbeat(interval time.Duration, deadAfter time.Duration) Option {
+	return func(k8s *K8SExec) {
+		k8s.heartbeat = &heartbeat{interval: interval, deadAfter: deadAfter}
+		if k8s.transports == nil {
+			k8s.transports = &transportCache{}
+		}
+	}
+}
+
+// dialer returns the dialer opening exec connections monitored by the heartbeat.
+func (hb *heartbeat) dialer() *net.Dialer {
+	return &net.Dialer{KeepAlive: hb.interval, Control: hb.control}
+}
+
+// isConnectionLost reports whether the error was caused by the connection timing out.
+func isConnectionLost(err error) bool {
+	return errors.Is(err, syscall.ETIMEDOUT) ||
+		errors.Is(err, os.ErrDeadlineExceeded) ||
+		strings.Contains(err.Error(), "connection timed out")
+}
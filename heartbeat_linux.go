@@ -0,0 +1,19 @@
+package k8sexec
+
+import (
+	"golang.org/x/sys/unix"
+	"syscall"
+)
+
+// control sets TCP_USER_TIMEOUT on the socket, making the kernel close the connection once transmitted data
+// (including SPDY pings) stays unacknowledged for longer than the dead-connection threshold.
+func (hb *heartbeat) control(_, _ string, conn syscall.RawConn) error {
+	var sockErr error
+	err := conn.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_TCP, unix.TCP_USER_TIMEOUT, int(hb.deadAfter.Milliseconds()))
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
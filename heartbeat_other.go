@@ -0,0 +1,12 @@
+//go:build !linux
+
+package k8sexec
+
+import (
+	"syscall"
+)
+
+// control leaves the socket unchanged; dead connections are detected by TCP keepalives only.
+func (hb *heartbeat) control(_, _ string, _ syscall.RawConn) error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package k8sexec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ErrIdleTimeout is returned when an execution is aborted because the command produced no output for longer
+// than the idle timeout set with WithIdleTimeout. It wraps ErrExecTimeout.
+var ErrIdleTimeout = fmt.Errorf("no output received within the idle timeout: %w", ErrExecTimeout)
+
+// WithIdleTimeout aborts the execution if no bytes are received on standard output or standard error for longer
+// than 'timeout', independently of the overall deadline. It tells hung commands from slow but alive ones,
+// which keep producing output. Outputs which are not captured are still transferred to observe the activity.
+// Aborted executions are reported with the ExecutionTimeOut exit code and an error wrapping ErrIdleTimeout.
+func WithIdleTimeout(timeout time.Duration) ExecOption {
+	return func(config *execConfig) {
+		config.idleTimeout = timeout
+	}
+}
+
+// idleWatchdog cancels an execution when no output is written for longer than the idle timeout.
+type idleWatchdog struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+// newIdleWatchdog starts a watchdog cancelling the execution with ErrIdleTimeout as its cause.
+func newIdleWatchdog(timeout time.Duration, cancel context.CancelCauseFunc) *idleWatchdog {
+	return &idleWatchdog{
+		timer:   time.AfterFunc(timeout, func() { cancel(ErrIdleTimeout) }),
+		timeout: timeout,
+	}
+}
+
+// writer returns a writer resetting the watchdog on every write to 'w'. A nil writer is replaced by io.Discard,
+// so the output is transferred and observed even if it is not captured.
+func (watchdog *idleWatchdog) writer(w io.Writer) io.Writer {
+	if w == nil {
+		w = io.Discard
+	}
+	return &idleWriter{writer: w, watchdog: watchdog}
+}
+
+// stop stops the watchdog.
+func (watchdog *idleWatchdog) stop() {
+	watchdog.mu.Lock()
+	defer watchdog.mu.Unlock()
+	watchdog.timer.Stop()
+}
+
+// kick postpones the expiration of the watchdog by the idle timeout.
+func (watchdog *idleWatchdog) kick() {
+	watchdog.mu.Lock()
+	defer watchdog.mu.Unlock()
+	watchdog.timer.Reset(watchdog.timeout)
+}
+
+// idleWriter is an io.Writer kicking the watchdog with every write.
+type idleWriter struct {
+	writer   io.Writer
+	watchdog *idleWatchdog
+}
+
+func (w *idleWriter) Write(p []byte) (int, error) {
+	w.watchdog.kick()
+	return w.writer.Write(p)
+}
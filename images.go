@@ -0,0 +1,55 @@
+package k8sexec
+
+import (
+	"context"
+	"github.com/hhruszka/k8sexec/discovery"
+)
+
+// ImageRef describes a container image used in the namespace. Images are identified by the digest of the image
+// actually running, resolved from the containers' statuses, so several tags pointing to the same image
+// (e.g. "nginx:1.25" and "nginx:stable") are reported as a single ImageRef.
+type ImageRef = discovery.ImageRef
+
+// GetUniqueImages retrieves all images used by containers (including init containers) of the pods within
+// the namespace specified by the 'k8s' context. The running image digest is resolved from the containers'
+// statuses rather than taken from the image tag in the pod spec, so the result reflects what is actually
+// running. Images are sorted by repository and digest. The call is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) GetUniqueImages() ([]ImageRef, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.GetUniqueImagesWithContext(ctx)
+}
+
+// GetUniqueImagesWithContext retrieves all images used by containers of the pods within the namespace specified
+// by the 'k8s' context, like GetUniqueImages. The call is governed by the provided context.
+func (k8s *K8SExec) GetUniqueImagesWithContext(ctx context.Context) ([]ImageRef, error) {
+	return k8s.discoveryClient().GetUniqueImages(ctx)
+}
+
+// ImageUsage describes where an image is used: which workloads run it, in which of their pods and containers.
+type ImageUsage = discovery.ImageUsage
+
+// WorkloadImageUsage describes pods and containers of a single workload running an image.
+type WorkloadImageUsage = discovery.WorkloadImageUsage
+
+// PodImageUsage lists containers (and init containers) of a pod running an image.
+type PodImageUsage = discovery.PodImageUsage
+
+// GetImageUsage returns, for every image used in the namespace specified by the 'k8s' context, the full mapping
+// of the image to the workloads running it, their pods and containers, with counts. Pods are attributed to
+// their top-level workloads, e.g. to a Deployment rather than to its ReplicaSet, which allows to answer questions
+// like "which deployments still run the vulnerable image" without joining results of separate list calls.
+// The call is bounded by the instance's discovery timeout.
+func (k8s *K8SExec) GetImageUsage() ([]ImageUsage, error) {
+	ctx, cancel := k8s.discoveryContext()
+	defer cancel()
+
+	return k8s.GetImageUsageWithContext(ctx)
+}
+
+// GetImageUsageWithContext returns the mapping of images used in the namespace specified by the 'k8s' context
+// to workloads, pods and containers running them, like GetImageUsage. The call is governed by the provided context.
+func (k8s *K8SExec) GetImageUsageWithContext(ctx context.Context) ([]ImageUsage, error) {
+	return k8s.discoveryClient().GetImageUsage(ctx)
+}
@@ -0,0 +1,62 @@
+package k8sexec
+
+import (
+	"context"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sync"
+)
+
+// PodExecOptions configures ExecInPod.
+type PodExecOptions struct {
+	// IncludeInit also executes the command in init containers, which only succeeds for restartable
+	// (sidecar) init containers that are still running.
+	IncludeInit bool
+	// IncludeEphemeral also executes the command in ephemeral (debug) containers.
+	IncludeEphemeral bool
+	// Exec are options applied to every execution, e.g. WithTimeout.
+	Exec []ExecOption
+}
+
+// ExecInPod executes the command provided as arguments ('args') in every container of the pod with the given
+// name, at the same time, and returns the results keyed by container name. Init and ephemeral containers are
+// included on request. The pod spec is retrieved first, so callers do not have to look up container names
+// themselves. The use of this function must provide a context that will govern the executions.
+func (k8s *K8SExec) ExecInPod(ctx context.Context, podName string, args []string, options PodExecOptions) (map[string]*ExecutionStatus, error) {
+	pod, err := k8s.GetPodWithContext(ctx, podName, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var containers []string
+	if options.IncludeInit {
+		for _, container := range pod.Spec.InitContainers {
+			containers = append(containers, container.Name)
+		}
+	}
+	for _, container := range pod.Spec.Containers {
+		containers = append(containers, container.Name)
+	}
+	if options.IncludeEphemeral {
+		for _, container := range pod.Spec.EphemeralContainers {
+			containers = append(containers, container.Name)
+		}
+	}
+
+	results := make(map[string]*ExecutionStatus, len(containers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, container := range containers {
+		wg.Add(1)
+		go func(container string) {
+			defer wg.Done()
+			status := k8s.ExecWithOptions(ctx, podName, container, args, options.Exec...)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[container] = status
+		}(container)
+	}
+	wg.Wait()
+
+	return results, nil
+}
@@ -0,0 +1,208 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// DefaultDialogStepTimeout bounds waiting for the expected output of a DialogStep when its Timeout is not set.
+const DefaultDialogStepTimeout = 10 * time.Second
+
+// ErrDialogTimeout is returned by Interact when the output expected by a dialog step does not appear in time, or
+// the command does not read the step's answer in time.
+var ErrDialogTimeout = errors.New("expected output did not appear in time")
+
+// DialogStep is a single expect/send pair of a scripted interaction with a command prompting on its standard input.
+type DialogStep struct {
+	// Expect is a pattern awaited in the command's output (standard output and standard error) before Send is
+	// written to its standard input. Each step only looks at the output following the previous step's match.
+	// A nil Expect sends immediately.
+	Expect *regexp.Regexp
+	// Send is written to the command's standard input once Expect matched, e.g. "secret\n".
+	Send string
+	// Timeout bounds waiting for Expect and writing Send. DefaultDialogStepTimeout is used when it is not set.
+	Timeout time.Duration
+}
+
+// Interact executes a command provided as arguments ('args') and drives it through the dialog described by
+// 'steps': for every step it waits until the expected pattern appears in the command's output and answers
+// by writing to the command's standard input. Once all steps are done, standard input is closed and Interact
+// waits for the command to exit. This allows to automate interactive tools such as password-protected CLIs or
+// database shells. Programs reading from a terminal rather than from standard input cannot be driven this way.
+// The returned ExecutionStatus holds the complete outputs of the command; the error describes a failed dialog
+// step, in which case the command is interrupted.
+func (k8s *K8SExec) Interact(ctx context.Context, podName string, containerName string, args []string, steps []DialogStep) (*ExecutionStatus, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	d := newDialog()
+	result := d.start(ctx, k8s, podName, containerName, args)
+
+	var dialogErr error
+	for i, step := range steps {
+		if dialogErr = d.step(ctx, step); dialogErr != nil {
+			dialogErr = fmt.Errorf("dialog step %d: %w", i+1, dialogErr)
+			cancel()
+			break
+		}
+	}
+	_ = d.stdinWriter.Close()
+
+	status := d.status(podName, containerName, <-result)
+	status.recordExecution(k8s.Namespace, args, start)
+	return status, dialogErr
+}
+
+// execResult is the outcome of a command driven by a dialog.
+type execResult struct {
+	retCode ExitCode
+	err     error
+}
+
+// dialog is the engine behind Interact. It collects the command's output, matches expected patterns against
+// it and feeds the command's standard input.
+type dialog struct {
+	stdinReader *io.PipeReader
+	stdinWriter *io.PipeWriter
+
+	mu       sync.Mutex
+	combined bytes.Buffer
+	stdout   bytes.Buffer
+	stderr   bytes.Buffer
+	offset   int
+	changed  chan struct{}
+	finished bool
+}
+
+func newDialog() *dialog {
+	d := &dialog{changed: make(chan struct{})}
+	d.stdinReader, d.stdinWriter = io.Pipe()
+	return d
+}
+
+// start executes the command in the background and returns a channel delivering its result.
+func (d *dialog) start(ctx context.Context, k8s *K8SExec, podName string, containerName string, args []string) <-chan execResult {
+	result := make(chan execResult, 1)
+	go func() {
+		retCode, err := k8s.exec(ctx, podName, containerName, args, d.stdinReader, dialogWriter{d, &d.stdout}, dialogWriter{d, &d.stderr}, false)
+		// unblock pending writes to the command's standard input
+		_ = d.stdinReader.Close()
+
+		d.mu.Lock()
+		d.finished = true
+		d.notify()
+		d.mu.Unlock()
+		result <- execResult{retCode: retCode, err: err}
+	}()
+	return result
+}
+
+// step waits for the step's expected output and sends its answer, both within the step's timeout.
+func (d *dialog) step(ctx context.Context, step DialogStep) error {
+	timeout := time.NewTimer(firstPositive(step.Timeout, DefaultDialogStepTimeout))
+	defer timeout.Stop()
+
+	if err := d.expect(ctx, step, timeout.C); err != nil {
+		return err
+	}
+	if err := d.send(ctx, step.Send, timeout.C); err != nil {
+		return fmt.Errorf("sending input: %w", err)
+	}
+	return nil
+}
+
+// expect waits until the step's pattern appears in the output following the previous match.
+func (d *dialog) expect(ctx context.Context, step DialogStep, timeout <-chan time.Time) error {
+	if step.Expect == nil {
+		return nil
+	}
+
+	for {
+		d.mu.Lock()
+		if match := step.Expect.FindIndex(d.combined.Bytes()[d.offset:]); match != nil {
+			d.offset += match[1]
+			d.mu.Unlock()
+			return nil
+		}
+		finished, changed := d.finished, d.changed
+		d.mu.Unlock()
+
+		if finished {
+			return fmt.Errorf("command exited before %q appeared in its output", step.Expect)
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return fmt.Errorf("%w: %q", ErrDialogTimeout, step.Expect)
+		}
+	}
+}
+
+// send writes text to the command's standard input. The write blocks until the command reads the text, so if
+// 'ctx' is done or 'timeout', which may be nil, fires first, standard input is closed to abandon it.
+func (d *dialog) send(ctx context.Context, text string, timeout <-chan time.Time) error {
+	if text == "" {
+		return nil
+	}
+	written := make(chan error, 1)
+	go func() {
+		_, err := io.WriteString(d.stdinWriter, text)
+		written <- err
+	}()
+
+	var err error
+	select {
+	case err = <-written:
+		return err
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = fmt.Errorf("%w: the command did not read its input", ErrDialogTimeout)
+	}
+	_ = d.stdinWriter.CloseWithError(err)
+	return err
+}
+
+// status builds the ExecutionStatus of the finished command.
+func (d *dialog) status(podName string, containerName string, result execResult) *ExecutionStatus {
+	var errMessage string
+	if result.err != nil {
+		errMessage = result.err.Error()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	status := NewExecutionStatus(podName, containerName, result.retCode, errMessage, d.stdout.String(), d.stderr.String())
+	status.Err = result.err
+	return status
+}
+
+// notify wakes up goroutines waiting for output; it must be called with the mutex held.
+func (d *dialog) notify() {
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+// dialogWriter collects one of the command's output streams.
+type dialogWriter struct {
+	d      *dialog
+	stream *bytes.Buffer
+}
+
+func (w dialogWriter) Write(p []byte) (int, error) {
+	w.d.mu.Lock()
+	defer w.d.mu.Unlock()
+	w.stream.Write(p)
+	w.d.combined.Write(p)
+	w.d.notify()
+	return len(p), nil
+}
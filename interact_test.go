@@ -0,0 +1,46 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// stdinIgnoringBackend executes commands which never read their standard input and run until they are
+// interrupted.
+type stdinIgnoringBackend struct{}
+
+func (stdinIgnoringBackend) Stream(ctx context.Context, k8s *K8SExec, request ExecRequest) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestInteractSendHonorsDeadlines(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		step    time.Duration
+		err     error
+	}{
+		{name: "step timeout", timeout: 10 * time.Second, step: 50 * time.Millisecond, err: ErrDialogTimeout},
+		{name: "context deadline", timeout: 50 * time.Millisecond, step: 10 * time.Second, err: context.DeadlineExceeded},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			k8s := newTestK8SExec(t, stdinIgnoringBackend{})
+			ctx, cancel := context.WithTimeout(context.Background(), test.timeout)
+			defer cancel()
+
+			start := time.Now()
+			_, err := k8s.Interact(ctx, "web", "app", []string{"cat"}, []DialogStep{{Send: "answer\n", Timeout: test.step}})
+			if !errors.Is(err, test.err) {
+				t.Errorf("error %v, want %v", err, test.err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("sending took %v", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+// Package apierror classifies and wraps errors returned by the Kubernetes API, so the root package and its
+// subpackages report them with the same sentinel errors, which are re-exported by both.
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"strings"
+)
+
+var (
+	// ErrThrottled is returned when a request is rejected or cannot be sent because of rate limiting, either
+	// by a client-side rate limiter or by the Kubernetes API server (HTTP 429).
+	ErrThrottled = errors.New("request throttled")
+	// ErrForbidden is returned when the API server denies a request because of missing permissions.
+	ErrForbidden = errors.New("forbidden")
+	// ErrPodNotFound is returned when a pod does not exist.
+	ErrPodNotFound = errors.New("pod not found")
+)
+
+// Wrap wraps an error returned by the Kubernetes API with a message describing the failed operation.
+// Errors caused by API server throttling or missing permissions additionally wrap ErrThrottled or ErrForbidden.
+func Wrap(err error, format string, args ...any) error {
+	message := fmt.Sprintf(format, args...)
+	switch {
+	case IsThrottled(err):
+		return fmt.Errorf("%s: %w: %w", message, ErrThrottled, err)
+	case IsForbidden(err):
+		return fmt.Errorf("%s: %w: %w", message, ErrForbidden, err)
+	default:
+		return fmt.Errorf("%s: %w", message, err)
+	}
+}
+
+// IsThrottled reports whether the error was caused by the API server rejecting a request with HTTP 429.
+// Errors of failed exec stream upgrades carry the API status only in their message, hence the text check.
+func IsThrottled(err error) bool {
+	return apiErrors.IsTooManyRequests(err) || strings.Contains(strings.ToLower(err.Error()), "too many requests")
+}
+
+// IsForbidden reports whether the error was caused by the API server denying a request with HTTP 403. Only
+// errors carrying the API status are recognized, which includes failed exec stream upgrades, since client-go
+// decodes the status of a rejected upgrade; messages merely mentioning the word, e.g. command output, are not.
+func IsForbidden(err error) bool {
+	return apiErrors.IsForbidden(err)
+}
@@ -0,0 +1,33 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"testing"
+)
+
+func TestIsForbidden(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "forbidden status", err: apiErrors.NewForbidden(pods, "web", errors.New("denied")), want: true},
+		{name: "wrapped forbidden status", err: fmt.Errorf("exec in web/app: %w", apiErrors.NewForbidden(pods, "web", nil)), want: true},
+		{name: "other status", err: apiErrors.NewNotFound(pods, "forbidden"), want: false},
+		{name: "message mentioning the word", err: errors.New(`cat: /etc/forbidden.conf: No such file or directory`), want: false},
+		{name: "upgrade failure without a status", err: errors.New("unable to upgrade connection: Forbidden"), want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsForbidden(test.err); got != test.want {
+				t.Errorf("IsForbidden(%v) = %t, want %t", test.err, got, test.want)
+			}
+		})
+	}
+}
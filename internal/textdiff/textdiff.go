@@ -0,0 +1,131 @@
+// Package textdiff computes line-based unified diffs of texts, shown by the root package and the audit
+// subpackage to explain how outputs and files differ.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes by Unified.
+const diffContext = 3
+
+// diffOp is a single line of a line-based diff: ' ' for an unchanged line, '-' for a removed one and '+' for
+// an added one.
+type diffOp struct {
+	kind byte
+	text string
+}
+
+// Unified returns a unified diff of two texts, labelled 'fromName' and 'toName', or an empty string if
+// the texts are equal.
+func Unified(fromName string, toName string, from string, to string) string {
+	if from == to {
+		return ""
+	}
+	ops := diffLines(splitLines(from), splitLines(to))
+
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- %s\n+++ %s\n", fromName, toName)
+	for start := 0; start < len(ops); {
+		// find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		// changes separated by no more than twice the context are shown in a single hunk
+		last := start
+		for next := start + 1; next < len(ops) && next-last <= 2*diffContext+1; next++ {
+			if ops[next].kind != ' ' {
+				last = next
+			}
+		}
+		begin := max(start-diffContext, 0)
+		end := min(last+diffContext+1, len(ops))
+
+		fromLine, toLine := lineNumbers(ops, begin)
+		var fromCount, toCount int
+		for _, op := range ops[begin:end] {
+			if op.kind != '+' {
+				fromCount++
+			}
+			if op.kind != '-' {
+				toCount++
+			}
+		}
+		fmt.Fprintf(&diff, "@@ -%d,%d +%d,%d @@\n", fromLine, fromCount, toLine, toCount)
+		for _, op := range ops[begin:end] {
+			diff.WriteByte(op.kind)
+			diff.WriteString(op.text)
+			diff.WriteByte('\n')
+		}
+		start = end
+	}
+	return diff.String()
+}
+
+// lineNumbers returns the 1-based line numbers in both texts of the operation at index 'at'.
+func lineNumbers(ops []diffOp, at int) (int, int) {
+	fromLine, toLine := 1, 1
+	for _, op := range ops[:at] {
+		if op.kind != '+' {
+			fromLine++
+		}
+		if op.kind != '-' {
+			toLine++
+		}
+	}
+	return fromLine, toLine
+}
+
+// diffLines computes the line-based diff of two texts using the longest common subsequence of their lines.
+func diffLines(from []string, to []string) []diffOp {
+	// lcs[i][j] is the length of the longest common subsequence of from[i:] and to[j:]
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			ops = append(ops, diffOp{' ', from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', from[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		ops = append(ops, diffOp{'-', from[i]})
+	}
+	for ; j < len(to); j++ {
+		ops = append(ops, diffOp{'+', to[j]})
+	}
+	return ops
+}
+
+// splitLines splits a text into lines, ignoring the final line terminator.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,59 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"strings"
+)
+
+// killWrapperScript runs the command in the background, records its PID in the file given as the first
+// parameter and waits for it. Asynchronous commands get /dev/null as standard input in non-interactive shells,
+// so the original standard input is handed over explicitly through file descriptor 3.
+const killWrapperScript = `f=$1; shift
+exec 3<&0
+"$@" <&3 3<&- &
+echo $! > "$f"
+wait $!
+rc=$?
+rm -f "$f"
+exit $rc`
+
+// killScript terminates the process recorded in the PID file given as the first parameter and its children.
+const killScript = `p=$(cat "$1" 2>/dev/null) || exit 0
+pkill -TERM -P "$p" 2>/dev/null
+kill -TERM "$p" 2>/dev/null
+rm -f "$1"
+exit 0`
+
+// WithKillOnCancel makes a best-effort attempt to terminate the command in the container when the execution is
+// cancelled or its deadline is exceeded. Closing the exec stream does not stop the remote process, so without
+// this option a cancelled command keeps running in the container. The command is wrapped to record its PID in
+// a temporary file, and a follow-up execution sends SIGTERM to it and its children. It requires a shell and
+// a writable /tmp in the container.
+func WithKillOnCancel() ExecOption {
+	return func(config *execConfig) {
+		config.killOnCancel = true
+	}
+}
+
+// killOnCancelCommand wraps the command to record its PID in 'pidFile'.
+func killOnCancelCommand(pidFile string, cmd []string) []string {
+	return append([]string{"sh", "-c", killWrapperScript, "sh", pidFile}, cmd...)
+}
+
+// killRemote terminates the command whose PID was recorded in 'pidFile'. It runs with its own timeout, since
+// the context of the execution is already done.
+func (k8s *K8SExec) killRemote(ctx context.Context, podName string, containerName string, pidFile string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), k8s.fileOpTimeout())
+	defer cancel()
+
+	// the API server rejects executions requesting no streams, so standard error is requested for diagnostics
+	var stderr bytes.Buffer
+	retCode, err := k8s.exec(ctx, podName, containerName, []string{"sh", "-c", killScript, "sh", pidFile}, nil, nil, &stderr, false)
+	if err != nil || retCode != Success {
+		k8s.logger().Warn("cannot terminate cancelled command", "pod", podName, "container", containerName,
+			"exitCode", retCode, "error", err, "stderr", strings.TrimSpace(stderr.String()))
+		return
+	}
+	k8s.logger().Debug("terminated cancelled command", "pod", podName, "container", containerName)
+}
@@ -0,0 +1,105 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+)
+
+// OutputStream identifies the output stream of a command a Line was written to.
+type OutputStream string
+
+const (
+	Stdout OutputStream = "stdout"
+	Stderr OutputStream = "stderr"
+)
+
+// maxLineLength is the length after which a line without a newline is delivered in parts, so commands writing
+// huge lines do not make ExecLines buffer unbounded amounts of output.
+const maxLineLength = 64 * 1024
+
+// Line is a single line of output of a command executed with ExecLines, labelled with its origin.
+type Line struct {
+	Pod       string
+	Container string
+	Stream    OutputStream
+	// Time is when the line was received, i.e. when its last part arrived.
+	Time time.Time
+	// Text is the line without the trailing newline (and carriage return).
+	Text string
+}
+
+// ExecLines executes a command provided as arguments ('args') and calls 'handler' for every line of its output
+// as soon as it is received, enabling real-time processing of output of long-running commands, e.g. following
+// logs or progress of a migration. Lines of standard output and standard error are delivered one at a time, never
+// concurrently, in the order they arrive; a final line lacking a newline is delivered when the command exits.
+// It returns the exit code of the command and any error encountered, like ExecStream.
+func (k8s *K8SExec) ExecLines(ctx context.Context, podName string, containerName string, args []string, handler func(line Line)) (ExitCode, error) {
+	if containerName == "" {
+		var err error
+		if containerName, err = k8s.DefaultContainer(ctx, podName); err != nil {
+			return InternalAppError, err
+		}
+	}
+
+	var mu sync.Mutex
+	deliver := func(line Line) {
+		mu.Lock()
+		defer mu.Unlock()
+		handler(line)
+	}
+	stdout := &lineWriter{line: Line{Pod: podName, Container: containerName, Stream: Stdout}, deliver: deliver}
+	stderr := &lineWriter{line: Line{Pod: podName, Container: containerName, Stream: Stderr}, deliver: deliver}
+
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, args, nil, stdout, stderr)
+	stdout.flush()
+	stderr.flush()
+	return retCode, err
+}
+
+// lineWriter splits output written to it into lines and delivers them labelled like 'line'.
+type lineWriter struct {
+	line    Line
+	deliver func(Line)
+	partial []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	data := append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(data[:i])
+		data = data[i+1:]
+	}
+	for len(data) >= maxLineLength {
+		w.emit(data[:maxLineLength])
+		data = data[maxLineLength:]
+	}
+	w.partial = append(w.partial[:0], data...)
+	return len(p), nil
+}
+
+// flush delivers the final line if it lacks a newline.
+func (w *lineWriter) flush() {
+	if len(w.partial) > 0 {
+		w.emit(w.partial)
+		w.partial = nil
+	}
+}
+
+// Close flushes the final line, see flush.
+func (w *lineWriter) Close() error {
+	w.flush()
+	return nil
+}
+
+func (w *lineWriter) emit(text []byte) {
+	line := w.line
+	line.Time = time.Now()
+	line.Text = string(bytes.TrimSuffix(text, []byte("\r")))
+	w.deliver(line)
+}
@@ -0,0 +1,32 @@
+package k8sexec
+
+import (
+	"context"
+	"github.com/hhruszka/k8sexec/discovery"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ListFunc is the signature of the List method of the typed and dynamic client-go resource interfaces, e.g.
+// k8s.Clientset.BatchV1().Jobs(k8s.Namespace).List or dynamicClient.Resource(gvr).Namespace(ns).List.
+// It allows to pass any of them directly to List and Iterate. It is convertible to discovery.ListFunc.
+type ListFunc[L runtime.Object] func(ctx context.Context, options metaV1.ListOptions) (L, error)
+
+// List retrieves all resources returned by the given list function and returns them as a slice of T, where T is
+// the item type of the list (e.g. batchV1.Job for *batchV1.JobList, or unstructured.Unstructured for resources
+// listed through the dynamic client). Label and field selectors are provided through 'options'.
+// This generic helper makes it possible to list any resource kind, including custom resources, without adding
+// a dedicated GetX method for every kind:
+//
+//	jobs, err := k8sexec.List[batchV1.Job](ctx, k8s.Clientset.BatchV1().Jobs(k8s.Namespace).List, options)
+func List[T any, L runtime.Object](ctx context.Context, list ListFunc[L], options metaV1.ListOptions) ([]T, error) {
+	return discovery.List[T](ctx, discovery.ListFunc[L](list), options)
+}
+
+// Iterate walks through all resources returned by the given list function and calls 'fn' for each of them.
+// Resources are retrieved page by page using the Limit and Continue fields of ListOptions, so large collections
+// are never held in memory at once. If options.Limit is not set, a default page size is used.
+// Iteration stops at the first error returned by 'fn' or by the Kubernetes API, and that error is returned.
+func Iterate[T any, L runtime.Object](ctx context.Context, list ListFunc[L], options metaV1.ListOptions, fn func(item *T) error) error {
+	return discovery.Iterate(ctx, discovery.ListFunc[L](list), options, fn)
+}
@@ -0,0 +1,238 @@
+package k8sexec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DirEntry describes an entry of a directory listed by ListDir.
+type DirEntry struct {
+	FileInfo
+	// Name is the path of the entry relative to the listed directory. It is the plain name of the entry unless
+	// the directory is listed recursively.
+	Name string `json:"name"`
+	// LinkTarget is the target of the entry if it is a symbolic link.
+	LinkTarget string `json:"linkTarget,omitempty"`
+}
+
+// listDirScript lists the directory given as the first parameter, recursively if the second parameter is 1.
+// It uses 'find -printf' if the container provides GNU find, printing NUL-terminated fields of every entry,
+// and 'ls -la' otherwise, e.g. in busybox based containers. The output is preceded by a line with the name of
+// the utility used, so it can be parsed accordingly. Subdirectories which cannot be read are skipped.
+const listDirScript = `[ -e "$1" ] || [ -L "$1" ] || exit 66
+[ -d "$1" ] || exit 65
+[ -r "$1" ] && [ -x "$1" ] || exit 77
+depth="-maxdepth 1"
+[ "$2" = 1 ] && depth=
+if find "$1" -maxdepth 0 -printf '' >/dev/null 2>&1; then
+	echo find
+	find "$1/" -mindepth 1 $depth -printf '%M\0%u\0%g\0%s\0%T@\0%P\0%l\0' 2>/dev/null
+else
+	echo ls
+	if [ "$2" = 1 ]; then ls -laR -- "$1/" 2>/dev/null; else ls -la -- "$1/"; fi
+fi
+s=$?
+[ "$2" = 1 ] && exit 0
+exit $s`
+
+// ListDir returns the entries of the directory 'dirPath' in a container, identified by the container's name
+// and the associated pod's name, with their types, permissions, owners, sizes, modification times and targets
+// of symbolic links. If 'recursive' is true, the entries of all subdirectories are returned as well, with
+// names relative to 'dirPath'; subdirectories which cannot be read are skipped. The entries are read with
+// GNU find, or parsed from 'ls -la' output in containers lacking it, e.g. busybox based containers, in which
+// case modification times have a precision of minutes, or of days for files older than half a year.
+// Missing and unreadable directories are reported with errors wrapping ErrFileNotFound and ErrFileNotReadable,
+// and other files with errors wrapping ErrNotDirectory. In pods running on Windows nodes the entries are read
+// with PowerShell's Get-ChildItem, and their names use backslashes as separators. The call is governed by
+// the provided context.
+func (k8s *K8SExec) ListDir(ctx context.Context, podName string, containerName string, dirPath string, recursive bool) ([]DirEntry, error) {
+	if k8s.targetsWindows(ctx, podName) {
+		return k8s.listDirWindows(ctx, podName, containerName, dirPath, recursive)
+	}
+
+	var stdout, stderr bytes.Buffer
+	flag := "0"
+	if recursive {
+		flag = "1"
+	}
+	cmd := []string{"sh", "-c", listDirScript, "sh", dirPath, flag}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if err := fileError("listing", podName, containerName, dirPath, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+
+	tool, output, _ := strings.Cut(stdout.String(), "\n")
+	var entries []DirEntry
+	switch tool {
+	case "find":
+		entries, err = parseFindListing(dirPath, output)
+	case "ls":
+		entries, err = parseLsListing(dirPath, output, time.Now())
+	default:
+		err = fmt.Errorf("unexpected output %q", tool)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing %s/%s:%s: %w", podName, containerName, dirPath, err)
+	}
+	return entries, nil
+}
+
+// findListingFields is the number of fields listDirScript prints for every entry with 'find -printf'.
+const findListingFields = 7
+
+// parseFindListing parses the NUL-terminated fields printed by listDirScript with 'find -printf'.
+func parseFindListing(dirPath string, output string) ([]DirEntry, error) {
+	fields := strings.Split(output, "\x00")
+	// the output ends with a terminator, leaving an empty trailing field
+	fields = fields[:len(fields)-1]
+	if len(fields)%findListingFields != 0 {
+		return nil, fmt.Errorf("unexpected number of fields %d", len(fields))
+	}
+
+	entries := make([]DirEntry, 0, len(fields)/findListingFields)
+	for ; len(fields) > 0; fields = fields[findListingFields:] {
+		mode, err := parseModeString(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.ParseInt(fields[3], 10, 64)
+		seconds, _, _ := strings.Cut(fields[4], ".")
+		entries = append(entries, DirEntry{
+			FileInfo: FileInfo{
+				Path:    path.Join(dirPath, fields[5]),
+				Type:    fileTypeOf(mode),
+				Mode:    mode,
+				Owner:   fields[1],
+				Group:   fields[2],
+				Size:    size,
+				ModTime: unixTime(seconds),
+			},
+			Name:       fields[5],
+			LinkTarget: fields[6],
+		})
+	}
+	return entries, nil
+}
+
+// parseLsListing parses the output of 'ls -la', or of 'ls -laR' in which listings of directories are preceded
+// by the directories' paths, as printed by listDirScript. Times without a year are assumed to fall within
+// the year before 'now'.
+func parseLsListing(dirPath string, output string, now time.Time) ([]DirEntry, error) {
+	// the listed directory is passed with a trailing slash, so headers share it as a prefix
+	root := strings.TrimSuffix(dirPath, "/") + "/"
+	var entries []DirEntry
+	dir := ""
+	// headers of directory listings start the output or follow an empty line
+	header := true
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(nil, maxLineLength)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			header = true
+			continue
+		case header && strings.HasSuffix(line, ":") && strings.HasPrefix(line, root):
+			dir = strings.Trim(strings.TrimPrefix(strings.TrimSuffix(line, ":"), root), "/")
+			header = false
+			continue
+		case strings.HasPrefix(line, "total "):
+			continue
+		}
+		header = false
+
+		entry, err := parseLsLine(line, now)
+		if err != nil {
+			return nil, err
+		}
+		if entry.Name == "." || entry.Name == ".." {
+			continue
+		}
+		entry.Name = path.Join(dir, entry.Name)
+		entry.Path = path.Join(dirPath, entry.Name)
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// parseLsLine parses a line of 'ls -l' output, e.g. "lrwxrwxrwx 1 root root 7 Jan 2 15:04 sh -> busybox".
+func parseLsLine(line string, now time.Time) (DirEntry, error) {
+	fields, rest := cutFields(line, 8)
+	if len(fields) < 8 || rest == "" {
+		return DirEntry{}, fmt.Errorf("unexpected listing line %q", line)
+	}
+	mode, err := parseModeString(fields[0])
+	if err != nil {
+		return DirEntry{}, err
+	}
+	// device files show their major and minor numbers, e.g. "1, 3", in place of the size
+	if strings.HasSuffix(fields[4], ",") {
+		var extra []string
+		extra, rest = cutFields(rest, 1)
+		if len(extra) == 0 || rest == "" {
+			return DirEntry{}, fmt.Errorf("unexpected listing line %q", line)
+		}
+		fields = append(fields[:5], append(fields[6:], extra...)...)
+	}
+	size, _ := strconv.ParseInt(fields[4], 10, 64)
+
+	entry := DirEntry{
+		FileInfo: FileInfo{
+			Type:    fileTypeOf(mode),
+			Mode:    mode,
+			Owner:   fields[2],
+			Group:   fields[3],
+			Size:    size,
+			ModTime: parseLsTime(fields[5], fields[6], fields[7], now),
+		},
+		Name: rest,
+	}
+	if entry.Type == FileTypeSymlink {
+		entry.Name, entry.LinkTarget, _ = strings.Cut(rest, " -> ")
+	}
+	return entry, nil
+}
+
+// parseLsTime parses the time shown by 'ls -l', either "Jan 2 15:04" for recent files or "Jan 2 2006" for
+// older ones. It returns the zero time if the time cannot be parsed.
+func parseLsTime(month string, day string, clockOrYear string, now time.Time) time.Time {
+	value := month + " " + day + " " + clockOrYear
+	if t, err := time.Parse("Jan 2 2006", value); err == nil {
+		return t
+	}
+	t, err := time.Parse("Jan 2 15:04", value)
+	if err != nil {
+		return time.Time{}
+	}
+	// the year is set rather than added, so February 29 is kept for leap years
+	stamp := time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	if stamp.After(now.AddDate(0, 0, 1)) {
+		stamp = time.Date(now.Year()-1, t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	}
+	return stamp
+}
+
+// cutFields splits up to 'n' whitespace separated fields off the beginning of 's' and returns them along with
+// the remainder of 's', whose whitespace is preserved.
+func cutFields(s string, n int) ([]string, string) {
+	var fields []string
+	for len(fields) < n {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			break
+		}
+		end := strings.IndexAny(s, " \t")
+		if end < 0 {
+			end = len(s)
+		}
+		fields = append(fields, s[:end])
+		s = s[end:]
+	}
+	return fields, strings.TrimLeft(s, " \t")
+}
@@ -0,0 +1,282 @@
+package k8sexec
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listing is the expected subset of a DirEntry; modification times are compared separately.
+type listing struct {
+	name, path, target string
+	fileType           FileType
+	mode               fs.FileMode
+	size               int64
+	modTime            time.Time
+}
+
+func checkListing(t *testing.T, entries []DirEntry, want []listing) {
+	t.Helper()
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries %+v, want %d", len(entries), entries, len(want))
+	}
+	for i, entry := range entries {
+		got := listing{entry.Name, entry.Path, entry.LinkTarget, entry.Type, entry.Mode, entry.Size, entry.ModTime}
+		if got.modTime.Equal(want[i].modTime) {
+			got.modTime = want[i].modTime
+		}
+		if got != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestParseFindListing(t *testing.T) {
+	modTime := time.Unix(1792152000, 0)
+	entry := func(fields ...string) string {
+		return strings.Join(fields, "\x00") + "\x00"
+	}
+
+	tests := []struct {
+		name   string
+		output string
+		want   []listing
+		err    bool
+	}{
+		{
+			name: "entries",
+			output: entry("drwxr-xr-x", "root", "root", "4096", "1792152000.0000000000", "sub dir", "") +
+				entry("-rw-r--r--", "root", "root", "2", "1792152000.0000000000", "sub dir/inner", "") +
+				entry("-rw-r--r--", "app", "app", "0", "1709632800.0000000000", "empty", "") +
+				entry("lrwxrwxrwx", "root", "root", "6", "1792152000.0000000000", "link", "a file"),
+			want: []listing{
+				{name: "sub dir", path: "/data/sub dir", fileType: FileTypeDirectory, mode: fs.ModeDir | 0o755, size: 4096, modTime: modTime},
+				{name: "sub dir/inner", path: "/data/sub dir/inner", fileType: FileTypeRegular, mode: 0o644, size: 2, modTime: modTime},
+				{name: "empty", path: "/data/empty", fileType: FileTypeRegular, mode: 0o644, modTime: time.Unix(1709632800, 0)},
+				{name: "link", path: "/data/link", target: "a file", fileType: FileTypeSymlink, mode: fs.ModeSymlink | 0o777, size: 6, modTime: modTime},
+			},
+		},
+		{
+			name:   "names with newlines and spaces",
+			output: entry("-rw-------", "root", "root", "1", "1792152000.5", " line 1\nline 2 ", ""),
+			want: []listing{
+				{name: " line 1\nline 2 ", path: "/data/ line 1\nline 2 ", fileType: FileTypeRegular, mode: 0o600, size: 1, modTime: modTime},
+			},
+		},
+		{name: "empty directory", output: "", want: nil},
+		{name: "truncated output", output: entry("-rw-r--r--", "root", "root", "1"), err: true},
+		{name: "invalid mode", output: entry("?rw-r--r-X", "root", "root", "1", "1792152000.0", "file", ""), err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := parseFindListing("/data", test.output)
+			if test.err {
+				if err == nil {
+					t.Errorf("parseFindListing = %+v, want an error", entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkListing(t, entries, test.want)
+		})
+	}
+}
+
+func TestParseLsListing(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 13, 3, 0, 0, time.UTC)
+	recent := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+	old := time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		output string
+		want   []listing
+		err    bool
+	}{
+		{
+			name: "GNU ls",
+			output: "total 16\n" +
+				"drwxr-xr-x  3 root root 4096 Oct 16 13:03 .\n" +
+				"drwxrwxrwt 17 root root 4096 Oct 16 13:03 ..\n" +
+				"-rw-r--r--  1 root root    1 Oct 16 12:00 a file\n" +
+				"-rw-r--r--  1 root root    0 Mar  5  2024 empty\n" +
+				"lrwxrwxrwx  1 root root    6 Oct 16 12:00 link -> a file\n" +
+				"drwxr-xr-x  2 root root 4096 Oct 16 12:00 sub dir\n",
+			want: []listing{
+				{name: "a file", path: "/data/a file", fileType: FileTypeRegular, mode: 0o644, size: 1, modTime: recent},
+				{name: "empty", path: "/data/empty", fileType: FileTypeRegular, mode: 0o644, modTime: old},
+				{name: "link", path: "/data/link", target: "a file", fileType: FileTypeSymlink, mode: fs.ModeSymlink | 0o777, size: 6, modTime: recent},
+				{name: "sub dir", path: "/data/sub dir", fileType: FileTypeDirectory, mode: fs.ModeDir | 0o755, size: 4096, modTime: recent},
+			},
+		},
+		{
+			name: "busybox ls",
+			output: "total 12\n" +
+				"drwxr-xr-x    3 root     root          4096 Oct 16 13:03 .\n" +
+				"drwxrwxrwt   17 root     root          4096 Oct 16 13:03 ..\n" +
+				"-rw-r--r--    1 1000     1000             1 Oct 16 12:00 a file\n" +
+				"crw-rw-rw-    1 root     root        1,   3 Oct 16 12:00 null\n" +
+				"lrwxrwxrwx    1 root     root             7 Oct 16 12:00 sh -> busybox\n",
+			want: []listing{
+				{name: "a file", path: "/data/a file", fileType: FileTypeRegular, mode: 0o644, size: 1, modTime: recent},
+				{name: "null", path: "/data/null", fileType: FileTypeCharDevice, mode: fs.ModeDevice | fs.ModeCharDevice | 0o666, modTime: recent},
+				{name: "sh", path: "/data/sh", target: "busybox", fileType: FileTypeSymlink, mode: fs.ModeSymlink | 0o777, size: 7, modTime: recent},
+			},
+		},
+		{
+			name: "recursive GNU ls",
+			output: "/data/:\n" +
+				"total 8\n" +
+				"drwxr-xr-x  3 root root 4096 Oct 16 13:03 .\n" +
+				"drwxrwxrwt 17 root root 4096 Oct 16 13:03 ..\n" +
+				"drwxr-xr-x  2 root root 4096 Oct 16 12:00 sub dir\n" +
+				"\n" +
+				"/data/sub dir:\n" +
+				"total 4\n" +
+				"drwxr-xr-x 2 root root 4096 Oct 16 12:00 .\n" +
+				"drwxr-xr-x 3 root root 4096 Oct 16 13:03 ..\n" +
+				"-rw-r--r-- 1 root root    2 Oct 16 12:00 inner:\n",
+			want: []listing{
+				{name: "sub dir", path: "/data/sub dir", fileType: FileTypeDirectory, mode: fs.ModeDir | 0o755, size: 4096, modTime: recent},
+				{name: "sub dir/inner:", path: "/data/sub dir/inner:", fileType: FileTypeRegular, mode: 0o644, size: 2, modTime: recent},
+			},
+		},
+		{
+			name: "recursive busybox ls",
+			output: "/data/:\n" +
+				"total 4\n" +
+				"drwxr-xr-x    2 root     root          4096 Oct 16 12:00 etc\n" +
+				"\n" +
+				"/data/etc:\n" +
+				"total 4\n" +
+				"-rw-r--r--    1 root     root             0 Mar  5  2024 hosts\n",
+			want: []listing{
+				{name: "etc", path: "/data/etc", fileType: FileTypeDirectory, mode: fs.ModeDir | 0o755, size: 4096, modTime: recent},
+				{name: "etc/hosts", path: "/data/etc/hosts", fileType: FileTypeRegular, mode: 0o644, modTime: old},
+			},
+		},
+		{
+			name: "empty directory",
+			output: "total 0\n" +
+				"drwxr-xr-x 2 root root 4096 Oct 16 12:00 .\n" +
+				"drwxr-xr-x 3 root root 4096 Oct 16 13:03 ..\n",
+			want: nil,
+		},
+		{
+			// ls prints newlines in names verbatim, leaving a line which cannot be parsed
+			name:   "name with a newline",
+			output: "-rw-r--r-- 1 root root 0 Oct 16 12:00 line 1\nline 2\n",
+			err:    true,
+		},
+		{name: "unexpected line", output: "ls: /data/secret: Permission denied\n", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := parseLsListing("/data", test.output, now)
+			if test.err {
+				if err == nil {
+					t.Errorf("parseLsListing = %+v, want an error", entries)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkListing(t, entries, test.want)
+		})
+	}
+}
+
+func TestParseLsLine(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 13, 3, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		line string
+		want listing
+		err  bool
+	}{
+		{
+			name: "name with spaces",
+			line: "-rw-r--r-- 1 root root 5 Oct 16 12:00 my  file ",
+			want: listing{name: "my  file ", fileType: FileTypeRegular, mode: 0o644, size: 5},
+		},
+		{
+			name: "symbolic link to a name with spaces",
+			line: "lrwxrwxrwx 1 root root 9 Oct 16 12:00 my link -> my  file ",
+			want: listing{name: "my link", target: "my  file ", fileType: FileTypeSymlink, mode: fs.ModeSymlink | 0o777, size: 9},
+		},
+		{
+			name: "block device",
+			line: "brw-rw---- 1 root disk 7, 0 Oct 16 12:00 loop0",
+			want: listing{name: "loop0", fileType: FileTypeBlockDevice, mode: fs.ModeDevice | 0o660},
+		},
+		{
+			name: "busybox device without space after the comma",
+			line: "crw-rw-rw-    1 root     root       1,3 Oct 16 12:00 null",
+			want: listing{name: "null", fileType: FileTypeCharDevice, mode: fs.ModeDevice | fs.ModeCharDevice | 0o666},
+		},
+		{
+			name: "sticky directory with ACL flag",
+			line: "drwxrwxrwt+ 2 root root 40 Oct 16 12:00 tmp",
+			want: listing{name: "tmp", fileType: FileTypeDirectory, mode: fs.ModeDir | fs.ModeSticky | 0o777, size: 40},
+		},
+		{name: "missing name", line: "-rw-r--r-- 1 root root 5 Oct 16 12:00", err: true},
+		{name: "device missing fields", line: "crw-rw-rw- 1 root root 1, 3 Oct 16", err: true},
+		{name: "invalid mode", line: "-rw-r--r-? 1 root root 5 Oct 16 12:00 file", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry, err := parseLsLine(test.line, now)
+			if test.err {
+				if err == nil {
+					t.Errorf("parseLsLine(%q) = %+v, want an error", test.line, entry)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.ModTime.IsZero() {
+				t.Errorf("parseLsLine(%q) has no modification time", test.line)
+			}
+			entry.ModTime = time.Time{}
+			checkListing(t, []DirEntry{entry}, []listing{test.want})
+		})
+	}
+}
+
+func TestParseLsTime(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 13, 3, 0, 0, time.UTC)
+
+	tests := []struct {
+		name                    string
+		month, day, clockOrYear string
+		now                     time.Time
+		want                    time.Time
+	}{
+		{name: "recent", month: "Oct", day: "16", clockOrYear: "12:00", now: now, want: time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)},
+		{name: "earlier this year", month: "Mar", day: "5", clockOrYear: "09:30", now: now, want: time.Date(2026, time.March, 5, 9, 30, 0, 0, time.UTC)},
+		{name: "tomorrow due to clock skew", month: "Oct", day: "17", clockOrYear: "08:00", now: now, want: time.Date(2026, time.October, 17, 8, 0, 0, 0, time.UTC)},
+		{name: "last year", month: "Dec", day: "31", clockOrYear: "23:59", now: time.Date(2026, time.January, 2, 0, 0, 0, 0, time.UTC), want: time.Date(2025, time.December, 31, 23, 59, 0, 0, time.UTC)},
+		{name: "leap day of last year", month: "Feb", day: "29", clockOrYear: "10:00", now: time.Date(2025, time.January, 2, 0, 0, 0, 0, time.UTC), want: time.Date(2024, time.February, 29, 10, 0, 0, 0, time.UTC)},
+		{name: "old file", month: "Mar", day: "5", clockOrYear: "2024", now: now, want: time.Date(2024, time.March, 5, 0, 0, 0, 0, time.UTC)},
+		{name: "localized month", month: "Okt", day: "16", clockOrYear: "12:00", now: now},
+		{name: "ISO time style", month: "2026-10-16", day: "12:00", clockOrYear: "file", now: now},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := parseLsTime(test.month, test.day, test.clockOrYear, test.now)
+			if !got.Equal(test.want) {
+				t.Errorf("parseLsTime(%q, %q, %q) = %v, want %v", test.month, test.day, test.clockOrYear, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package k8sexec
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sync"
+)
+
+// prefixColors are the ANSI colors of prefixes written by MultiplexWriter with coloring enabled.
+var prefixColors = []int{31, 32, 33, 34, 35, 36, 91, 92, 93, 94, 95, 96}
+
+// MultiplexWriter merges live output of commands executed concurrently in many containers into a single
+// stream, like stern does for logs: every line is prefixed with "[pod/container] " and lines are never
+// interleaved. With coloring enabled, prefixes are colored with ANSI escape codes, with a stable color per
+// container. It is meant for tools tailing a command across a whole deployment:
+//
+//	mux := NewMultiplexWriter(os.Stdout, true)
+//	for _, target := range targets {
+//		go k8s.ExecLines(ctx, target.Pod, target.Container, args, mux.WriteLine)
+//	}
+//
+// Output streamed with ExecStream and similar functions can be multiplexed with the writers returned by Writer.
+type MultiplexWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	color bool
+	err   error
+}
+
+// NewMultiplexWriter creates a MultiplexWriter writing to 'w', coloring prefixes if 'color' is set.
+func NewMultiplexWriter(w io.Writer, color bool) *MultiplexWriter {
+	return &MultiplexWriter{w: w, color: color}
+}
+
+// WriteLine writes a line of output, e.g. delivered by ExecLines, prefixed with its pod and container.
+func (mux *MultiplexWriter) WriteLine(line Line) {
+	prefix := "[" + line.Pod + "/" + line.Container + "]"
+	if mux.color {
+		hash := fnv.New32a()
+		_, _ = io.WriteString(hash, line.Pod+"/"+line.Container)
+		prefix = fmt.Sprintf("\x1b[%dm%s\x1b[0m", prefixColors[hash.Sum32()%uint32(len(prefixColors))], prefix)
+	}
+
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	if mux.err == nil {
+		_, mux.err = fmt.Fprintf(mux.w, "%s %s\n", prefix, line.Text)
+	}
+}
+
+// Writer returns a writer for the output of a command executed in the container, to be passed e.g. as stdout or
+// stderr of ExecStream. Output is written to the multiplexed stream line by line; the writer must be closed once
+// the command exits to flush a final line lacking a newline.
+func (mux *MultiplexWriter) Writer(podName string, containerName string) io.WriteCloser {
+	return &lineWriter{line: Line{Pod: podName, Container: containerName, Stream: Stdout}, deliver: mux.WriteLine}
+}
+
+// Err returns the first error encountered while writing to the underlying writer, if any.
+func (mux *MultiplexWriter) Err() error {
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	return mux.err
+}
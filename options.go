@@ -0,0 +1,74 @@
+package k8sexec
+
+import (
+	"io"
+	"k8s.io/client-go/util/flowcontrol"
+	"log/slog"
+	"time"
+)
+
+// Option configures instance-wide behavior of a K8SExec instance. Options are passed to NewK8SExec and
+// are applied before the Kubernetes clientset is created, so they can influence the underlying rest.Config.
+type Option func(k8s *K8SExec)
+
+// WithLogger sets a logger used to report diagnostic information such as executed commands and retries.
+// By default nothing is logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(k8s *K8SExec) {
+		k8s.log = logger
+	}
+}
+
+// WithRateLimiter sets a rate limiter shared by all requests sent to the Kubernetes API by the instance,
+// including command executions, which are not covered by the clientset's own rate limiting.
+// The state of the limiter can be inspected with RateLimiterStats; limiters not implementing
+// RateLimiterIntrospector themselves are instrumented to make that possible.
+func WithRateLimiter(rateLimiter flowcontrol.RateLimiter) Option {
+	if _, ok := rateLimiter.(RateLimiterIntrospector); !ok && rateLimiter != nil {
+		rateLimiter = &instrumentedRateLimiter{RateLimiter: rateLimiter}
+	}
+	return func(k8s *K8SExec) {
+		k8s.rateLimiter = rateLimiter
+		k8s.Config.RateLimiter = rateLimiter
+	}
+}
+
+// WithDefaultTimeout sets the timeout applied by Exec when it is called with a zero timeout, and by helper
+// executions such as CheckUtilInContainer. It is a shorthand for WithDefaultTimeouts(Timeouts{Default: timeout}).
+func WithDefaultTimeout(timeout time.Duration) Option {
+	return WithDefaultTimeouts(Timeouts{Default: timeout})
+}
+
+// WithRetryPolicy sets the policy used to retry command executions failing because of transport problems.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(k8s *K8SExec) {
+		k8s.retryPolicy = policy
+	}
+}
+
+// WithTransportCache enables caching of the TLS configuration used to open exec streams. With the cache
+// enabled, certificates are loaded only once and TLS sessions are resumed across executions, which noticeably
+// reduces the cost of running many commands against the same cluster.
+func WithTransportCache() Option {
+	return func(k8s *K8SExec) {
+		k8s.transports = &transportCache{}
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request to the Kubernetes API.
+func WithUserAgent(userAgent string) Option {
+	return func(k8s *K8SExec) {
+		k8s.Config.UserAgent = userAgent
+	}
+}
+
+// logger returns the logger configured with WithLogger, or a logger discarding all messages if none was set.
+func (k8s *K8SExec) logger() *slog.Logger {
+	if k8s.log == nil {
+		return discardLogger
+	}
+	return k8s.log
+}
+
+// discardLogger is used when no logger was configured for a K8SExec instance.
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
@@ -0,0 +1,60 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// pathTypeScript prints the type of the file system object given as the first parameter, without following
+// symbolic links, using the names of FileType. For symbolic links, the link's target follows on the next line.
+const pathTypeScript = `if [ -L "$1" ]; then
+	echo symlink
+	t=$(readlink -- "$1" 2>/dev/null) || { l=$(ls -ld -- "$1") && t=${l#* -> }; }
+	printf '%s' "$t"
+	exit 0
+fi
+[ -e "$1" ] || exit 66
+if [ -f "$1" ]; then echo regular
+elif [ -d "$1" ]; then echo directory
+elif [ -S "$1" ]; then echo socket
+elif [ -p "$1" ]; then echo fifo
+elif [ -c "$1" ]; then echo char-device
+elif [ -b "$1" ]; then echo block-device
+else echo unknown
+fi`
+
+// PathType returns the type of the file system object 'path' in a container, identified by the container's name
+// and the associated pod's name: a regular file, a directory, a symbolic link, a socket, a FIFO or a device.
+// For symbolic links, which are not followed, the link's target is returned as well; it is empty for other
+// types. Missing objects are reported with errors wrapping ErrFileNotFound. Only shell tests are used, so it
+// works in containers lacking 'stat'. The call is governed by the provided context.
+func (k8s *K8SExec) PathType(ctx context.Context, podName string, containerName string, path string) (FileType, string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"sh", "-c", pathTypeScript, "sh", path}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if err := fileError("inspecting", podName, containerName, path, retCode, err, &stderr); err != nil {
+		return "", "", err
+	}
+
+	fileType, target, _ := strings.Cut(stdout.String(), "\n")
+	switch FileType(fileType) {
+	case FileTypeRegular, FileTypeDirectory, FileTypeSymlink, FileTypeSocket, FileTypeFIFO,
+		FileTypeCharDevice, FileTypeBlockDevice, FileTypeUnknown:
+		return FileType(fileType), target, nil
+	}
+	return "", "", fmt.Errorf("inspecting %s/%s:%s: unexpected output %q", podName, containerName, path, stdout.String())
+}
+
+// PathExists reports whether the file system object 'path' exists in a container, identified by the container's
+// name and the associated pod's name, whatever its type, e.g. a directory or a dangling symbolic link.
+// An error is returned only if the check itself fails. The call is governed by the provided context.
+func (k8s *K8SExec) PathExists(ctx context.Context, podName string, containerName string, path string) (bool, error) {
+	_, _, err := k8s.PathType(ctx, podName, containerName, path)
+	if errors.Is(err, ErrFileNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
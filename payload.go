@@ -0,0 +1,83 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// base64LineLength is the length of lines of base64-encoded payloads embedded in scripts by Base64Heredoc.
+const base64LineLength = 76
+
+// Heredoc returns a shell snippet delivering 'payload' to the standard input of 'command' with a here-document,
+// e.g. Heredoc("cat > /tmp/app.conf", config). The delimiter is quoted, so the payload is not subject to
+// parameter expansion or command substitution, and it is generated to never occur in the payload.
+// A newline is appended to payloads not ending with one. Here-documents are not binary safe; Base64Heredoc
+// should be used for payloads that may contain NUL bytes or must be delivered byte for byte.
+func Heredoc(command string, payload []byte) string {
+	return heredoc(command, "", payload)
+}
+
+// heredoc returns a shell snippet delivering 'payload' to the standard input of 'command' with a here-document.
+// 'pipeline', if not empty, follows the redirection on the same line, e.g. "| sh" to pipe the output of
+// 'command' to another one.
+func heredoc(command string, pipeline string, payload []byte) string {
+	delimiter := heredocDelimiter(payload)
+
+	var script strings.Builder
+	script.WriteString(command)
+	script.WriteString(" <<'")
+	script.WriteString(delimiter)
+	script.WriteString("'")
+	script.WriteString(pipeline)
+	script.WriteString("\n")
+	script.Write(payload)
+	if len(payload) > 0 && payload[len(payload)-1] != '\n' {
+		script.WriteByte('\n')
+	}
+	script.WriteString(delimiter)
+	script.WriteByte('\n')
+	return script.String()
+}
+
+// Base64Heredoc returns a shell snippet delivering 'payload' byte for byte to the standard input of 'command',
+// by embedding it base64-encoded in a here-document decoded in the container. It requires the base64 utility
+// in the container.
+func Base64Heredoc(command string, payload []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(payload)
+
+	var lines bytes.Buffer
+	for len(encoded) > base64LineLength {
+		lines.WriteString(encoded[:base64LineLength])
+		lines.WriteByte('\n')
+		encoded = encoded[base64LineLength:]
+	}
+	lines.WriteString(encoded)
+
+	// the here-document is redirected to base64, which is the first command of the pipeline
+	return heredoc("base64 -d", " | "+command, lines.Bytes())
+}
+
+// heredocDelimiter returns a here-document delimiter which does not occur in 'payload'.
+func heredocDelimiter(payload []byte) string {
+	for {
+		random := make([]byte, 8)
+		_, _ = rand.Read(random)
+		delimiter := "K8SEXEC_EOF_" + strings.ToUpper(hex.EncodeToString(random))
+		if !bytes.Contains(payload, []byte(delimiter)) {
+			return delimiter
+		}
+	}
+}
+
+// ExecWithPayload executes the command provided as arguments ('args') and delivers 'payload' to its standard
+// input. Standard input is binary safe, so this is the preferred way of passing files or scripts to a single
+// command, e.g. ExecWithPayload(ctx, pod, container, []string{"sh", "-c", "cat > /tmp/tool && chmod +x /tmp/tool"}, tool).
+// Heredoc and Base64Heredoc are useful when the payload is one of many parts of a larger script.
+// The execution is governed by the provided context.
+func (k8s *K8SExec) ExecWithPayload(ctx context.Context, podName string, containerName string, args []string, payload []byte) *ExecutionStatus {
+	return k8s.ExecWithContext(ctx, podName, containerName, args, bytes.NewReader(payload))
+}
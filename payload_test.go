@@ -0,0 +1,93 @@
+package k8sexec
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// runShell runs a script with the local sh and returns its standard output.
+func runShell(t *testing.T, script string) []byte {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh available")
+	}
+	output, err := exec.Command("sh", "-c", script).Output()
+	if err != nil {
+		t.Fatalf("running %q: %v", script, err)
+	}
+	return output
+}
+
+func TestHeredoc(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{name: "empty payload", payload: "", want: ""},
+		{name: "trailing newline", payload: "key=value\n", want: "key=value\n"},
+		{name: "no trailing newline", payload: "key=value", want: "key=value\n"},
+		{name: "expansions are not performed", payload: "$HOME $(id) `id` ${x:-y} \\n\n", want: "$HOME $(id) `id` ${x:-y} \\n\n"},
+		{name: "quotes and leading tabs", payload: "\t'single' \"double\"\n  spaced  \n", want: "\t'single' \"double\"\n  spaced  \n"},
+		{name: "delimiter-like lines", payload: "EOF\nK8SEXEC_EOF_\nK8SEXEC_EOF_0123456789ABCDEF\n", want: "EOF\nK8SEXEC_EOF_\nK8SEXEC_EOF_0123456789ABCDEF\n"},
+		{name: "empty lines", payload: "\n\n\n", want: "\n\n\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if output := runShell(t, Heredoc("cat", []byte(test.payload))); string(output) != test.want {
+				t.Errorf("delivered %q, want %q", output, test.want)
+			}
+		})
+	}
+}
+
+func TestBase64Heredoc(t *testing.T) {
+	if _, err := exec.LookPath("base64"); err != nil {
+		t.Skip("no base64 available")
+	}
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{name: "empty payload", payload: nil},
+		{name: "text without a trailing newline", payload: []byte("no newline")},
+		{name: "binary data", payload: []byte{0, 1, 2, 0xff, '\n', 0, '\r', 0x7f}},
+		{name: "several encoded lines", payload: bytes.Repeat([]byte("0123456789\x00"), 50)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if output := runShell(t, Base64Heredoc("cat", test.payload)); !bytes.Equal(output, test.payload) {
+				t.Errorf("delivered %q, want %q", output, test.payload)
+			}
+		})
+	}
+}
+
+func TestHeredocDelimiter(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{name: "empty payload", payload: ""},
+		{name: "payload with the delimiter prefix", payload: strings.Repeat("K8SEXEC_EOF_\n", 10)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			delimiter := heredocDelimiter([]byte(test.payload))
+			if !strings.HasPrefix(delimiter, "K8SEXEC_EOF_") || len(delimiter) != len("K8SEXEC_EOF_")+16 {
+				t.Errorf("unexpected delimiter %q", delimiter)
+			}
+			if strings.Contains(test.payload, delimiter) {
+				t.Errorf("delimiter %q occurs in the payload", delimiter)
+			}
+			if strings.ContainsAny(delimiter, "'\"$` \n") {
+				t.Errorf("delimiter %q needs quoting", delimiter)
+			}
+		})
+	}
+}
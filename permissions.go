@@ -0,0 +1,108 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// inspectPermissionsScript prints the properties of the file given as the first parameter, obtained with
+// the script given as the second parameter (statScript), followed by the access the current user has to it,
+// the user's name and ID, and the file's ACL entries if the container provides 'getfacl'.
+const inspectPermissionsScript = `s=$(sh -c "$2" sh "$1") || exit
+echo "$s"
+[ -r "$1" ] && a=r || a=-
+[ -w "$1" ] && a=${a}w || a=${a}-
+[ -x "$1" ] && a=${a}x || a=${a}-
+echo "access $a"
+u=$(id -un 2>/dev/null)
+i=$(id -u 2>/dev/null)
+echo "user ${u:--} ${i:--}"
+if command -v getfacl >/dev/null 2>&1; then
+	echo acl
+	getfacl -p -- "$1" 2>/dev/null
+fi
+exit 0`
+
+// Permissions describes the permissions of a file system object in a container and the access the container's
+// user has to it.
+type Permissions struct {
+	FileInfo
+	// User is the name of the user the container's processes run as, or its numeric ID if it has no name.
+	// UserID is the numeric ID, or -1 if it could not be determined.
+	User   string `json:"user"`
+	UserID int    `json:"userID"`
+	// Readable, Writable and Executable report whether the user can read, write and execute (or, for
+	// directories, search) the object, as determined by the container's kernel, so capabilities and ACLs are
+	// taken into account.
+	Readable   bool `json:"readable"`
+	Writable   bool `json:"writable"`
+	Executable bool `json:"executable"`
+	// ACL holds the access control list entries of the object as printed by 'getfacl', e.g. "user:app:r--",
+	// including the entries corresponding to the mode bits. It is nil if the container lacks getfacl.
+	ACL []string `json:"acl,omitempty"`
+}
+
+// InspectPermissions returns the owner, group and mode bits of the file system object 'path' in a container,
+// identified by the container's name and the associated pod's name, its ACL entries if the container provides
+// 'getfacl', and whether the user the container runs as can read, write and execute it. Unlike boolean
+// checks, it tells why an object is not accessible. Missing objects are reported with errors wrapping
+// ErrFileNotFound. Symbolic links are not followed for the properties, but are for the access checks.
+// The call is governed by the provided context.
+func (k8s *K8SExec) InspectPermissions(ctx context.Context, podName string, containerName string, path string) (*Permissions, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"sh", "-c", inspectPermissionsScript, "sh", path, statScript}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if err := fileError("inspecting", podName, containerName, path, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+
+	permissions, err := parsePermissions(path, stdout.String())
+	if err != nil {
+		return nil, fmt.Errorf("inspecting %s/%s:%s: %w", podName, containerName, path, err)
+	}
+	return permissions, nil
+}
+
+// parsePermissions parses the output of inspectPermissionsScript.
+func parsePermissions(path string, output string) (*Permissions, error) {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	info, err := parseStat(path, lines[0])
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := &Permissions{FileInfo: *info, UserID: -1}
+	acl := false
+	for _, line := range lines[1:] {
+		switch {
+		case acl:
+			if line != "" && !strings.HasPrefix(line, "#") {
+				permissions.ACL = append(permissions.ACL, line)
+			}
+		case strings.HasPrefix(line, "access "):
+			access := strings.TrimPrefix(line, "access ")
+			permissions.Readable = strings.Contains(access, "r")
+			permissions.Writable = strings.Contains(access, "w")
+			permissions.Executable = strings.Contains(access, "x")
+		case strings.HasPrefix(line, "user "):
+			fields := strings.Fields(line)
+			if len(fields) != 3 {
+				return nil, fmt.Errorf("unexpected output %q", line)
+			}
+			if id, err := strconv.Atoi(fields[2]); err == nil {
+				permissions.UserID = id
+			}
+			permissions.User = fields[1]
+			if permissions.User == "-" {
+				permissions.User = fields[2]
+			}
+		case line == "acl":
+			acl = true
+			permissions.ACL = []string{}
+		}
+	}
+	return permissions, nil
+}
@@ -0,0 +1,52 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrContainerNotRunning is returned when a command cannot be executed because the target container is not
+// running, e.g. because it is waiting in CrashLoopBackOff or has terminated. The error message carries
+// the container's state and its reason.
+var ErrContainerNotRunning = errors.New("container not running")
+
+// WithContainerCheck makes the instance verify, before every execution, that the target container exists in
+// the pod's spec and is running. Failing executions then report ErrContainerNotFound or ErrContainerNotRunning
+// with the container's state reason, instead of the opaque error returned by the kubelet. Readiness is not
+// required, since commands can be executed in running containers failing their readiness probes.
+// The check costs an additional API request per execution.
+func WithContainerCheck() Option {
+	return func(k8s *K8SExec) {
+		k8s.containerCheck = true
+	}
+}
+
+// checkContainer verifies that the container exists in the pod and is running.
+func (k8s *K8SExec) checkContainer(ctx context.Context, podName string, containerName string) error {
+	pod, err := k8s.GetPodWithContext(ctx, podName, metaV1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, container := range ContainersOf(pod) {
+		if container.Name != containerName {
+			continue
+		}
+		if container.State == ContainerRunning {
+			return nil
+		}
+		state := string(container.State)
+		if container.Reason != "" {
+			state += ": " + container.Reason
+		}
+		return fmt.Errorf("exec in %s/%s: %w (%s)", podName, containerName, ErrContainerNotRunning, state)
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		if container.Name == containerName {
+			return nil
+		}
+	}
+	return fmt.Errorf("exec in %s/%s: %w", podName, containerName, ErrContainerNotFound)
+}
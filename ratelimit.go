@@ -0,0 +1,194 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/flowcontrol"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimiterStats is a snapshot of the state of a rate limiter. It allows batch run operators to tell whether
+// they are limited by the API server or by the client-side rate limiter: a long queue and a growing cumulative
+// wait time mean that requests are held back by the limiter.
+type RateLimiterStats struct {
+	// QPS is the sustained rate of requests per second allowed by the limiter.
+	QPS float32 `json:"QPS"`
+	// Burst is the maximum number of requests allowed at once, or zero if it is not known.
+	Burst int `json:"Burst"`
+	// AvailableTokens is the number of tokens currently available, or -1 if it is not known.
+	AvailableTokens float64 `json:"AvailableTokens"`
+	// Waiting is the number of requests currently waiting for a token (the queue length).
+	Waiting int64 `json:"Waiting"`
+	// Accepted is the total number of requests that obtained a token.
+	Accepted uint64 `json:"Accepted"`
+	// TotalWait is the cumulative time requests spent waiting for tokens.
+	TotalWait time.Duration `json:"TotalWait"`
+}
+
+// RateLimiterIntrospector is implemented by rate limiters able to report their state.
+type RateLimiterIntrospector interface {
+	Stats() RateLimiterStats
+}
+
+// RateLimiterMetrics receives measurements from a rate limiter, e.g. to feed them into a metrics system.
+type RateLimiterMetrics interface {
+	// ObserveWait is called every time a request obtains a token, with the time the request waited for it.
+	ObserveWait(wait time.Duration)
+}
+
+// limiterInstrumentation tracks waits of a rate limiter.
+type limiterInstrumentation struct {
+	waiting   atomic.Int64
+	accepted  atomic.Uint64
+	totalWait atomic.Int64
+	metrics   atomic.Pointer[RateLimiterMetrics]
+}
+
+// wait runs the waiting function 'fn' and records how long it took.
+func (instrumentation *limiterInstrumentation) wait(fn func() error) error {
+	instrumentation.waiting.Add(1)
+	start := time.Now()
+	err := fn()
+	wait := time.Since(start)
+	instrumentation.waiting.Add(-1)
+
+	if err != nil {
+		return err
+	}
+	instrumentation.accepted.Add(1)
+	instrumentation.totalWait.Add(int64(wait))
+	if metrics := instrumentation.metrics.Load(); metrics != nil {
+		(*metrics).ObserveWait(wait)
+	}
+	return nil
+}
+
+// stats fills in the instrumentation part of RateLimiterStats.
+func (instrumentation *limiterInstrumentation) stats(stats RateLimiterStats) RateLimiterStats {
+	stats.Waiting = instrumentation.waiting.Load()
+	stats.Accepted = instrumentation.accepted.Load()
+	stats.TotalWait = time.Duration(instrumentation.totalWait.Load())
+	return stats
+}
+
+// TokenBucket is a token bucket rate limiter implementing flowcontrol.RateLimiter, so it can be passed to
+// WithRateLimiter, and RateLimiterIntrospector, so its state can be inspected while a batch run is in flight.
+type TokenBucket struct {
+	limiter *rate.Limiter
+	limiterInstrumentation
+}
+
+// NewTokenBucket creates a token bucket rate limiter allowing 'qps' requests per second on average and bursts
+// of up to 'burst' requests. The bucket is initially full.
+func NewTokenBucket(qps float32, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{limiter: rate.NewLimiter(rate.Limit(qps), burst)}
+}
+
+// SetRate changes the sustained rate and the burst of the bucket. It is safe to call while requests are waiting
+// for tokens, e.g. to dial a batch run down when the cluster shows stress.
+func (bucket *TokenBucket) SetRate(qps float32, burst int) {
+	bucket.limiter.SetLimit(rate.Limit(qps))
+	bucket.limiter.SetBurst(max(burst, 1))
+}
+
+// SetMetrics sets a receiver of the bucket's measurements; nil disables reporting.
+func (bucket *TokenBucket) SetMetrics(metrics RateLimiterMetrics) {
+	if metrics == nil {
+		bucket.metrics.Store(nil)
+		return
+	}
+	bucket.metrics.Store(&metrics)
+}
+
+// TryAccept takes a token if one is available and reports whether it did.
+func (bucket *TokenBucket) TryAccept() bool {
+	if !bucket.limiter.Allow() {
+		return false
+	}
+	bucket.accepted.Add(1)
+	return true
+}
+
+// Accept waits until a token is available and takes it.
+func (bucket *TokenBucket) Accept() {
+	_ = bucket.Wait(context.Background())
+}
+
+// Wait waits until a token is available and takes it, or returns an error if 'ctx' is done first or the token
+// would not become available before the context's deadline.
+func (bucket *TokenBucket) Wait(ctx context.Context) error {
+	return bucket.wait(func() error { return bucket.limiter.Wait(ctx) })
+}
+
+// Stop is a no-op; it is required by flowcontrol.RateLimiter.
+func (bucket *TokenBucket) Stop() {}
+
+// QPS returns the sustained rate of requests per second allowed by the bucket.
+func (bucket *TokenBucket) QPS() float32 {
+	return float32(bucket.limiter.Limit())
+}
+
+// Stats returns a snapshot of the bucket's state.
+func (bucket *TokenBucket) Stats() RateLimiterStats {
+	return bucket.stats(RateLimiterStats{
+		QPS:             bucket.QPS(),
+		Burst:           bucket.limiter.Burst(),
+		AvailableTokens: bucket.limiter.Tokens(),
+	})
+}
+
+// instrumentedRateLimiter adds introspection to rate limiters not providing it, e.g. to the ones created with
+// flowcontrol.NewTokenBucketRateLimiter. The number of available tokens of such limiters is not known.
+type instrumentedRateLimiter struct {
+	flowcontrol.RateLimiter
+	limiterInstrumentation
+}
+
+func (limiter *instrumentedRateLimiter) TryAccept() bool {
+	if !limiter.RateLimiter.TryAccept() {
+		return false
+	}
+	limiter.accepted.Add(1)
+	return true
+}
+
+func (limiter *instrumentedRateLimiter) Accept() {
+	_ = limiter.wait(func() error {
+		limiter.RateLimiter.Accept()
+		return nil
+	})
+}
+
+func (limiter *instrumentedRateLimiter) Wait(ctx context.Context) error {
+	return limiter.wait(func() error { return limiter.RateLimiter.Wait(ctx) })
+}
+
+func (limiter *instrumentedRateLimiter) Stats() RateLimiterStats {
+	return limiter.stats(RateLimiterStats{QPS: limiter.QPS(), AvailableTokens: -1})
+}
+
+// SetRate changes the rate and the burst of the instance's rate limiter while the instance is in use.
+// It requires the rate limiter configured with WithRateLimiter to be a TokenBucket.
+func (k8s *K8SExec) SetRate(qps float32, burst int) error {
+	bucket, ok := k8s.rateLimiter.(*TokenBucket)
+	if !ok {
+		return errors.New("the rate limiter of the instance does not support changing its rate")
+	}
+	bucket.SetRate(qps, burst)
+	return nil
+}
+
+// RateLimiterStats returns a snapshot of the state of the instance's rate limiter. The second result is false
+// if no rate limiter was configured with WithRateLimiter.
+func (k8s *K8SExec) RateLimiterStats() (RateLimiterStats, bool) {
+	introspector, ok := k8s.rateLimiter.(RateLimiterIntrospector)
+	if !ok {
+		return RateLimiterStats{}, false
+	}
+	return introspector.Stats(), true
+}
@@ -0,0 +1,37 @@
+package k8sexec
+
+import (
+	"context"
+	"github.com/hhruszka/k8sexec/internal/apierror"
+	authorizationV1 "k8s.io/api/authorization/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CanExec reports whether the current user is allowed to execute commands in pods of the instance's namespace,
+// by performing a SelfSubjectAccessReview for the 'create' verb on the pods/exec subresource. If 'podName' is
+// not empty, the review is limited to that pod, otherwise it covers any pod in the namespace. Batch tools can use
+// it to detect missing permissions up front instead of collecting hundreds of ErrForbidden errors.
+// The returned reason is the explanation provided by the authorizer, which may be empty.
+func (k8s *K8SExec) CanExec(ctx context.Context, podName string) (allowed bool, reason string, err error) {
+	// exec streams are opened with POST requests, which the API server authorizes as 'create'
+	review := &authorizationV1.SelfSubjectAccessReview{
+		Spec: authorizationV1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationV1.ResourceAttributes{
+				Namespace:   k8s.Namespace,
+				Verb:        "create",
+				Resource:    "pods",
+				Subresource: "exec",
+				Name:        podName,
+			},
+		},
+	}
+	response, err := k8s.Clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metaV1.CreateOptions{})
+	if err != nil {
+		return false, "", apierror.Wrap(err, "reviewing exec permission in namespace %s", k8s.Namespace)
+	}
+	reason = response.Status.Reason
+	if response.Status.EvaluationError != "" && reason == "" {
+		reason = response.Status.EvaluationError
+	}
+	return response.Status.Allowed, reason, nil
+}
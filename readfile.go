@@ -0,0 +1,153 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrFileNotFound is returned by file operations when the file does not exist in the container.
+	ErrFileNotFound = errors.New("file not found")
+	// ErrFileNotReadable is returned by file operations when the file exists in the container but cannot be read,
+	// usually because of missing permissions.
+	ErrFileNotReadable = errors.New("file not readable")
+	// ErrNotDirectory is returned by directory operations when the path in the container is not a directory.
+	ErrNotDirectory = errors.New("not a directory")
+	// ErrIsDirectory is returned by file operations when the path in the container is a directory.
+	ErrIsDirectory = errors.New("is a directory")
+)
+
+// Exit codes of file scripts reporting missing and unreadable files and paths which are not directories,
+// borrowed from sysexits.h (EX_NOINPUT, EX_NOPERM and EX_DATAERR), so they are not confused with exit codes of
+// the utilities reading the files.
+const (
+	fileNotFoundExitCode    ExitCode = 66
+	fileNotReadableExitCode ExitCode = 77
+	notDirectoryExitCode    ExitCode = 65
+)
+
+// checkReadableScript is the prelude of scripts reading the file given as the first parameter. It fails with
+// fileNotFoundExitCode or fileNotReadableExitCode if the file is missing or cannot be read.
+const checkReadableScript = `[ -e "$1" ] || [ -L "$1" ] || exit 66
+[ -r "$1" ] || exit 77
+`
+
+// readFileScript writes the content of the file given as the first parameter to standard output.
+const readFileScript = checkReadableScript + `exec cat -- "$1"`
+
+// ReadFileTo streams the content of the file 'path' in a container, identified by the container's name and
+// the associated pod's name, to 'w' and returns the number of bytes written. The content is not buffered, and
+// no timeout other than the deadline of the provided context applies, so files of hundreds of megabytes can be
+// read reliably. Missing and unreadable files are reported with errors wrapping ErrFileNotFound and
+// ErrFileNotReadable. The read is governed by the provided context.
+func (k8s *K8SExec) ReadFileTo(ctx context.Context, podName string, containerName string, path string, w io.Writer) (int64, error) {
+	var written streamCounter
+	var stderr bytes.Buffer
+	cmd := []string{"sh", "-c", readFileScript, "sh", path}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &countingWriter{writer: w, counter: &written}, &stderr)
+	return written.bytes(), fileError("reading", podName, containerName, path, retCode, err, &stderr)
+}
+
+// pythonReaderScript writes the content of the file given as the first argument to standard output, exiting
+// with fileNotFoundExitCode or fileNotReadableExitCode if it is missing or cannot be read. It runs with both
+// Python 3 and Python 2.
+const pythonReaderScript = `import errno, sys
+try:
+    f = open(sys.argv[1], "rb")
+except (IOError, OSError) as e:
+    sys.exit({errno.ENOENT: 66, errno.EACCES: 77, errno.EPERM: 77}.get(e.errno, 1))
+out = getattr(sys.stdout, "buffer", sys.stdout)
+while True:
+    chunk = f.read(65536)
+    if not chunk:
+        break
+    out.write(chunk)
+`
+
+// perlReaderScript is the Perl counterpart of pythonReaderScript.
+const perlReaderScript = `open(my $f, "<", $ARGV[0]) or exit($!{ENOENT} ? 66 : ($!{EACCES} || $!{EPERM}) ? 77 : 1);
+binmode $f; binmode STDOUT;
+print $_ while read($f, $_, 65536);`
+
+// fileReaders are the commands ReadFile tries in turn, each reading the file given as the argument appended to
+// the command. The shell loop reads text files only: it adds a missing trailing newline and stops at NUL bytes in
+// some shells. The interpreters, the last resort, are run directly, so they work in images shipping an
+// interpreter but no shell.
+var fileReaders = []struct {
+	name string
+	cmd  []string
+}{
+	{name: "cat", cmd: []string{"sh", "-c", checkReadableScript + `exec cat -- "$1"`, "sh"}},
+	{name: "sed", cmd: []string{"sh", "-c", checkReadableScript + `exec sed -n p "$1"`, "sh"}},
+	{name: "tail", cmd: []string{"sh", "-c", checkReadableScript + `exec tail -c +1 "$1"`, "sh"}},
+	{name: "shell loop", cmd: []string{"sh", "-c", checkReadableScript + `while IFS= read -r l || [ -n "$l" ]; do printf '%s\n' "$l"; done < "$1"`, "sh"}},
+	{name: "python3", cmd: []string{"python3", "-c", pythonReaderScript}},
+	{name: "python", cmd: []string{"python", "-c", pythonReaderScript}},
+	{name: "perl", cmd: []string{"perl", "-e", perlReaderScript}},
+}
+
+// ReadFile reads the file 'path' in a container, identified by the container's name and the associated pod's
+// name, and returns its content along with the ExecutionStatus of the command which read it, whose Command
+// tells which reader was used. The readers, 'cat', 'sed', 'tail', a shell loop and, in containers without
+// a shell, 'python3', 'python' or 'perl' one-liners, are tried in turn until one succeeds, so files can be read in
+// minimal containers lacking some of the utilities. If all of them fail, the returned error joins the failures
+// of every attempt with their exit codes. Missing and unreadable files are reported with errors wrapping
+// ErrFileNotFound and ErrFileNotReadable without trying further readers, as are failures of the execution
+// itself, e.g. a missing pod. The content is held in memory; ReadFileTo should be used for large files.
+// Files in pods running on Windows nodes, according to the OS label of the node, or read by instances in Windows
+// mode are read with PowerShell's Get-Content instead. The read is governed by the provided context.
+func (k8s *K8SExec) ReadFile(ctx context.Context, podName string, containerName string, path string) ([]byte, *ExecutionStatus, error) {
+	if k8s.targetsWindows(ctx, podName) {
+		return k8s.readFileWindows(ctx, podName, containerName, path)
+	}
+
+	var errs []error
+	for _, reader := range fileReaders {
+		cmd := append(slices.Clip(reader.cmd), path)
+		status := k8s.ExecWithOptions(ctx, podName, containerName, cmd, WithRawOutput())
+		if status.RetCode == Success {
+			if len(errs) > 0 {
+				k8s.logger().Debug("file read with fallback reader", "pod", podName, "container", containerName,
+					"path", path, "reader", reader.name, "failures", len(errs))
+			}
+			return status.StdoutRaw, status, nil
+		}
+
+		stderr := bytes.NewBuffer(status.StderrRaw)
+		switch {
+		case status.RetCode == fileNotFoundExitCode || status.RetCode == fileNotReadableExitCode:
+			return nil, status, fileError("reading", podName, containerName, path, status.RetCode, status.Err, stderr)
+		case status.RetCode < Success:
+			return nil, status, copyError("reading", podName, containerName, path, status.RetCode, status.Err, stderr)
+		}
+		// a missing shell or interpreter is reported by the container runtime rather than on standard error
+		message := strings.TrimSpace(stderr.String())
+		if message == "" && status.Err != nil {
+			message = status.Err.Error()
+		}
+		errs = append(errs, fmt.Errorf("%s: exit code %d (%s): %s", reader.name, status.RetCode, status.ExitDescription(), message))
+	}
+	return nil, nil, fmt.Errorf("reading %s/%s:%s: all readers failed: %w", podName, containerName, path, errors.Join(errs...))
+}
+
+// fileError returns the error of a failed file operation, or nil if it succeeded. Missing and unreadable files
+// reported by checkReadableScript are mapped to ErrFileNotFound and ErrFileNotReadable, and paths reported as
+// not being directories to ErrNotDirectory.
+func fileError(operation string, podName string, containerName string, path string, retCode ExitCode, err error, stderr *bytes.Buffer) error {
+	switch {
+	case retCode == fileNotFoundExitCode:
+		return fmt.Errorf("%s %s/%s:%s: %w", operation, podName, containerName, path, ErrFileNotFound)
+	case retCode == fileNotReadableExitCode:
+		return fmt.Errorf("%s %s/%s:%s: %w", operation, podName, containerName, path, ErrFileNotReadable)
+	case retCode == notDirectoryExitCode:
+		return fmt.Errorf("%s %s/%s:%s: %w", operation, podName, containerName, path, ErrNotDirectory)
+	case retCode == CommandNotFound || retCode == CommandCannotExecute:
+		return fmt.Errorf("%s %s/%s:%s: %w: %s", operation, podName, containerName, path, ErrNoShell, strings.TrimSpace(stderr.String()))
+	}
+	return copyError(operation, podName, containerName, path, retCode, err, stderr)
+}
@@ -0,0 +1,124 @@
+package k8sexec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// readFilesScript writes the contents of the files given as the parameters to standard output, framed so they
+// can be told apart: every file is preceded by a line "ok <length> <index>" and followed by an empty line and
+// a line "end <index>", or replaced by a line "err <exit code> <index>" if it cannot be read, the exit codes
+// being those of checkReadableScript. Contents are cut to the announced length with 'head' if the container
+// provides it, so files growing while being read do not break the framing.
+const readFilesScript = `command -v wc >/dev/null 2>&1 || { echo "no wc available" >&2; exit 127; }
+reader=cat
+command -v head >/dev/null 2>&1 && reader=head
+i=0
+for f in "$@"; do
+	if [ ! -e "$f" ] && [ ! -L "$f" ]; then
+		echo "err 66 $i"
+	elif [ -d "$f" ]; then
+		echo "err 1 $i"
+	elif [ ! -r "$f" ]; then
+		echo "err 77 $i"
+	elif n=$(wc -c < "$f" 2>/dev/null); then
+		echo "ok $((n)) $i"
+		if [ $reader = head ]; then head -c "$n" < "$f"; else cat < "$f"; fi
+		printf '\nend %s\n' $i
+	else
+		echo "err 77 $i"
+	fi
+	i=$((i + 1))
+done`
+
+// FileReadResult is the result of reading a single file with ReadFiles.
+type FileReadResult struct {
+	Content []byte
+	// Err is the error the file could not be read with, e.g. wrapping ErrFileNotFound or ErrFileNotReadable.
+	Err error
+}
+
+// ReadFiles reads the files 'paths' in a container, identified by the container's name and the associated pod's
+// name, with a single execution, sparing the stream setup per file of repeated ReadFile calls, e.g. when reading
+// dozens of small configuration files. It returns a result per path: the content of the file, or the error it
+// could not be read with; missing and unreadable files and directories are reported with errors wrapping
+// ErrFileNotFound, ErrFileNotReadable and ErrIsDirectory. An error is returned only if the execution itself
+// fails. All contents are held in memory. It requires 'wc' in the container. The read is governed by
+// the provided context.
+func (k8s *K8SExec) ReadFiles(ctx context.Context, podName string, containerName string, paths []string) (map[string]FileReadResult, error) {
+	results := make(map[string]FileReadResult, len(paths))
+	if len(paths) == 0 {
+		return results, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := append([]string{"sh", "-c", readFilesScript, "sh"}, paths...)
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	target := strings.Join(paths, ",")
+	if retCode == CommandNotFound && strings.Contains(stderr.String(), "no wc available") {
+		return nil, fmt.Errorf("reading %s/%s:%s: %w: wc", podName, containerName, target, ErrUtilNotFound)
+	}
+	if err := copyError("reading", podName, containerName, target, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(&stdout)
+	for i, path := range paths {
+		content, retCode, err := readFrame(reader, i)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s/%s:%s: %w", podName, containerName, path, err)
+		}
+		switch retCode {
+		case Success:
+			results[path] = FileReadResult{Content: content}
+		case fileNotFoundExitCode:
+			results[path] = FileReadResult{Err: fmt.Errorf("reading %s/%s:%s: %w", podName, containerName, path, ErrFileNotFound)}
+		case fileNotReadableExitCode:
+			results[path] = FileReadResult{Err: fmt.Errorf("reading %s/%s:%s: %w", podName, containerName, path, ErrFileNotReadable)}
+		default:
+			results[path] = FileReadResult{Err: fmt.Errorf("reading %s/%s:%s: %w", podName, containerName, path, ErrIsDirectory)}
+		}
+	}
+	return results, nil
+}
+
+// readFrame reads the frame of the file with the index 'index' from the output of readFilesScript.
+func readFrame(reader *bufio.Reader, index int) ([]byte, ExitCode, error) {
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, InternalAppError, fmt.Errorf("reading frame header: %w", err)
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 3 || fields[2] != strconv.Itoa(index) {
+		return nil, InternalAppError, fmt.Errorf("unexpected frame header %q", header)
+	}
+	value, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return nil, InternalAppError, fmt.Errorf("unexpected frame header %q", header)
+	}
+	switch fields[0] {
+	case "err":
+		return nil, ExitCode(value), nil
+	case "ok":
+	default:
+		return nil, InternalAppError, fmt.Errorf("unexpected frame header %q", header)
+	}
+
+	content := make([]byte, value)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return nil, InternalAppError, fmt.Errorf("reading frame: %w", err)
+	}
+	// a file shrinking or growing while being read shifts the trailer
+	trailer := fmt.Sprintf("\nend %d\n", index)
+	actual := make([]byte, len(trailer))
+	if _, err := io.ReadFull(reader, actual); err != nil || string(actual) != trailer {
+		return nil, InternalAppError, errors.New("file changed while being read")
+	}
+	return content, Success, nil
+}
@@ -0,0 +1,35 @@
+package k8sexec
+
+import (
+	"strconv"
+	"time"
+)
+
+// remoteTimeoutExitCode is the exit code of GNU and busybox timeout utilities when the command timed out.
+const remoteTimeoutExitCode ExitCode = 124
+
+// remoteTimeoutScript runs the command with the timeout utility available in the container. Busybox releases
+// older than 1.30 take the duration with the -t flag. Commands are run directly when there is no timeout utility.
+const remoteTimeoutScript = `s=$1; shift
+if command -v timeout >/dev/null 2>&1; then
+  if timeout 1 true >/dev/null 2>&1; then exec timeout "$s" "$@"; fi
+  exec timeout -t "$s" "$@"
+fi
+exec "$@"`
+
+// WithRemoteTimeout enforces a timeout inside the container by wrapping the command with the GNU or busybox
+// timeout utility, when it is available. Unlike WithTimeout, which only interrupts the stream on the client side,
+// the command is actually terminated once the timeout expires rather than lingering in the container.
+// Commands terminated this way are reported with the ExecutionTimeOut exit code and an error wrapping
+// ErrExecTimeout. The timeout is rounded up to whole seconds and requires a shell in the container.
+func WithRemoteTimeout(timeout time.Duration) ExecOption {
+	return func(config *execConfig) {
+		config.remoteTimeout = timeout
+	}
+}
+
+// remoteTimeoutCommand wraps the command to be terminated by the timeout utility after 'timeout'.
+func remoteTimeoutCommand(timeout time.Duration, cmd []string) []string {
+	seconds := int64((timeout + time.Second - 1) / time.Second)
+	return append([]string{"sh", "-c", remoteTimeoutScript, "sh", strconv.FormatInt(max(seconds, 1), 10)}, cmd...)
+}
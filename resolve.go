@@ -0,0 +1,130 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSymlinkLoop is returned by ResolvePath when resolving a path follows too many symbolic links, usually
+// because they form a loop.
+var ErrSymlinkLoop = errors.New("too many levels of symbolic links")
+
+// symlinkLoopExitCode is the exit code of resolvePathScript reporting too many symbolic links, borrowed from
+// ELOOP.
+const symlinkLoopExitCode ExitCode = 40
+
+// resolvePathScript resolves the path given as the first parameter component by component, the way the kernel
+// does, printing a "link", link path and target record for every symbolic link followed and a "path" record
+// with the resolved path, all fields NUL-terminated. If the container provides 'readlink -f', its result is
+// printed first as a "canonical" record. Targets of links are read with 'readlink', or parsed from 'ls -ld'
+// output in containers lacking it. Only the last component may be missing; missing directories are reported
+// with exit code 66, other files used as directories with 65 and more than 40 links, the limit of Linux, with 40.
+const resolvePathScript = `c=$(readlink -f -- "$1" 2>/dev/null) && [ -n "$c" ] && printf 'canonical\0%s\0' "$c"
+case $1 in /*) rest=$1 ;; *) rest=$(pwd)/$1 ;; esac
+done= hops=0
+while :; do
+	while [ "${rest#/}" != "$rest" ]; do rest=${rest#/}; done
+	[ -n "$rest" ] || break
+	c=${rest%%/*}
+	case $rest in */*) rest=${rest#*/} ;; *) rest= ;; esac
+	case $c in
+	.) continue ;;
+	..) done=${done%/*}; continue ;;
+	esac
+	n=$done/$c
+	if [ -L "$n" ]; then
+		hops=$((hops + 1))
+		[ $hops -le 40 ] || exit 40
+		t=$(readlink -- "$n" 2>/dev/null) || { l=$(ls -ld -- "$n") && t=${l#* -> }; } || exit 1
+		printf 'link\0%s\0%s\0' "$n" "$t"
+		case $t in /*) done= ;; esac
+		rest=$t/$rest
+	elif [ -z "$rest" ]; then
+		done=$n
+	elif [ -d "$n" ]; then
+		done=$n
+	elif [ -e "$n" ]; then
+		exit 65
+	else
+		exit 66
+	fi
+done
+printf 'path\0%s\0' "${done:-/}"`
+
+// SymlinkHop is a symbolic link followed while resolving a path.
+type SymlinkHop struct {
+	// Link is the path of the symbolic link, with the links preceding it resolved.
+	Link string `json:"link"`
+	// Target is the target of the link as stored in it, which may be relative to the link's directory.
+	Target string `json:"target"`
+}
+
+// ResolvedPath is a path resolved by ResolvePath.
+type ResolvedPath struct {
+	// Path is the path which was resolved.
+	Path string `json:"path"`
+	// Canonical is the absolute path 'Path' refers to, free of symbolic links and "." and ".." components.
+	Canonical string `json:"canonical"`
+	// Chain lists the symbolic links followed to resolve the path, in the order they were followed. It is empty
+	// if the path involves no symbolic links.
+	Chain []SymlinkHop `json:"chain,omitempty"`
+}
+
+// ResolvePath follows the symbolic links in the path 'path' in a container, identified by the container's name
+// and the associated pod's name, and returns the canonical path it refers to along with the chain of links
+// followed, including links in its directories. Checks of paths against sensitive files, e.g. /etc/passwd,
+// should be applied to the canonical path, so they cannot be dodged with symbolic links. The canonical path is
+// taken from 'readlink -f' if the container provides it and from resolving the path component by component
+// otherwise; the chain is always collected component by component. Relative paths are resolved against
+// the working directory of the container. The last component of the path may be missing, e.g. a dangling
+// link's target, but missing directories are reported with errors wrapping ErrFileNotFound, files used as
+// directories with errors wrapping ErrNotDirectory and loops of links with errors wrapping ErrSymlinkLoop.
+// The call is governed by the provided context.
+func (k8s *K8SExec) ResolvePath(ctx context.Context, podName string, containerName string, path string) (*ResolvedPath, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"sh", "-c", resolvePathScript, "sh", path}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if retCode == symlinkLoopExitCode {
+		return nil, fmt.Errorf("resolving %s/%s:%s: %w", podName, containerName, path, ErrSymlinkLoop)
+	}
+	if err := fileError("resolving", podName, containerName, path, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+
+	resolved, err := parseResolvedPath(path, stdout.String())
+	if err != nil {
+		return nil, fmt.Errorf("resolving %s/%s:%s: %w", podName, containerName, path, err)
+	}
+	return resolved, nil
+}
+
+// parseResolvedPath parses the records printed by resolvePathScript.
+func parseResolvedPath(path string, output string) (*ResolvedPath, error) {
+	resolved := &ResolvedPath{Path: path}
+	var canonical string
+	fields := strings.Split(output, "\x00")
+	// the output ends with a terminator, leaving an empty trailing field
+	for fields = fields[:len(fields)-1]; len(fields) > 0; {
+		switch {
+		case fields[0] == "canonical" && len(fields) >= 2:
+			canonical, fields = fields[1], fields[2:]
+		case fields[0] == "link" && len(fields) >= 3:
+			resolved.Chain = append(resolved.Chain, SymlinkHop{Link: fields[1], Target: fields[2]})
+			fields = fields[3:]
+		case fields[0] == "path" && len(fields) >= 2:
+			resolved.Canonical, fields = fields[1], fields[2:]
+		default:
+			return nil, fmt.Errorf("unexpected output %q", output)
+		}
+	}
+	if resolved.Canonical == "" {
+		return nil, fmt.Errorf("unexpected output %q", output)
+	}
+	if canonical != "" {
+		resolved.Canonical = canonical
+	}
+	return resolved, nil
+}
@@ -0,0 +1,133 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchTools "k8s.io/client-go/tools/watch"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WithRestartRecovery makes the instance re-execute commands interrupted because their container restarted,
+// e.g. during a rolling restart or after an OOM kill, up to 'maxRestarts' times per execution. When an
+// execution fails with an error indicating that the container is missing, restarting or that the stream was
+// closed, the instance waits, bounded by the execution's context, for the container to be running again and
+// executes the command anew. Closed streams are only recovered from if the container actually restarted
+// after the execution started, so commands are never re-executed in a container that is still running them.
+// Re-executions require that standard input can be replayed (it implements io.Seeker) and that output already
+// received can be discarded, which is the case for executions collecting output in an ExecutionStatus.
+// The number of restarts recovered from is recorded in ExecutionStatus.Restarts.
+func WithRestartRecovery(maxRestarts int) Option {
+	return func(k8s *K8SExec) {
+		k8s.restartRecovery = maxRestarts
+	}
+}
+
+// execTrace records details of a single execution which are reported in its ExecutionStatus.
+type execTrace struct {
+	restarts int
+}
+
+// isContainerRestart reports whether the error may have been caused by the container restarting.
+func isContainerRestart(err error) bool {
+	message := strings.ToLower(err.Error())
+	return errors.Is(err, ErrContainerNotFound) ||
+		errors.Is(err, ErrContainerNotRunning) ||
+		errors.Is(err, ErrStreamClosed) ||
+		strings.Contains(message, "container restarting") ||
+		strings.Contains(message, "container not running")
+}
+
+// waitForContainerRestart waits until the container is running again after having been restarted since
+// 'since', and reports whether it is. It gives up if the pod is deleted or if the container is still running
+// without having restarted, in which case the error was not caused by a restart.
+func (k8s *K8SExec) waitForContainerRestart(ctx context.Context, podName string, containerName string, since time.Time, cause error) bool {
+	k8s.logger().Info("waiting for restarted container", "pod", podName, "container", containerName, "error", cause)
+
+	// container start times have a resolution of seconds
+	since = since.Truncate(time.Second)
+	errNotRestarted := errors.New("container has not restarted")
+	restarted := func(pod *coreV1.Pod) (bool, error) {
+		if !slices.ContainsFunc(ContainersOf(pod), func(container ContainerInfo) bool { return container.Name == containerName }) {
+			return false, fmt.Errorf("%s/%s: %w", podName, containerName, ErrContainerNotFound)
+		}
+		for _, status := range append(pod.Status.ContainerStatuses, pod.Status.InitContainerStatuses...) {
+			if status.Name != containerName {
+				continue
+			}
+			running := status.State.Running
+			switch {
+			case running == nil:
+				return false, nil
+			case running.StartedAt.Time.Before(since):
+				return false, errNotRestarted
+			default:
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	lw := cache.NewListWatchFromClient(k8s.Clientset.CoreV1().RESTClient(), "pods", k8s.Namespace,
+		fields.OneTermEqualSelector("metadata.name", podName))
+	precondition := func(store cache.Store) (bool, error) {
+		obj, exists, err := store.GetByKey(k8s.Namespace + "/" + podName)
+		if err != nil || !exists {
+			return false, fmt.Errorf("%s: %w", podName, ErrPodNotFound)
+		}
+		return restarted(obj.(*coreV1.Pod))
+	}
+	condition := func(event watch.Event) (bool, error) {
+		switch event.Type {
+		case watch.Deleted:
+			return false, fmt.Errorf("%s: %w", podName, ErrPodNotFound)
+		case watch.Added, watch.Modified:
+			return restarted(event.Object.(*coreV1.Pod))
+		}
+		return false, nil
+	}
+
+	if _, err := watchTools.UntilWithSync(ctx, lw, &coreV1.Pod{}, precondition, condition); err != nil {
+		k8s.logger().Debug("not re-executing command", "pod", podName, "container", containerName, "reason", err)
+		return false
+	}
+	k8s.logger().Warn("re-executing command in restarted container", "pod", podName, "container", containerName)
+	return true
+}
+
+// isSeekable reports whether the reader can be rewound to replay it.
+func isSeekable(reader io.Reader) bool {
+	_, ok := reader.(io.Seeker)
+	return ok
+}
+
+// resetter is implemented by output writers whose content can be discarded, e.g. bytes.Buffer and hash.Hash.
+type resetter interface {
+	Reset()
+}
+
+// resettable reports whether all non-nil writers can be reset.
+func resettable(writers []io.Writer) bool {
+	for _, writer := range writers {
+		if _, ok := writer.(resetter); writer != nil && !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// resetOutputs discards the content of all resettable writers.
+func resetOutputs(writers []io.Writer) {
+	for _, writer := range writers {
+		if writer, ok := writer.(resetter); ok {
+			writer.Reset()
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy defines how many times and how often a command execution is retried when it fails because
+// of a transport problem (e.g. an SPDY dial error, API server throttling or an API server hiccup), i.e. when
+// the execution ends with InternalAppError before any data was streamed from the container. Commands that
+// were started in the container and returned an exit code are never retried by the default classifier.
+//
+// Delays grow exponentially when Multiplier is set, e.g. a policy retrying large audit sweeps over flaky
+// clusters could be:
+//
+//	k8sexec.RetryPolicy{MaxAttempts: 5, Backoff: 500 * time.Millisecond, Multiplier: 2, MaxBackoff: 10 * time.Second, Jitter: 0.2}
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one. Values lower than 2 disable retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry.
+	Backoff time.Duration
+	// Multiplier is the factor the delay grows by with every retry. Values lower than or equal to 1 keep
+	// the delay constant.
+	Multiplier float64
+	// MaxBackoff caps the delay between attempts. Zero means no cap.
+	MaxBackoff time.Duration
+	// Jitter randomizes delays by up to the given fraction (0 to 1) in both directions, so retries of many
+	// concurrent executions do not hit the API server at the same time.
+	Jitter float64
+	// Retryable decides whether a failed attempt is retried, given its exit code and error.
+	// DefaultRetryable is used when it is nil.
+	Retryable func(retCode ExitCode, err error) bool
+}
+
+// DefaultRetryable is the default classifier of retryable failures: executions which could not be performed
+// (InternalAppError), e.g. because the exec stream could not be established or the API server throttled
+// the request, are retried, unless they were cancelled or timed out.
+func DefaultRetryable(retCode ExitCode, err error) bool {
+	return retCode == InternalAppError && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// retryable reports whether a failed attempt should be retried.
+func (policy RetryPolicy) retryable(retCode ExitCode, err error) bool {
+	if retCode == Success {
+		return false
+	}
+	if policy.Retryable != nil {
+		return policy.Retryable(retCode, err)
+	}
+	return DefaultRetryable(retCode, err)
+}
+
+// delay returns the delay before the attempt following attempt number 'attempt' (starting from 1).
+func (policy RetryPolicy) delay(attempt int) time.Duration {
+	delay := float64(policy.Backoff)
+	if policy.Multiplier > 1 {
+		delay *= math.Pow(policy.Multiplier, float64(attempt-1))
+	}
+	if policy.MaxBackoff > 0 {
+		delay = math.Min(delay, float64(policy.MaxBackoff))
+	}
+	if policy.Jitter > 0 {
+		jitter := math.Min(policy.Jitter, 1)
+		delay *= 1 + jitter*(2*rand.Float64()-1)
+	}
+	// delays growing without a cap would overflow, and turn negative, when converted
+	if delay >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(delay)
+}
+
+// newRewinder returns a function rewinding 'stdin' to its current position, so its content can be replayed
+// by a retried execution. The function reports false if the reader cannot be rewound.
+func newRewinder(stdin io.Reader) func() bool {
+	seeker, ok := stdin.(io.Seeker)
+	if !ok {
+		return func() bool { return false }
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return func() bool { return false }
+	}
+	return func() bool {
+		_, err := seeker.Seek(start, io.SeekStart)
+		return err == nil
+	}
+}
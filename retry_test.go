@@ -0,0 +1,62 @@
+package k8sexec
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{name: "first retry", policy: RetryPolicy{Backoff: time.Second, Multiplier: 2}, attempt: 1, want: time.Second},
+		{name: "exponential growth", policy: RetryPolicy{Backoff: time.Second, Multiplier: 2}, attempt: 4, want: 8 * time.Second},
+		{name: "fractional multiplier", policy: RetryPolicy{Backoff: 100 * time.Millisecond, Multiplier: 1.5}, attempt: 3, want: 225 * time.Millisecond},
+		{name: "constant without multiplier", policy: RetryPolicy{Backoff: time.Second}, attempt: 5, want: time.Second},
+		{name: "constant with multiplier of one", policy: RetryPolicy{Backoff: time.Second, Multiplier: 1}, attempt: 5, want: time.Second},
+		{name: "capped", policy: RetryPolicy{Backoff: time.Second, Multiplier: 2, MaxBackoff: 5 * time.Second}, attempt: 10, want: 5 * time.Second},
+		{name: "cap above the delay", policy: RetryPolicy{Backoff: time.Second, Multiplier: 2, MaxBackoff: time.Minute}, attempt: 2, want: 2 * time.Second},
+		{name: "overflowing growth with a cap", policy: RetryPolicy{Backoff: time.Second, Multiplier: 10, MaxBackoff: time.Minute}, attempt: 1000, want: time.Minute},
+		{name: "overflowing growth without a cap", policy: RetryPolicy{Backoff: time.Second, Multiplier: 10}, attempt: 1000, want: time.Duration(1<<63 - 1)},
+		{name: "no backoff", policy: RetryPolicy{Multiplier: 2}, attempt: 3, want: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if delay := test.policy.delay(test.attempt); delay != test.want {
+				t.Errorf("delay(%d) = %v, want %v", test.attempt, delay, test.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDelayJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		min, max time.Duration
+	}{
+		{name: "partial jitter", policy: RetryPolicy{Backoff: time.Second, Jitter: 0.2}, min: 800 * time.Millisecond, max: 1200 * time.Millisecond},
+		{name: "jitter above one is clamped", policy: RetryPolicy{Backoff: time.Second, Jitter: 5}, min: 0, max: 2 * time.Second},
+		{name: "jitter applied after the cap", policy: RetryPolicy{Backoff: time.Second, Multiplier: 2, MaxBackoff: 4 * time.Second, Jitter: 0.5}, min: 2 * time.Second, max: 6 * time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			distinct := make(map[time.Duration]bool)
+			for i := 0; i < 200; i++ {
+				delay := test.policy.delay(5)
+				if delay < test.min || delay > test.max {
+					t.Fatalf("delay %v outside of [%v, %v]", delay, test.min, test.max)
+				}
+				distinct[delay] = true
+			}
+			if len(distinct) < 2 {
+				t.Error("jitter does not randomize delays")
+			}
+		})
+	}
+}
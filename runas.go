@@ -0,0 +1,54 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNoRunAsMechanism is returned when a command should be executed as another user, but the container provides
+// none of the utilities allowing to switch users.
+var ErrNoRunAsMechanism = errors.New("no utility to switch users available in the container")
+
+// runAsMechanisms lists utilities switching users in the order of preference. runuser and setpriv do not
+// involve PAM or passwords and work for root, which exec sessions usually run as; sudo and su are fallbacks.
+var runAsMechanisms = []string{"runuser", "setpriv", "sudo", "su"}
+
+// WithUser executes the command as another user, given by name or numeric ID. The utility used to switch
+// users (runuser, setpriv, sudo or su) is detected automatically in the container; if none is available
+// the execution fails with ErrNoRunAsMechanism. Switching users generally requires the exec session to run
+// as root, or sudo configured not to ask for a password.
+func WithUser(user string) ExecOption {
+	return func(config *execConfig) {
+		config.user = user
+	}
+}
+
+// runAsCommand detects the utility allowing to switch users in the container and returns the command wrapped
+// to be executed as 'user'.
+func (k8s *K8SExec) runAsCommand(ctx context.Context, podName string, containerName string, user string, cmd []string) ([]string, error) {
+	available, err := k8s.CheckUtilsInContainerWithContext(ctx, podName, containerName, runAsMechanisms)
+	if err != nil {
+		return nil, fmt.Errorf("detecting how to run as %s: %w", user, err)
+	}
+
+	for _, mechanism := range runAsMechanisms {
+		if !available[mechanism] {
+			continue
+		}
+		k8s.logger().Debug("switching user", "pod", podName, "container", containerName, "user", user, "mechanism", mechanism)
+		switch mechanism {
+		case "runuser":
+			return append([]string{"runuser", "-u", user, "--"}, cmd...), nil
+		case "setpriv":
+			// setpriv needs the group to be given explicitly; it is resolved in the container
+			script := `u=$1; shift; exec setpriv --reuid="$u" --regid="$(id -g "$u")" --init-groups -- "$@"`
+			return append([]string{"sh", "-c", script, "sh", user}, cmd...), nil
+		case "sudo":
+			return append([]string{"sudo", "-n", "-u", user, "--"}, cmd...), nil
+		case "su":
+			return []string{"su", "-s", "/bin/sh", user, "-c", shellJoin(cmd)}, nil
+		}
+	}
+	return nil, fmt.Errorf("running as %s in %s/%s: %w", user, podName, containerName, ErrNoRunAsMechanism)
+}
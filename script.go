@@ -0,0 +1,100 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// shellCandidates are the shells tried by DetectShell, in the order of preference.
+var shellCandidates = [][]string{
+	{"bash"},
+	{"sh"},
+	{"ash"},
+	{"busybox", "sh"},
+}
+
+// shellCache remembers shells detected in containers, so that scripts executed repeatedly in the same container
+// do not pay for the detection every time.
+type shellCache struct {
+	shells sync.Map
+}
+
+// DetectShell detects the shell available in a container, identified by the container's name and the associated
+// pod's name, trying bash, sh, ash and busybox's sh in this order. It returns the command starting the shell,
+// e.g. []string{"busybox", "sh"}, or an error wrapping ErrNoShell if the container has no shell. Executions
+// failing regardless of the shell, e.g. because the pod does not exist or 'ctx' is done, stop the detection and
+// their error is returned instead. Detected shells are cached per container by instances created with
+// NewK8SExec. In Windows mode powershell and pwsh are tried instead.
+func (k8s *K8SExec) DetectShell(ctx context.Context, podName string, containerName string) ([]string, error) {
+	key := k8s.Namespace + "/" + podName + "/" + containerName
+	if k8s.shells != nil {
+		if shell, ok := k8s.shells.shells.Load(key); ok {
+			return shell.([]string), nil
+		}
+	}
+
+	candidates, probe := shellCandidates, []string{"-c", "exit 0"}
+	if k8s.windows {
+		candidates, probe = windowsShellCandidates, []string{"-Command", "exit 0"}
+	}
+	for _, shell := range candidates {
+		// the API server rejects executions requesting no streams, so the probe's output is requested and dropped
+		retCode, err := k8s.exec(ctx, podName, containerName, slices.Concat(shell, probe), nil, io.Discard, nil, false)
+		// a missing shell makes the probe exit with a non-zero code; a failed execution, e.g. a missing pod,
+		// a denied request or an expired context, would fail for the other candidates as well
+		if retCode == InternalAppError {
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			return nil, fmt.Errorf("detecting shell in %s/%s: %w", podName, containerName, err)
+		}
+		if retCode == Success {
+			if k8s.shells != nil {
+				k8s.shells.shells.Store(key, shell)
+			}
+			return shell, nil
+		}
+	}
+	return nil, fmt.Errorf("%s/%s: %w", podName, containerName, ErrNoShell)
+}
+
+// ExecScript executes a shell script in a container, identified by the container's name and the associated pod's
+// name. The available shell is detected automatically (see DetectShell) and the script is delivered via standard
+// input, so it needs no quoting. This function returns a pointer to an instance of ExecutionStatus, which
+// encapsulates the results of the script's execution. The execution is bounded by the instance's default
+// exec timeout.
+func (k8s *K8SExec) ExecScript(podName string, containerName string, script string) *ExecutionStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), k8s.ExecTimeout(0))
+	defer cancel()
+
+	return k8s.ExecScriptWithContext(ctx, podName, containerName, script)
+}
+
+// ExecScriptWithContext executes a shell script in a container like ExecScript. The use of this function must
+// provide a context that will govern the script's execution, including the shell detection.
+func (k8s *K8SExec) ExecScriptWithContext(ctx context.Context, podName string, containerName string, script string) *ExecutionStatus {
+	start := time.Now()
+	candidates, stdinArgs := shellCandidates, []string{"-s"}
+	if k8s.windows {
+		candidates, stdinArgs = windowsShellCandidates, []string{"-Command", "-"}
+	}
+	shell, err := k8s.DetectShell(ctx, podName, containerName)
+	if err != nil {
+		var retCode ExitCode = CommandNotFound
+		if !errors.Is(err, ErrNoShell) {
+			retCode = ContextExitCode(ctx.Err())
+		}
+		status := NewExecutionStatus(podName, containerName, retCode, err.Error(), "", "")
+		status.Err = err
+		// no shell was detected, so the command of the preferred one is recorded
+		status.recordExecution(k8s.Namespace, slices.Concat(candidates[0], stdinArgs), start)
+		return status
+	}
+	return k8s.ExecWithOptions(ctx, podName, containerName, slices.Concat(shell, stdinArgs), WithStdin(strings.NewReader(script)))
+}
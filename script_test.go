@@ -0,0 +1,81 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	exec2 "k8s.io/client-go/util/exec"
+	"sync/atomic"
+	"testing"
+)
+
+// failingBackend fails every execution with 'err'.
+type failingBackend struct {
+	err     error
+	streams atomic.Int64
+}
+
+func (backend *failingBackend) Stream(ctx context.Context, k8s *K8SExec, request ExecRequest) error {
+	backend.streams.Add(1)
+	return backend.err
+}
+
+func TestExecScriptDetectionFailures(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		err      error
+		retCode  ExitCode
+		streams  int64
+		noShell  bool
+		sentinel error
+	}{
+		{
+			name:    "no shell",
+			ctx:     context.Background(),
+			err:     exec2.CodeExitError{Err: errors.New("command terminated with exit code 127"), Code: 127},
+			retCode: CommandNotFound,
+			streams: int64(len(shellCandidates)),
+			noShell: true,
+		},
+		{
+			name:     "failed execution",
+			ctx:      context.Background(),
+			err:      errors.New(`pods "web" not found`),
+			retCode:  InternalAppError,
+			streams:  1,
+			sentinel: ErrPodNotFound,
+		},
+		{
+			name:     "cancelled context",
+			ctx:      cancelled,
+			err:      context.Canceled,
+			retCode:  ExecutionCancelled,
+			streams:  1,
+			sentinel: context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			backend := &failingBackend{err: test.err}
+			k8s := newTestK8SExec(t, backend)
+
+			status := k8s.ExecScriptWithContext(test.ctx, "web", "app", "echo hello")
+			if status.RetCode != test.retCode {
+				t.Errorf("exit code %d, want %d", status.RetCode, test.retCode)
+			}
+			if errors.Is(status.Err, ErrNoShell) != test.noShell {
+				t.Errorf("error %v, wrapping ErrNoShell: %t", status.Err, test.noShell)
+			}
+			if test.sentinel != nil && !errors.Is(status.Err, test.sentinel) {
+				t.Errorf("error %v does not wrap %v", status.Err, test.sentinel)
+			}
+			if streams := backend.streams.Load(); streams != test.streams {
+				t.Errorf("%d shells probed, want %d", streams, test.streams)
+			}
+		})
+	}
+}
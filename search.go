@@ -0,0 +1,102 @@
+package k8sexec
+
+import (
+	"context"
+	"strconv"
+)
+
+// searchFilesScript prints paths matching the pattern passed as the first positional parameter. Absolute
+// patterns (e.g. "/etc/passwd~" or "/etc/ssl/*.pem") are expanded by the shell; other patterns (e.g. "*.pem")
+// are matched against file names under the search roots passed as remaining parameters. The second parameter
+// limits the depth of the search, zero meaning no limit.
+const searchFilesScript = `p=$1; depth=$2; shift 2
+case $p in
+/*)
+  IFS=
+  for f in $p; do
+    [ -e "$f" ] && printf '%s\n' "$f"
+  done
+  unset IFS
+  ;;
+*)
+  if [ "$depth" -gt 0 ]; then
+    find "$@" -maxdepth "$depth" -name "$p" -print 2>/dev/null
+  else
+    find "$@" -name "$p" -print 2>/dev/null
+  fi
+  [ $? -eq 127 ] && exit 127
+  ;;
+esac
+exit 0`
+
+// FileSearchOptions configures SearchFiles.
+type FileSearchOptions struct {
+	// Roots are directories searched for file name patterns. The root directory is searched when it is empty.
+	// Roots are not used for absolute patterns.
+	Roots []string
+	// MaxDepth limits the depth of the search below the roots. Zero means no limit.
+	MaxDepth int
+	// Targets are containers to search. All containers of pods returned by GetUniquePods are searched
+	// when it is empty.
+	Targets []Target
+	// Batch controls concurrency, throttling and timeouts of the search.
+	Batch BatchOptions
+}
+
+// FileSearchResult holds the paths matching a search pattern in a single container.
+type FileSearchResult struct {
+	Target
+	// Paths lists the matching paths; it is empty if nothing matched.
+	Paths []string `json:"Paths,omitempty"`
+	// Status is the status of the search command, allowing to tell containers without matches from
+	// containers which could not be searched.
+	Status *ExecutionStatus `json:"Status"`
+}
+
+// Found reports whether the pattern matched anything in the container.
+func (result FileSearchResult) Found() bool {
+	return len(result.Paths) > 0
+}
+
+// SearchFiles searches for a path or a glob pattern across every unique container in the namespace in parallel.
+// Absolute patterns such as "/etc/passwd~" or "/etc/ssl/private/*.key" are checked directly, while name
+// patterns such as "*.pem" are searched for with find under options.Roots. The search runs as a batch, so
+// concurrency and throttling are controlled by options.Batch. It returns a result per searched container,
+// in the order of the targets; containers in which the search failed have no paths and a failed Status.
+func (k8s *K8SExec) SearchFiles(ctx context.Context, pattern string, options FileSearchOptions) ([]FileSearchResult, error) {
+	targets := options.Targets
+	if len(targets) == 0 {
+		_, pods, err := k8s.GetUniquePodsWithContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		targets = TargetsFromPods(pods)
+	}
+
+	roots := options.Roots
+	if len(roots) == 0 {
+		roots = []string{"/"}
+	}
+	cmd := append([]string{"sh", "-c", searchFilesScript, "sh", pattern, strconv.Itoa(options.MaxDepth)}, roots...)
+
+	statuses := k8s.BatchExec(ctx, targets, cmd, options.Batch)
+	results := make([]FileSearchResult, len(targets))
+	for i, status := range statuses {
+		results[i] = FileSearchResult{Target: targets[i], Status: status}
+		if status.RetCode == Success {
+			results[i].Paths = outputLines(status.Stdout)
+		}
+	}
+	return results, nil
+}
+
+// outputLines returns the non-empty lines of a command's output as stored in ExecutionStatus.
+func outputLines(lines []string) []string {
+	var nonEmpty []string
+	for _, line := range lines {
+		if line != "" {
+			nonEmpty = append(nonEmpty, line)
+		}
+	}
+	return nonEmpty
+}
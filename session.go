@@ -0,0 +1,166 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrSessionClosed is returned when a command is run in a Session whose shell has exited or which was closed.
+var ErrSessionClosed = errors.New("session closed")
+
+// Session is a long-lived shell running in a container, over which many commands can be run with a single exec
+// stream. Outputs and exit codes of consecutive commands are separated by unique delimiters. Audit scripts running
+// dozens of small commands per container save a round trip to the API server and the kubelet for every command.
+// Commands of a session run one at a time, share the shell's state (working directory, variables) and cannot
+// read standard input. A Session is safe for concurrent use, but concurrent Run calls are serialized.
+type Session struct {
+	k8s       *K8SExec
+	pod       string
+	container string
+	marker    string
+	cancel    context.CancelFunc
+
+	mu      sync.Mutex
+	dialog  *dialog
+	result  <-chan execResult
+	counter int
+	closed  bool
+}
+
+// OpenSession starts a shell in a container, identified by the container's name and the associated pod's name,
+// and returns a Session running commands in it. The shell is detected like in ExecScript. The session lives
+// until it is closed with Close or 'ctx' is done.
+func (k8s *K8SExec) OpenSession(ctx context.Context, podName string, containerName string) (*Session, error) {
+	shell, err := k8s.DetectShell(ctx, podName, containerName)
+	if err != nil {
+		return nil, err
+	}
+
+	random := make([]byte, 8)
+	_, _ = rand.Read(random)
+
+	ctx, cancel := context.WithCancel(ctx)
+	session := &Session{
+		k8s:       k8s,
+		pod:       podName,
+		container: containerName,
+		marker:    "__K8SEXEC_" + hex.EncodeToString(random),
+		cancel:    cancel,
+		dialog:    newDialog(),
+	}
+	session.result = session.dialog.start(ctx, k8s, podName, containerName, shell)
+	return session, nil
+}
+
+// Run runs a shell command line in the session and returns its ExecutionStatus. The returned error reports
+// failures of the session itself, e.g. when it was closed or 'ctx' was done before the command completed;
+// the session is unusable afterwards.
+func (session *Session) Run(ctx context.Context, command string) (*ExecutionStatus, error) {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.closed {
+		return nil, ErrSessionClosed
+	}
+
+	start := time.Now()
+	session.counter++
+	marker := session.marker + "_" + strconv.Itoa(session.counter)
+	// the command's standard input is redirected, so it does not consume the script sent to the shell
+	script := fmt.Sprintf("{ %s\n} </dev/null\n__rc=$?\nprintf '\\n%s:%%d\\n' \"$__rc\"\nprintf '\\n%s\\n' >&2\n", command, marker, marker)
+	if err := session.dialog.send(ctx, script, nil); err != nil {
+		session.abort()
+		return nil, fmt.Errorf("%w: %w", ErrSessionClosed, err)
+	}
+
+	stdoutEnd := []byte("\n" + marker + ":")
+	stderrEnd := []byte("\n" + marker + "\n")
+	var stdout, stderr string
+	var retCode int
+	err := session.dialog.waitUntil(ctx, func(d *dialog) bool {
+		outIndex := bytes.Index(d.stdout.Bytes(), stdoutEnd)
+		errIndex := bytes.Index(d.stderr.Bytes(), stderrEnd)
+		if outIndex < 0 || errIndex < 0 {
+			return false
+		}
+		rest := d.stdout.Bytes()[outIndex+len(stdoutEnd):]
+		newline := bytes.IndexByte(rest, '\n')
+		if newline < 0 {
+			return false
+		}
+		retCode, _ = strconv.Atoi(string(rest[:newline]))
+
+		stdout = string(d.stdout.Next(outIndex))
+		d.stdout.Next(len(stdoutEnd) + newline + 1)
+		stderr = string(d.stderr.Next(errIndex))
+		d.stderr.Next(len(stderrEnd))
+		d.combined.Reset()
+		return true
+	})
+	if err != nil {
+		session.abort()
+		return nil, err
+	}
+
+	status := NewExecutionStatus(session.pod, session.container, ExitCode(retCode), "", stdout, stderr)
+	status.recordExecution(session.k8s.Namespace, []string{command}, start)
+	return status, nil
+}
+
+// Close terminates the session's shell and releases the exec stream.
+func (session *Session) Close() error {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.closed {
+		return nil
+	}
+	session.closed = true
+	_ = session.dialog.stdinWriter.Close()
+
+	select {
+	case result := <-session.result:
+		session.cancel()
+		if result.err != nil && result.retCode == InternalAppError {
+			return result.err
+		}
+		return nil
+	case <-time.After(session.k8s.fileOpTimeout()):
+		session.cancel()
+		return nil
+	}
+}
+
+// abort closes a session which became unusable; it must be called with the mutex held.
+func (session *Session) abort() {
+	session.closed = true
+	session.cancel()
+}
+
+// waitUntil waits until 'done' reports true, the command exits or 'ctx' is done. 'done' is called with the mutex
+// held, so it can inspect and consume the collected outputs.
+func (d *dialog) waitUntil(ctx context.Context, done func(d *dialog) bool) error {
+	for {
+		d.mu.Lock()
+		if done(d) {
+			d.mu.Unlock()
+			return nil
+		}
+		finished, changed := d.finished, d.changed
+		d.mu.Unlock()
+
+		if finished {
+			return ErrSessionClosed
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
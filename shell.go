@@ -0,0 +1,31 @@
+package k8sexec
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// shellQuote quotes a single argument for POSIX shells, so it is passed to the command verbatim.
+func shellQuote(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-./=:,@%+") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// shellJoin quotes all arguments for POSIX shells and joins them into a single command line.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// tempPath returns a random path of a temporary file in the container's /tmp directory, ending with 'suffix'.
+func tempPath(suffix string) string {
+	random := make([]byte, 8)
+	_, _ = rand.Read(random)
+	return "/tmp/k8sexec-" + hex.EncodeToString(random) + suffix
+}
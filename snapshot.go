@@ -0,0 +1,133 @@
+package k8sexec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
+	"time"
+)
+
+// SnapshotSchemaVersion is the version of the NamespaceSnapshot document written by this release.
+const SnapshotSchemaVersion = 1
+
+// NamespaceSnapshot is a serializable document capturing the state of a namespace: its pods, workloads,
+// images and facts collected by executing commands in containers. It allows analysis and re-reporting
+// to happen offline, without further access to the cluster.
+type NamespaceSnapshot struct {
+	SchemaVersion int                  `json:"SchemaVersion"`
+	Namespace     string               `json:"Namespace"`
+	CapturedAt    time.Time            `json:"CapturedAt"`
+	Pods          []coreV1.Pod         `json:"Pods"`
+	Deployments   []appsV1.Deployment  `json:"Deployments"`
+	StatefulSets  []appsV1.StatefulSet `json:"StatefulSets"`
+	DaemonSets    []appsV1.DaemonSet   `json:"DaemonSets"`
+	Images        []ImageUsage         `json:"Images"`
+	Facts         []Fact               `json:"Facts,omitempty"`
+}
+
+// Fact holds results of a command executed in every container of the unique pods of a namespace.
+type Fact struct {
+	Name    string             `json:"Name"`
+	Command []string           `json:"Command"`
+	Results []*ExecutionStatus `json:"Results"`
+}
+
+// SnapshotOptions configures what is captured by Snapshot.
+type SnapshotOptions struct {
+	// Facts maps names of facts to commands collected from every container of the pods returned by
+	// GetUniquePods, e.g. {"os-release": {"cat", "/etc/os-release"}}.
+	Facts map[string][]string
+	// Batch configures the execution of fact commands.
+	Batch BatchOptions
+}
+
+// Snapshot captures the pods, workloads and images of the namespace specified by the 'k8s' context, together
+// with the facts requested in 'options', into a single serializable document. Managed fields are stripped from
+// the captured objects to keep the document compact. Both discovery and fact collection are governed by 'ctx'.
+func (k8s *K8SExec) Snapshot(ctx context.Context, options SnapshotOptions) (*NamespaceSnapshot, error) {
+	snapshot := &NamespaceSnapshot{SchemaVersion: SnapshotSchemaVersion, Namespace: k8s.Namespace, CapturedAt: time.Now().UTC()}
+
+	pods, err := k8s.GetPodsWithContext(ctx, metaV1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	deployments, err := k8s.GetDeploymentsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	statefulSets, err := k8s.GetStatefulSetsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	daemonSets, err := k8s.GetDaemonSetsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Images, err = k8s.GetImageUsageWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Pods = pods
+	snapshot.Deployments = deployments.Items
+	snapshot.StatefulSets = statefulSets.Items
+	snapshot.DaemonSets = daemonSets.Items
+	for i := range snapshot.Pods {
+		snapshot.Pods[i].ManagedFields = nil
+	}
+	for i := range snapshot.Deployments {
+		snapshot.Deployments[i].ManagedFields = nil
+	}
+	for i := range snapshot.StatefulSets {
+		snapshot.StatefulSets[i].ManagedFields = nil
+	}
+	for i := range snapshot.DaemonSets {
+		snapshot.DaemonSets[i].ManagedFields = nil
+	}
+
+	if len(options.Facts) == 0 {
+		return snapshot, nil
+	}
+
+	_, uniquePods, err := k8s.GetUniquePodsWithContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targets := TargetsFromPods(uniquePods)
+
+	names := make([]string, 0, len(options.Facts))
+	for name := range options.Facts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		command := options.Facts[name]
+		results := k8s.BatchExec(ctx, targets, command, options.Batch)
+		snapshot.Facts = append(snapshot.Facts, Fact{Name: name, Command: command, Results: results})
+	}
+
+	return snapshot, nil
+}
+
+// Write serializes the snapshot as JSON to 'w'.
+func (snapshot *NamespaceSnapshot) Write(w io.Writer) error {
+	return json.NewEncoder(w).Encode(snapshot)
+}
+
+// LoadSnapshot reads a snapshot serialized with NamespaceSnapshot.Write. Snapshots written with a newer,
+// unknown schema version are rejected with ErrUnsupportedSchemaVersion.
+func LoadSnapshot(r io.Reader) (*NamespaceSnapshot, error) {
+	var snapshot NamespaceSnapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("decoding snapshot: %w", err)
+	}
+	if snapshot.SchemaVersion > SnapshotSchemaVersion {
+		return nil, fmt.Errorf("%w: snapshot version %d", ErrUnsupportedSchemaVersion, snapshot.SchemaVersion)
+	}
+	return &snapshot, nil
+}
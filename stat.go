@@ -0,0 +1,248 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileType is the type of file system objects reported by StatFile.
+type FileType string
+
+// File types reported by StatFile.
+const (
+	FileTypeRegular     FileType = "regular"
+	FileTypeDirectory   FileType = "directory"
+	FileTypeSymlink     FileType = "symlink"
+	FileTypeFIFO        FileType = "fifo"
+	FileTypeSocket      FileType = "socket"
+	FileTypeCharDevice  FileType = "char-device"
+	FileTypeBlockDevice FileType = "block-device"
+	FileTypeUnknown     FileType = "unknown"
+)
+
+// FileInfo describes a file system object in a container.
+type FileInfo struct {
+	// Path is the path the object was looked up with.
+	Path string `json:"path"`
+	// Type is the type of the object. Symbolic links are not followed.
+	Type FileType `json:"type"`
+	// Mode holds the permission bits of the object as well as its type bits, as known from os.FileMode.
+	Mode os.FileMode `json:"mode"`
+	// Owner and Group are the names of the owning user and group, or their numeric identifiers if the
+	// container cannot resolve them.
+	Owner string `json:"owner"`
+	Group string `json:"group"`
+	// Size is the size of the object in bytes.
+	Size int64 `json:"size"`
+	// ModTime is the modification time of the object. It is zero if the container cannot report it.
+	ModTime time.Time `json:"modTime"`
+}
+
+// IsDir reports whether the object is a directory.
+func (info *FileInfo) IsDir() bool {
+	return info.Type == FileTypeDirectory
+}
+
+// statScript prints the properties of the file given as the first parameter, using 'stat' if the container
+// provides it and 'ls' otherwise. The output is prefixed with the name of the utility used, so it can be
+// parsed accordingly.
+const statScript = `[ -e "$1" ] || [ -L "$1" ] || exit 66
+if command -v stat >/dev/null 2>&1; then
+	s=$(stat -c '%f %U %G %s %Y' -- "$1" 2>/dev/null) && { echo "stat $s"; exit 0; }
+fi
+l=$(ls -ld -- "$1") || exit 1
+t=$(date -r "$1" +%s 2>/dev/null)
+echo "ls ${t:--} $l"`
+
+// StatFile returns the properties of the file system object 'path' in a container, identified by
+// the container's name and the associated pod's name: its type, permissions, owner, group, size and
+// modification time. Unlike the boolean checks, it tells apart missing objects, reported with errors wrapping
+// ErrFileNotFound, from objects of unexpected types. Symbolic links are not followed. The properties are
+// read with 'stat', or with 'ls' and 'date' in containers lacking it, in which case the modification time
+// may be missing. In pods running on Windows nodes the properties are read with PowerShell's Get-Item; since
+// Windows has no permission bits, the permissions are derived from the read-only attribute. The call is
+// governed by the provided context.
+func (k8s *K8SExec) StatFile(ctx context.Context, podName string, containerName string, path string) (*FileInfo, error) {
+	if k8s.targetsWindows(ctx, podName) {
+		return k8s.statFileWindows(ctx, podName, containerName, path)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"sh", "-c", statScript, "sh", path}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if err := fileError("inspecting", podName, containerName, path, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+
+	info, err := parseStat(path, strings.TrimSpace(stdout.String()))
+	if err != nil {
+		return nil, fmt.Errorf("inspecting %s/%s:%s: %w", podName, containerName, path, err)
+	}
+	return info, nil
+}
+
+// parseStat parses the output of statScript.
+func parseStat(path string, output string) (*FileInfo, error) {
+	fields := strings.Fields(output)
+	switch {
+	case len(fields) == 6 && fields[0] == "stat":
+		raw, err := strconv.ParseUint(fields[1], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("parsing mode %q: %w", fields[1], err)
+		}
+		size, _ := strconv.ParseInt(fields[4], 10, 64)
+		mode := unixFileMode(uint32(raw))
+		return &FileInfo{
+			Path:    path,
+			Type:    fileTypeOf(mode),
+			Mode:    mode,
+			Owner:   fields[2],
+			Group:   fields[3],
+			Size:    size,
+			ModTime: unixTime(fields[5]),
+		}, nil
+	case len(fields) >= 7 && fields[0] == "ls":
+		mode, err := parseModeString(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		// device files report their major and minor numbers instead of the size
+		size, _ := strconv.ParseInt(fields[6], 10, 64)
+		return &FileInfo{
+			Path:    path,
+			Type:    fileTypeOf(mode),
+			Mode:    mode,
+			Owner:   fields[4],
+			Group:   fields[5],
+			Size:    size,
+			ModTime: unixTime(fields[1]),
+		}, nil
+	}
+	return nil, fmt.Errorf("unexpected output %q", output)
+}
+
+// unixTime converts seconds since the epoch to time, or returns the zero time if they cannot be parsed.
+func unixTime(seconds string) time.Time {
+	secs, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+// unixFileMode converts a raw Unix mode, as in st_mode, to os.FileMode.
+func unixFileMode(raw uint32) os.FileMode {
+	mode := os.FileMode(raw & 0o777)
+	switch raw & 0o170000 {
+	case 0o040000:
+		mode |= fs.ModeDir
+	case 0o120000:
+		mode |= fs.ModeSymlink
+	case 0o010000:
+		mode |= fs.ModeNamedPipe
+	case 0o140000:
+		mode |= fs.ModeSocket
+	case 0o020000:
+		mode |= fs.ModeDevice | fs.ModeCharDevice
+	case 0o060000:
+		mode |= fs.ModeDevice
+	case 0o100000:
+	default:
+		mode |= fs.ModeIrregular
+	}
+	if raw&0o4000 != 0 {
+		mode |= fs.ModeSetuid
+	}
+	if raw&0o2000 != 0 {
+		mode |= fs.ModeSetgid
+	}
+	if raw&0o1000 != 0 {
+		mode |= fs.ModeSticky
+	}
+	return mode
+}
+
+// parseModeString converts a mode as printed by 'ls -l', e.g. "drwxr-sr-x", to os.FileMode.
+func parseModeString(s string) (os.FileMode, error) {
+	// trailing characters flag ACLs and security contexts, e.g. "-rw-r--r--+"
+	if len(s) < 10 {
+		return 0, fmt.Errorf("parsing mode %q: too short", s)
+	}
+
+	var mode os.FileMode
+	switch s[0] {
+	case '-':
+	case 'd':
+		mode |= fs.ModeDir
+	case 'l':
+		mode |= fs.ModeSymlink
+	case 'p':
+		mode |= fs.ModeNamedPipe
+	case 's':
+		mode |= fs.ModeSocket
+	case 'c':
+		mode |= fs.ModeDevice | fs.ModeCharDevice
+	case 'b':
+		mode |= fs.ModeDevice
+	default:
+		mode |= fs.ModeIrregular
+	}
+
+	for i, c := range s[1:10] {
+		bit := os.FileMode(1) << (8 - i)
+		switch c {
+		case 'r', 'w', 'x':
+			mode |= bit
+		case 's', 't':
+			mode |= bit
+			fallthrough
+		case 'S', 'T':
+			mode |= specialBit(i)
+		case '-':
+		default:
+			return 0, fmt.Errorf("parsing mode %q: unexpected %q", s, c)
+		}
+	}
+	return mode, nil
+}
+
+// specialBit returns the setuid, setgid or sticky bit shown in place of the execute bit at position 'i' of
+// the permissions in 'ls -l' output.
+func specialBit(i int) os.FileMode {
+	switch i {
+	case 2:
+		return fs.ModeSetuid
+	case 5:
+		return fs.ModeSetgid
+	case 8:
+		return fs.ModeSticky
+	}
+	return 0
+}
+
+// fileTypeOf returns the file type of a mode.
+func fileTypeOf(mode os.FileMode) FileType {
+	switch {
+	case mode.IsRegular():
+		return FileTypeRegular
+	case mode.IsDir():
+		return FileTypeDirectory
+	case mode&fs.ModeSymlink != 0:
+		return FileTypeSymlink
+	case mode&fs.ModeNamedPipe != 0:
+		return FileTypeFIFO
+	case mode&fs.ModeSocket != 0:
+		return FileTypeSocket
+	case mode&fs.ModeCharDevice != 0:
+		return FileTypeCharDevice
+	case mode&fs.ModeDevice != 0:
+		return FileTypeBlockDevice
+	}
+	return FileTypeUnknown
+}
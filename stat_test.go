@@ -0,0 +1,118 @@
+package k8sexec
+
+import (
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestParseStat(t *testing.T) {
+	modTime := time.Unix(1792155759, 0)
+
+	tests := []struct {
+		name   string
+		output string
+		want   FileInfo
+		err    bool
+	}{
+		{
+			name:   "stat regular file",
+			output: "stat 81a4 root root 1024 1792155759",
+			want:   FileInfo{Type: FileTypeRegular, Mode: 0o644, Owner: "root", Group: "root", Size: 1024, ModTime: modTime},
+		},
+		{
+			name:   "stat empty file",
+			output: "stat 81a4 app app 0 1792155759",
+			want:   FileInfo{Type: FileTypeRegular, Mode: 0o644, Owner: "app", Group: "app", Size: 0, ModTime: modTime},
+		},
+		{
+			name:   "stat sticky directory",
+			output: "stat 43ff root root 4096 1792155759",
+			want:   FileInfo{Type: FileTypeDirectory, Mode: fs.ModeDir | fs.ModeSticky | 0o777, Owner: "root", Group: "root", Size: 4096, ModTime: modTime},
+		},
+		{
+			name:   "stat setuid binary",
+			output: "stat 89ed root root 55680 1792155759",
+			want:   FileInfo{Type: FileTypeRegular, Mode: fs.ModeSetuid | 0o755, Owner: "root", Group: "root", Size: 55680, ModTime: modTime},
+		},
+		{
+			name:   "stat symbolic link",
+			output: "stat a1ff root root 7 1792155759",
+			want:   FileInfo{Type: FileTypeSymlink, Mode: fs.ModeSymlink | 0o777, Owner: "root", Group: "root", Size: 7, ModTime: modTime},
+		},
+		{
+			name:   "stat character device",
+			output: "stat 21b6 root root 0 1792155759",
+			want:   FileInfo{Type: FileTypeCharDevice, Mode: fs.ModeDevice | fs.ModeCharDevice | 0o666, Owner: "root", Group: "root", ModTime: modTime},
+		},
+		{
+			name:   "stat block device",
+			output: "stat 6180 root disk 0 1792155759",
+			want:   FileInfo{Type: FileTypeBlockDevice, Mode: fs.ModeDevice | 0o600, Owner: "root", Group: "disk", ModTime: modTime},
+		},
+		{
+			name:   "stat fifo and unresolved owner",
+			output: "stat 11a4 1000 1000 0 1792155759",
+			want:   FileInfo{Type: FileTypeFIFO, Mode: fs.ModeNamedPipe | 0o644, Owner: "1000", Group: "1000", ModTime: modTime},
+		},
+		{
+			name:   "GNU ls",
+			output: "ls 1792155759 -rw-r--r--  1 root root    0 Oct 16 13:02 my file",
+			want:   FileInfo{Type: FileTypeRegular, Mode: 0o644, Owner: "root", Group: "root", Size: 0, ModTime: modTime},
+		},
+		{
+			name:   "busybox ls without date -r",
+			output: "ls - drwxrwxrwt   16 root     root          4096 Oct 16 13:02 /tmp",
+			want:   FileInfo{Type: FileTypeDirectory, Mode: fs.ModeDir | fs.ModeSticky | 0o777, Owner: "root", Group: "root", Size: 4096},
+		},
+		{
+			name:   "ls with ACL flag",
+			output: "ls 1792155759 -rwxr-s---+ 1 app staff 12 Oct 16 13:02 /data/run.sh",
+			want:   FileInfo{Type: FileTypeRegular, Mode: fs.ModeSetgid | 0o750, Owner: "app", Group: "staff", Size: 12, ModTime: modTime},
+		},
+		{
+			name:   "ls setgid without execute",
+			output: "ls 1792155759 -rw-r-S--- 1 app staff 12 Oct 16 13:02 /data/file",
+			want:   FileInfo{Type: FileTypeRegular, Mode: fs.ModeSetgid | 0o640, Owner: "app", Group: "staff", Size: 12, ModTime: modTime},
+		},
+		{
+			name:   "ls character device",
+			output: "ls 1792155759 crw-rw-rw- 1 root root 1, 3 Oct 15 03:09 /dev/null",
+			want:   FileInfo{Type: FileTypeCharDevice, Mode: fs.ModeDevice | fs.ModeCharDevice | 0o666, Owner: "root", Group: "root", ModTime: modTime},
+		},
+		{
+			name:   "ls symbolic link",
+			output: "ls 1792155759 lrwxrwxrwx 1 root root 7 Oct 16 13:02 /bin -> usr/bin",
+			want:   FileInfo{Type: FileTypeSymlink, Mode: fs.ModeSymlink | 0o777, Owner: "root", Group: "root", Size: 7, ModTime: modTime},
+		},
+		{name: "invalid stat mode", output: "stat zz root root 0 1792155759", err: true},
+		{name: "invalid ls mode", output: "ls - -rw-r--r-Q 1 root root 0 Oct 16 13:02 file", err: true},
+		{name: "short ls mode", output: "ls - -rw 1 root root 0 Oct 16 13:02 file", err: true},
+		{name: "truncated stat output", output: "stat 81a4 root root", err: true},
+		{name: "empty output", output: "", err: true},
+		{name: "unknown utility", output: "find /data 0644", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info, err := parseStat("/data/file", test.output)
+			if test.err {
+				if err == nil {
+					t.Errorf("parseStat(%q) = %+v, want an error", test.output, info)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseStat(%q): %v", test.output, err)
+			}
+			test.want.Path = "/data/file"
+			if !info.ModTime.Equal(test.want.ModTime) {
+				t.Errorf("ModTime = %v, want %v", info.ModTime, test.want.ModTime)
+			}
+			info.ModTime, test.want.ModTime = time.Time{}, time.Time{}
+			if *info != test.want {
+				t.Errorf("parseStat(%q) = %+v, want %+v", test.output, *info, test.want)
+			}
+		})
+	}
+}
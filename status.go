@@ -0,0 +1,190 @@
+package k8sexec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExecutionStatus encapsulates the result and details of executing a command within a specific container.
+// It includes both identification and outcome information. The container is specified by its name and
+// the associated pod's name, provided in the Container and Pod fields, respectively.
+// The execution outcome is detailed as follows:
+// - RetCode: The exit code of the command executed within the container. A zero value typically indicates success.
+// - Error: A string representation of any error that occurred during command execution, as reported by the Kubernetes API.
+// - Stdout: The standard output generated by the command.
+// - Stderr: The standard error output generated by the command, if any.
+// - SkipReason: The reason why the command was not executed at all, set along with the ExecutionSkipped exit code.
+// - StdoutSHA256, StderrSHA256: Hex-encoded SHA-256 digests of the outputs, set instead of the outputs themselves
+// when the command was executed with the CaptureHashes policy.
+// - Namespace, Command: The namespace of the pod and the executed command's arguments.
+// - StartTime, Duration: When the execution started and how long it took, including retries.
+// - StdoutRaw, StderrRaw: The exact bytes of the outputs, set instead of Stdout and Stderr when the command
+// was executed with WithRawOutput, so binary output and trailing whitespace are preserved.
+// - StdoutTruncated, StderrTruncated: Whether the outputs were truncated because of WithMaxOutputBytes.
+// - Restarts: How many times the command was re-executed because its container restarted, see WithRestartRecovery.
+// - DryRun: Whether the status is a synthetic result of a dry run (BatchOptions.DryRun), in which case
+// the command was not executed and SkipReason describes what would have been executed.
+// The JSON representation of ExecutionStatus is versioned by SchemaVersion (see ResultSchemaVersion),
+// so results stored by older releases can be loaded by newer ones.
+type ExecutionStatus struct {
+	SchemaVersion   int           `json:"SchemaVersion,omitempty"`
+	Pod             string        `json:"Pod"`
+	Container       string        `json:"Container"`
+	RetCode         ExitCode      `json:"RetCode"`
+	Error           []string      `json:"Error,omitempty"`
+	Stdout          []string      `json:"Stdout,omitempty"`
+	Stderr          []string      `json:"Stderr,omitempty"`
+	SkipReason      string        `json:"SkipReason,omitempty"`
+	StdoutSHA256    string        `json:"StdoutSHA256,omitempty"`
+	StderrSHA256    string        `json:"StderrSHA256,omitempty"`
+	Namespace       string        `json:"Namespace,omitempty"`
+	Command         []string      `json:"Command,omitempty"`
+	StartTime       time.Time     `json:"StartTime"`
+	Duration        time.Duration `json:"Duration,omitempty"`
+	StdoutRaw       []byte        `json:"StdoutRaw,omitempty"`
+	StderrRaw       []byte        `json:"StderrRaw,omitempty"`
+	StdoutTruncated bool          `json:"StdoutTruncated,omitempty"`
+	StderrTruncated bool          `json:"StderrTruncated,omitempty"`
+	Restarts        int           `json:"Restarts,omitempty"`
+	DryRun          bool          `json:"DryRun,omitempty"`
+	// Err is the error the execution failed with, allowing to check its cause with errors.Is and errors.As
+	// (e.g. errors.Is(status.Err, ErrPodNotFound)) instead of matching Error. It is not serialized.
+	Err error `json:"-"`
+}
+
+// NewExecutionStatus initializes a new instance of the ExecutionStatus type, providing a method
+// to encapsulate the outcome of a command's execution within a structured format.
+// This function serves as a constructor, setting up an ExecutionStatus instance. Outputs and errors are split
+// into lines; empty ones are left nil, so they are omitted from the JSON representation.
+func NewExecutionStatus(pod string, container string, retCode ExitCode, error string, stdout string, stderr string) *ExecutionStatus {
+	return &ExecutionStatus{SchemaVersion: ResultSchemaVersion, Pod: pod, Container: container, RetCode: retCode, Error: splitLines(error), Stdout: splitLines(stdout), Stderr: splitLines(stderr)}
+}
+
+// splitLines splits a text into lines, returning nil for an empty text.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// recordExecution records the namespace, the command and the timing of the execution in the status.
+func (status *ExecutionStatus) recordExecution(namespace string, command []string, start time.Time) {
+	status.Namespace = namespace
+	status.Command = command
+	status.StartTime = start
+	status.Duration = time.Since(start)
+}
+
+// Succeeded reports whether the command was executed and exited with the Success exit code.
+func (status *ExecutionStatus) Succeeded() bool {
+	return status.RetCode == Success
+}
+
+// TimedOut reports whether the execution was interrupted because its timeout or deadline was exceeded.
+func (status *ExecutionStatus) TimedOut() bool {
+	return status.RetCode == ExecutionTimeOut || errors.Is(status.Err, ErrExecTimeout)
+}
+
+// Cancelled reports whether the execution was interrupted because its context was cancelled.
+func (status *ExecutionStatus) Cancelled() bool {
+	return status.RetCode == ExecutionCancelled
+}
+
+// Output returns the standard output of the command as a single string.
+func (status *ExecutionStatus) Output() string {
+	if status.StdoutRaw != nil {
+		return string(status.StdoutRaw)
+	}
+	return strings.Join(status.Stdout, "\n")
+}
+
+// ErrorOutput returns the standard error of the command as a single string.
+func (status *ExecutionStatus) ErrorOutput() string {
+	if status.StderrRaw != nil {
+		return string(status.StderrRaw)
+	}
+	return strings.Join(status.Stderr, "\n")
+}
+
+// CombinedOutput returns the standard output of the command followed by its standard error, as a single string.
+// The streams are captured separately, so their original interleaving is not preserved.
+func (status *ExecutionStatus) CombinedOutput() string {
+	stdout := status.Output()
+	stderr := status.ErrorOutput()
+	switch {
+	case stderr == "":
+		return stdout
+	case stdout == "":
+		return stderr
+	case strings.HasSuffix(stdout, "\n"):
+		return stdout + stderr
+	default:
+		return stdout + "\n" + stderr
+	}
+}
+
+// ExitDescription returns a human-readable description of the exit code, e.g. "Command not found",
+// or a generic one for exit codes without a known meaning.
+func (status *ExecutionStatus) ExitDescription() string {
+	if description := GetExitCodeDescription(status.RetCode); description != "" {
+		return description
+	}
+	return fmt.Sprintf("Exit code %d", status.RetCode)
+}
+
+// ResultSchemaVersion is the version of the JSON representation of ExecutionStatus written by this release.
+// Version 1 is the first versioned schema; results written before versioning was introduced carry no
+// SchemaVersion field and are loaded as version 1, since their fields are identical.
+// Adding optional fields does not change the version; renaming, removing or changing the meaning of
+// a field does.
+//
+// Field semantics of version 1:
+//   - SchemaVersion: version of the schema the result was written with.
+//   - Pod, Container: names of the pod and the container the command was executed in.
+//   - RetCode: exit code of the command, or one of the negative codes defined by ExitCode for failures
+//     that prevented obtaining it (e.g. ExecutionTimeOut, ExecutionCancelled, InternalAppError).
+//   - Error: lines of the error message reported by the Kubernetes API, omitted if empty.
+//   - Stdout, Stderr: lines of the standard output and standard error of the command, omitted if empty.
+//   - SkipReason: reason why the command was not executed (RetCode ExecutionSkipped), omitted if empty.
+//   - StdoutSHA256, StderrSHA256: hex-encoded SHA-256 digests of the outputs, set only by CaptureHashes.
+//   - Namespace, Command: namespace of the pod and arguments of the executed command, omitted if unknown.
+//   - StartTime, Duration: start of the execution (RFC 3339) and its duration in nanoseconds, including retries.
+//     StartTime is always present; it is the zero time "0001-01-01T00:00:00Z" if unknown, e.g. for statuses not
+//     produced by an execution. Duration is omitted if zero.
+//   - StdoutRaw, StderrRaw: base64-encoded exact outputs, set instead of Stdout and Stderr in raw output mode.
+//   - StdoutTruncated, StderrTruncated: whether the outputs were truncated to a size limit, omitted if false.
+//   - Restarts: number of container restarts the execution recovered from by re-executing the command,
+//     omitted if zero.
+//   - DryRun: whether the result was produced by a dry run without executing the command, omitted if false.
+const ResultSchemaVersion = 1
+
+// ErrUnsupportedSchemaVersion is returned when loading a result written with a newer, unknown schema version.
+var ErrUnsupportedSchemaVersion = errors.New("unsupported result schema version")
+
+// UnmarshalJSON decodes an ExecutionStatus from its JSON representation written by the current or any older
+// schema version, and normalizes it to the current version. Results written with a newer schema version are
+// rejected with ErrUnsupportedSchemaVersion.
+func (status *ExecutionStatus) UnmarshalJSON(data []byte) error {
+	// executionStatus has the same fields as ExecutionStatus but not its methods, avoiding recursion.
+	type executionStatus ExecutionStatus
+
+	var decoded executionStatus
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	switch {
+	case decoded.SchemaVersion > ResultSchemaVersion:
+		return fmt.Errorf("%w: %d", ErrUnsupportedSchemaVersion, decoded.SchemaVersion)
+	case decoded.SchemaVersion == 0:
+		// results written before the schema was versioned
+		decoded.SchemaVersion = ResultSchemaVersion
+	}
+
+	*status = ExecutionStatus(decoded)
+	return nil
+}
@@ -0,0 +1,120 @@
+package k8sexec
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewExecutionStatusOmitsEmptyOutputs(t *testing.T) {
+	data, err := json.Marshal(NewExecutionStatus("web", "app", Success, "", "", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{`"Error"`, `"Stdout"`, `"Stderr"`} {
+		if strings.Contains(string(data), field) {
+			t.Errorf("empty %s field serialized: %s", field, data)
+		}
+	}
+}
+
+func TestExecutionStatusJSONRoundTrip(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		status *ExecutionStatus
+	}{
+		{
+			name:   "constructed status",
+			status: NewExecutionStatus("web", "app", Success, "", "line 1\nline 2", ""),
+		},
+		{
+			name:   "failure",
+			status: NewExecutionStatus("web", "app", InternalAppError, "pods \"web\" not found", "", "stderr line"),
+		},
+		{
+			name: "all fields",
+			status: &ExecutionStatus{
+				SchemaVersion:   ResultSchemaVersion,
+				Pod:             "web",
+				Container:       "app",
+				RetCode:         GeneralError,
+				Stdout:          []string{"out"},
+				Stderr:          []string{"err 1", "err 2"},
+				StdoutSHA256:    "a",
+				StderrSHA256:    "b",
+				Namespace:       "default",
+				Command:         []string{"sh", "-c", "exit 1"},
+				StartTime:       start,
+				Duration:        1500 * time.Millisecond,
+				StdoutRaw:       []byte{0, 1, 2, '\n'},
+				StdoutTruncated: true,
+				Restarts:        2,
+				DryRun:          true,
+			},
+		},
+		{
+			name:   "skipped target",
+			status: &ExecutionStatus{SchemaVersion: ResultSchemaVersion, Pod: "web", Container: "app", RetCode: ExecutionSkipped, SkipReason: "pod is not ready"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(test.status)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded ExecutionStatus
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(&decoded, test.status) {
+				t.Errorf("decoded %+v, want %+v", decoded, *test.status)
+			}
+		})
+	}
+}
+
+func TestExecutionStatusUnmarshalSchemaVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    *ExecutionStatus
+		err     error
+	}{
+		{
+			name:    "legacy payload without a schema version",
+			payload: `{"Pod":"web","Container":"app","RetCode":1,"Error":["command terminated with exit code 1"],"Stdout":[""],"Stderr":["boom"],"StartTime":"0001-01-01T00:00:00Z"}`,
+			want: &ExecutionStatus{SchemaVersion: 1, Pod: "web", Container: "app", RetCode: GeneralError,
+				Error: []string{"command terminated with exit code 1"}, Stdout: []string{""}, Stderr: []string{"boom"}},
+		},
+		{
+			name:    "current version",
+			payload: `{"SchemaVersion":1,"Pod":"web","Container":"app","RetCode":0,"Stdout":["ok"],"StartTime":"0001-01-01T00:00:00Z"}`,
+			want:    &ExecutionStatus{SchemaVersion: 1, Pod: "web", Container: "app", Stdout: []string{"ok"}},
+		},
+		{
+			name:    "newer version",
+			payload: `{"SchemaVersion":2,"Pod":"web","Container":"app","RetCode":0}`,
+			err:     ErrUnsupportedSchemaVersion,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var decoded ExecutionStatus
+			err := json.Unmarshal([]byte(test.payload), &decoded)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+			if test.want != nil && !reflect.DeepEqual(&decoded, test.want) {
+				t.Errorf("decoded %+v, want %+v", decoded, *test.want)
+			}
+		})
+	}
+}
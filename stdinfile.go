@@ -0,0 +1,26 @@
+package k8sexec
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// ExecWithStdinFile executes a command provided as arguments ('args') with the content of the local file 'path'
+// delivered to its standard input, e.g. to restore a multi-gigabyte database dump with
+// ExecWithStdinFile(ctx, pod, container, []string{"psql", "-d", "app"}, "dump.sql"). The file is streamed as
+// the command consumes it, without being read into memory, and since it can be rewound, the execution can be
+// retried according to the instance's RetryPolicy. Further options are applied like by ExecWithOptions.
+func (k8s *K8SExec) ExecWithStdinFile(ctx context.Context, podName string, containerName string, args []string, path string, opts ...ExecOption) *ExecutionStatus {
+	file, err := os.Open(path)
+	if err != nil {
+		err = fmt.Errorf("opening standard input: %w", err)
+		status := NewExecutionStatus(podName, containerName, InternalAppError, err.Error(), "", "")
+		status.Err = err
+		return status
+	}
+	defer file.Close()
+
+	return k8s.ExecWithOptions(ctx, podName, containerName, args, slices.Concat(opts, []ExecOption{WithStdin(file)})...)
+}
@@ -0,0 +1,33 @@
+package k8sexec
+
+import (
+	"github.com/hhruszka/k8sexec/discovery"
+)
+
+// RepresentativeStrategy decides which of the pods of a workload represent it in DiscoverUniquePods and
+// GetUniquePods.
+type RepresentativeStrategy = discovery.RepresentativeStrategy
+
+// Strategies of selecting representative pods, see the discovery package for their descriptions.
+const (
+	StrategyFirst   = discovery.StrategyFirst
+	StrategyRandom  = discovery.StrategyRandom
+	StrategyNewest  = discovery.StrategyNewest
+	StrategyOldest  = discovery.StrategyOldest
+	StrategyPerNode = discovery.StrategyPerNode
+)
+
+// ParseRepresentativeStrategy returns the strategy named 'name', e.g. given as a command line flag, or an error
+// wrapping ErrNoSuchStrategy if there is no such strategy.
+func ParseRepresentativeStrategy(name string) (RepresentativeStrategy, error) {
+	return discovery.ParseRepresentativeStrategy(name)
+}
+
+// WithRepresentativeStrategy sets the strategy of selecting the pods representing workloads in
+// DiscoverUniquePods and GetUniquePods. By default the first pod listed by the API server is selected.
+// Unknown strategies make the discovery fail with an error wrapping ErrNoSuchStrategy.
+func WithRepresentativeStrategy(strategy RepresentativeStrategy) Option {
+	return func(k8s *K8SExec) {
+		k8s.strategy = strategy
+	}
+}
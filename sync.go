@@ -0,0 +1,377 @@
+package k8sexec
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SyncDirection is the direction in which SyncDir transfers files.
+type SyncDirection int
+
+// Directions of SyncDir.
+const (
+	// SyncToPod updates the remote directory with the content of the local one.
+	SyncToPod SyncDirection = iota
+	// SyncFromPod updates the local directory with the content of the remote one.
+	SyncFromPod
+)
+
+// SyncOptions configures SyncDir.
+type SyncOptions struct {
+	Direction SyncDirection
+	// Delete removes files from the destination directory which do not exist in the source directory.
+	Delete bool
+}
+
+// SyncReport is the result of SyncDir. Files are identified by their slash-separated paths relative to
+// the synchronized directories.
+type SyncReport struct {
+	// Transferred lists files which were missing or differed in the destination directory.
+	Transferred []string `json:"Transferred,omitempty"`
+	// Deleted lists files removed from the destination directory because of SyncOptions.Delete.
+	Deleted []string `json:"Deleted,omitempty"`
+	// Unchanged is the number of files which were identical in both directories.
+	Unchanged int `json:"Unchanged"`
+}
+
+// remoteChecksumsScript prints SHA-256 checksums of all regular files below the directory given as the first
+// parameter, with paths relative to it. It prints nothing if the directory does not exist and the second
+// parameter is 1.
+const remoteChecksumsScript = `if [ ! -d "$1" ]; then
+	[ -e "$1" ] && exit 65
+	[ "$2" = 1 ] && exit 0
+	exit 66
+fi
+command -v sha256sum >/dev/null 2>&1 || { echo "no sha256sum available" >&2; exit 127; }
+cd "$1" && find . -type f -exec sha256sum {} +`
+
+// syncTarScript writes a tar archive of the files given as the parameters following the first one, relative to
+// the directory given as the first parameter, to standard output.
+const syncTarScript = `cd "$1" && shift && tar -cf - -- "$@"`
+
+// removeFilesScript removes the files given as the parameters following the first one, relative to
+// the directory given as the first parameter.
+const removeFilesScript = `cd "$1" && shift && rm -f -- "$@"`
+
+// SyncDir synchronizes the local directory 'localDir' with the directory 'remoteDir' in a container, identified
+// by the container's name and the associated pod's name, in the direction given by options.Direction, like
+// a minimal rsync: SHA-256 checksums of the regular files of both directories are compared, and only files
+// which are missing or differ in the destination are transferred, so in-container tooling can be iterated on
+// without full re-uploads. The transferred files are verified with their checksums, mismatches being reported
+// with errors wrapping ErrChecksumMismatch. Symbolic links and special files are ignored. The container must
+// provide 'sha256sum' and 'find'; files are transferred in a tar archive if it provides 'tar', and one by one
+// otherwise. The destination directory is created if it does not exist; the root directory of the container
+// cannot be synchronized. The synchronization is governed by the provided context.
+func (k8s *K8SExec) SyncDir(ctx context.Context, localDir string, podName string, containerName string, remoteDir string, options SyncOptions) (*SyncReport, error) {
+	remoteDir = path.Clean(remoteDir)
+	if remoteDir == "/" {
+		return nil, fmt.Errorf("synchronizing %s/%s:%s: the root directory cannot be synchronized", podName, containerName, remoteDir)
+	}
+	local, err := localChecksums(localDir, options.Direction == SyncFromPod)
+	if err != nil {
+		return nil, fmt.Errorf("synchronizing %s: %w", localDir, err)
+	}
+	remote, err := k8s.remoteChecksums(ctx, podName, containerName, remoteDir, options.Direction == SyncToPod)
+	if err != nil {
+		return nil, err
+	}
+
+	source, destination := local, remote
+	if options.Direction == SyncFromPod {
+		source, destination = remote, local
+	}
+	report := &SyncReport{}
+	for _, file := range sortedKeys(source) {
+		if destination[file] == source[file] {
+			report.Unchanged++
+			continue
+		}
+		report.Transferred = append(report.Transferred, file)
+	}
+	if options.Delete {
+		for _, file := range sortedKeys(destination) {
+			if _, ok := source[file]; !ok {
+				report.Deleted = append(report.Deleted, file)
+			}
+		}
+	}
+
+	useTar := k8s.CheckUtilInContainerWithContext(ctx, podName, containerName, "tar")
+	if options.Direction == SyncToPod {
+		err = k8s.syncToPod(ctx, localDir, podName, containerName, remoteDir, report, useTar)
+	} else {
+		err = k8s.syncFromPod(ctx, localDir, podName, containerName, remoteDir, report, remote, useTar)
+	}
+	if err != nil {
+		return nil, err
+	}
+	k8s.logger().Debug("directory synchronized", "pod", podName, "container", containerName, "remoteDir", remoteDir,
+		"localDir", localDir, "transferred", len(report.Transferred), "deleted", len(report.Deleted), "unchanged", report.Unchanged)
+	return report, nil
+}
+
+// syncToPod uploads and removes the files listed in 'report' and verifies the uploaded files.
+func (k8s *K8SExec) syncToPod(ctx context.Context, localDir string, podName string, containerName string, remoteDir string, report *SyncReport, useTar bool) error {
+	if len(report.Transferred) > 0 {
+		var err error
+		if useTar {
+			reader, writer := io.Pipe()
+			go func() {
+				_ = writer.CloseWithError(writeTarFiles(writer, localDir, report.Transferred))
+			}()
+			var stderr bytes.Buffer
+			retCode, execErr := k8s.ExecStream(ctx, podName, containerName, []string{"sh", "-c", untarScript, "sh", remoteDir}, reader, nil, &stderr)
+			_ = reader.Close()
+			err = copyError("synchronizing", podName, containerName, remoteDir, retCode, execErr, &stderr)
+		} else {
+			err = k8s.uploadFiles(ctx, localDir, podName, containerName, remoteDir, report.Transferred)
+		}
+		if err != nil {
+			return err
+		}
+
+		local, err := localChecksums(localDir, false)
+		if err != nil {
+			return fmt.Errorf("synchronizing %s: %w", localDir, err)
+		}
+		remote, err := k8s.remoteChecksums(ctx, podName, containerName, remoteDir, false)
+		if err != nil {
+			return err
+		}
+		for _, file := range report.Transferred {
+			if remote[file] != local[file] {
+				return fmt.Errorf("synchronizing %s/%s:%s: %w: %s", podName, containerName, remoteDir, ErrChecksumMismatch, file)
+			}
+		}
+	}
+
+	if len(report.Deleted) > 0 {
+		var stderr bytes.Buffer
+		cmd := append([]string{"sh", "-c", removeFilesScript, "sh", remoteDir}, report.Deleted...)
+		retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, nil, &stderr)
+		return copyError("synchronizing", podName, containerName, remoteDir, retCode, err, &stderr)
+	}
+	return nil
+}
+
+// uploadFiles uploads files one by one with WriteFile, for containers lacking 'tar'.
+func (k8s *K8SExec) uploadFiles(ctx context.Context, localDir string, podName string, containerName string, remoteDir string, files []string) error {
+	for _, file := range files {
+		localFile := filepath.Join(localDir, filepath.FromSlash(file))
+		info, err := os.Stat(localFile)
+		if err != nil {
+			return fmt.Errorf("synchronizing %s: %w", localDir, err)
+		}
+		remoteFile := path.Join(remoteDir, file)
+		var stderr bytes.Buffer
+		retCode, err := k8s.ExecStream(ctx, podName, containerName, []string{"mkdir", "-p", path.Dir(remoteFile)}, nil, nil, &stderr)
+		if err := copyError("synchronizing", podName, containerName, remoteFile, retCode, err, &stderr); err != nil {
+			return err
+		}
+
+		f, err := os.Open(localFile)
+		if err != nil {
+			return fmt.Errorf("synchronizing %s: %w", localDir, err)
+		}
+		err = k8s.WriteFile(ctx, podName, containerName, remoteFile, f, info.Mode().Perm())
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncFromPod downloads and removes the files listed in 'report' and verifies the downloaded files against
+// the remote checksums.
+func (k8s *K8SExec) syncFromPod(ctx context.Context, localDir string, podName string, containerName string, remoteDir string, report *SyncReport, remote map[string]string, useTar bool) error {
+	checksums := make(map[string]string)
+	if len(report.Transferred) > 0 && useTar {
+		dir, base := path.Dir(remoteDir), path.Base(remoteDir)
+		reader, writer := io.Pipe()
+		extracted := make(map[string]string)
+		done := make(chan error, 1)
+		go func() {
+			err := extractTar(reader, base, localDir, extracted)
+			// drain the archive, so the command is not blocked writing it
+			_, _ = io.Copy(io.Discard, reader)
+			done <- err
+		}()
+
+		var stderr bytes.Buffer
+		cmd := []string{"sh", "-c", syncTarScript, "sh", dir}
+		for _, file := range report.Transferred {
+			cmd = append(cmd, path.Join(base, file))
+		}
+		retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, writer, &stderr)
+		_ = writer.Close()
+		extractErr := <-done
+		if err := copyError("synchronizing", podName, containerName, remoteDir, retCode, err, &stderr); err != nil {
+			return err
+		}
+		if extractErr != nil {
+			return fmt.Errorf("synchronizing %s/%s:%s: %w", podName, containerName, remoteDir, extractErr)
+		}
+		// entries are named relative to the parent of the remote directory in the archive
+		for entry, checksum := range extracted {
+			checksums[strings.TrimPrefix(entry, base+"/")] = checksum
+		}
+	} else {
+		for _, file := range report.Transferred {
+			hash := sha256.New()
+			target := filepath.Join(localDir, filepath.FromSlash(file))
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return fmt.Errorf("synchronizing %s: %w", localDir, err)
+			}
+			f, err := os.Create(target)
+			if err != nil {
+				return fmt.Errorf("synchronizing %s: %w", localDir, err)
+			}
+			_, err = k8s.ReadFileTo(ctx, podName, containerName, path.Join(remoteDir, file), io.MultiWriter(f, hash))
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				return err
+			}
+			checksums[file] = hex.EncodeToString(hash.Sum(nil))
+		}
+	}
+
+	for _, file := range report.Transferred {
+		if checksums[file] != remote[file] {
+			return fmt.Errorf("synchronizing %s/%s:%s: %w: %s", podName, containerName, remoteDir, ErrChecksumMismatch, file)
+		}
+	}
+	for _, file := range report.Deleted {
+		if err := os.Remove(filepath.Join(localDir, filepath.FromSlash(file))); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("synchronizing %s: %w", localDir, err)
+		}
+	}
+	return nil
+}
+
+// remoteChecksums returns SHA-256 checksums of the regular files below a directory in the container, keyed by
+// their slash-separated paths relative to it. A missing directory is reported with an error wrapping
+// ErrFileNotFound, unless 'missingOK' is true.
+func (k8s *K8SExec) remoteChecksums(ctx context.Context, podName string, containerName string, remoteDir string, missingOK bool) (map[string]string, error) {
+	flag := "0"
+	if missingOK {
+		flag = "1"
+	}
+	var stdout, stderr bytes.Buffer
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, []string{"sh", "-c", remoteChecksumsScript, "sh", remoteDir, flag}, nil, &stdout, &stderr)
+	if retCode == CommandNotFound && strings.Contains(stderr.String(), "no sha256sum available") {
+		return nil, fmt.Errorf("synchronizing %s/%s:%s: %w: sha256sum", podName, containerName, remoteDir, ErrUtilNotFound)
+	}
+	if err := fileError("synchronizing", podName, containerName, remoteDir, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+
+	checksums, err := parseChecksums(&stdout)
+	if err != nil {
+		return nil, fmt.Errorf("synchronizing %s/%s:%s: %w", podName, containerName, remoteDir, err)
+	}
+	return checksums, nil
+}
+
+// parseChecksums parses the output of remoteChecksumsScript. Names are used as local paths, so names which are
+// absolute or lead outside of the directory, e.g. "../.ssh/authorized_keys", are rejected with an error.
+// GNU sha256sum escapes backslashes and line breaks in names and marks such lines with a leading backslash;
+// their names are unescaped, so they can be compared with the local ones.
+func parseChecksums(r io.Reader) (map[string]string, error) {
+	checksums := make(map[string]string)
+	lines := bufio.NewScanner(r)
+	lines.Buffer(nil, maxLineLength)
+	for lines.Scan() {
+		line, escaped := strings.CutPrefix(lines.Text(), `\`)
+		checksum, file, ok := strings.Cut(line, "  ")
+		if !ok {
+			continue
+		}
+		if escaped {
+			file = checksumNameReplacer.Replace(file)
+		}
+		file = strings.TrimPrefix(file, "./")
+		if path.IsAbs(file) || !filepath.IsLocal(filepath.FromSlash(file)) {
+			return nil, fmt.Errorf("unexpected file name %q", file)
+		}
+		checksums[file] = checksum
+	}
+	return checksums, lines.Err()
+}
+
+// checksumNameReplacer unescapes names escaped by GNU sha256sum.
+var checksumNameReplacer = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\r`, "\r")
+
+// localChecksums returns SHA-256 checksums of the regular files below a local directory, keyed by their
+// slash-separated paths relative to it. A missing directory yields no checksums if 'missingOK' is true.
+func localChecksums(localDir string, missingOK bool) (map[string]string, error) {
+	checksums := make(map[string]string)
+	if _, err := os.Stat(localDir); missingOK && os.IsNotExist(err) {
+		return checksums, nil
+	}
+	err := filepath.WalkDir(localDir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return err
+		}
+		relative, err := filepath.Rel(localDir, file)
+		if err != nil {
+			return err
+		}
+		hash := sha256.New()
+		if err := copyFile(hash, file); err != nil {
+			return err
+		}
+		checksums[filepath.ToSlash(relative)] = hex.EncodeToString(hash.Sum(nil))
+		return nil
+	})
+	return checksums, err
+}
+
+// writeTarFiles writes a tar archive of the regular files 'files', given by their slash-separated paths relative
+// to 'localDir', to 'w'.
+func writeTarFiles(w io.Writer, localDir string, files []string) error {
+	archive := tar.NewWriter(w)
+	for _, file := range files {
+		localFile := filepath.Join(localDir, filepath.FromSlash(file))
+		info, err := os.Stat(localFile)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = file
+		if err := archive.WriteHeader(header); err != nil {
+			return err
+		}
+		if err := copyFile(archive, localFile); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// sortedKeys returns the keys of a map in ascending order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,62 @@
+package k8sexec
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+func TestParseChecksums(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:   "empty directory",
+			output: "",
+			want:   map[string]string{},
+		},
+		{
+			name:   "nested files and names with spaces",
+			output: "aa  ./a.txt\nbb  ./dir/b c.txt\ncc  ./dir/  leading\n",
+			want:   map[string]string{"a.txt": "aa", "dir/b c.txt": "bb", "dir/  leading": "cc"},
+		},
+		{
+			name:   "names escaped by GNU sha256sum",
+			output: "aa  ./a.txt\n\\bb  ./new\\nline\n\\cc  ./back\\\\slash\n\\dd  ./dir\\\\n\n",
+			want:   map[string]string{"a.txt": "aa", "new\nline": "bb", `back\slash`: "cc", `dir\n`: "dd"},
+		},
+		{
+			name:    "parent directory",
+			output:  "aa  ../../.ssh/authorized_keys\n",
+			wantErr: true,
+		},
+		{
+			name:    "parent directory below the directory",
+			output:  "aa  ./dir/../../escape\n",
+			wantErr: true,
+		},
+		{
+			name:    "absolute name",
+			output:  "aa  /etc/passwd\n",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseChecksums(strings.NewReader(test.output))
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("parseChecksums accepted %q: %v", test.output, got)
+				}
+				return
+			}
+			if err != nil || !maps.Equal(got, test.want) {
+				t.Errorf("parseChecksums(%q) = %v, %v, want %v", test.output, got, err, test.want)
+			}
+		})
+	}
+}
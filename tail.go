@@ -0,0 +1,87 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultTailLines is the number of last lines of a file delivered by TailFile when it does not follow the file.
+const DefaultTailLines = 10
+
+// tailFileScript prints the last lines of the file passed as the first positional parameter, or with "follow"
+// as the second parameter, lines appended to it from now on. tail is used when the container provides it;
+// otherwise the last lines are extracted with sed, and the file is followed by polling its size every second,
+// which also copes with truncated (e.g. rotated in place) files.
+const tailFileScript = `p=$1; mode=$2; n=$3
+[ -r "$p" ] || { printf 'cannot read %s\n' "$p" >&2; exit 1; }
+if command -v tail >/dev/null 2>&1; then
+  if [ "$mode" = follow ]; then exec tail -n 0 -f "$p"; fi
+  exec tail -n "$n" "$p"
+fi
+if [ "$mode" != follow ]; then
+  exec sed -e :a -e '$q;N;'"$((n+1))"',$D;ba' "$p"
+fi
+off=$(wc -c < "$p")
+while :; do
+  size=$(wc -c < "$p") || exit 1
+  [ "$size" -lt "$off" ] && off=0
+  if [ "$size" -gt "$off" ]; then
+    dd if="$p" bs=1 skip="$off" count=$((size - off)) 2>/dev/null
+    off=$size
+  fi
+  sleep 1
+done`
+
+// TailFile streams lines of a file in a container, identified by the container's name and the associated pod's
+// name, over the returned channel. Without 'follow' it delivers the last DefaultTailLines lines of the file;
+// with 'follow' it delivers lines appended to the file from now on, like 'tail -f', until 'ctx' is cancelled,
+// which allows to observe log files of containers without log shipping live. 'tail' is used when the container
+// provides it, with a polling fallback otherwise. The lines channel is closed when streaming ends; the error
+// channel then delivers a single error, which is nil if the file was streamed successfully or following it was
+// stopped by cancelling 'ctx'.
+func (k8s *K8SExec) TailFile(ctx context.Context, podName string, containerName string, path string, follow bool) (<-chan Line, <-chan error) {
+	lines := make(chan Line)
+	errs := make(chan error, 1)
+
+	mode := "last"
+	if follow {
+		mode = "follow"
+	}
+	cmd := []string{"sh", "-c", tailFileScript, "sh", path, mode, strconv.Itoa(DefaultTailLines)}
+
+	go func() {
+		defer close(errs)
+		defer close(lines)
+
+		if containerName == "" {
+			var err error
+			if containerName, err = k8s.DefaultContainer(ctx, podName); err != nil {
+				errs <- err
+				return
+			}
+		}
+		var stderr bytes.Buffer
+		stdout := &lineWriter{line: Line{Pod: podName, Container: containerName, Stream: Stdout}, deliver: func(line Line) {
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+			}
+		}}
+		retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, stdout, &stderr)
+		stdout.flush()
+		switch {
+		case follow && ctx.Err() != nil:
+			errs <- nil
+		case err != nil && retCode < Success:
+			errs <- fmt.Errorf("tailing %s in %s/%s: %w", path, podName, containerName, err)
+		case retCode != Success:
+			errs <- fmt.Errorf("tailing %s in %s/%s: exit code %d: %s", path, podName, containerName, retCode, strings.TrimSpace(stderr.String()))
+		default:
+			errs <- nil
+		}
+	}()
+	return lines, errs
+}
@@ -0,0 +1,81 @@
+package k8sexec
+
+import (
+	"context"
+	"golang.org/x/term"
+	"k8s.io/client-go/tools/remotecommand"
+	"os"
+	"sync"
+)
+
+// Shell starts an interactive shell in a container, identified by the container's name and the associated pod's
+// name, connected to the local terminal, like 'kubectl exec -it' does. The shell is detected with DetectShell.
+// The local terminal is put in raw mode, so Control-C and other control keys are passed to the remote shell
+// instead of terminating the calling program, and size changes of the local terminal resize the remote one.
+// The previous state of the terminal is restored when the shell exits. Shell returns the exit code of the shell.
+func (k8s *K8SExec) Shell(podName string, containerName string) (ExitCode, error) {
+	return k8s.ShellWithContext(context.Background(), podName, containerName)
+}
+
+// ShellWithContext starts an interactive shell in a container like Shell. The use of this function must provide
+// a context that will govern the session, including the shell detection.
+func (k8s *K8SExec) ShellWithContext(ctx context.Context, podName string, containerName string) (ExitCode, error) {
+	shell, err := k8s.DetectShell(ctx, podName, containerName)
+	if err != nil {
+		return CommandNotFound, err
+	}
+
+	var sizes remotecommand.TerminalSizeQueue
+	if fd := int(os.Stdout.Fd()); term.IsTerminal(fd) {
+		queue := newTerminalSizeQueue(fd)
+		defer queue.stop()
+		sizes = queue
+	}
+	return k8s.ExecTTY(ctx, podName, containerName, shell, os.Stdin, os.Stdout, sizes)
+}
+
+// terminalSizeQueue delivers size changes of a local terminal to a remote one. It starts with the current size,
+// and keeps at most one pending size, so slow consumers always receive the latest one.
+type terminalSizeQueue struct {
+	fd      int
+	sizes   chan remotecommand.TerminalSize
+	done    chan struct{}
+	stopped sync.Once
+}
+
+// newTerminalSizeQueue starts watching size changes of the terminal 'fd'.
+func newTerminalSizeQueue(fd int) *terminalSizeQueue {
+	queue := &terminalSizeQueue{fd: fd, sizes: make(chan remotecommand.TerminalSize, 1), done: make(chan struct{})}
+	queue.update()
+	go watchTerminalResize(fd, queue.done, queue.update)
+	return queue
+}
+
+// update queues the current size of the terminal, replacing a size that was not consumed yet.
+func (queue *terminalSizeQueue) update() {
+	width, height, err := term.GetSize(queue.fd)
+	if err != nil {
+		return
+	}
+	size := remotecommand.TerminalSize{Width: uint16(width), Height: uint16(height)}
+	select {
+	case <-queue.sizes:
+	default:
+	}
+	queue.sizes <- size
+}
+
+// Next returns the next size of the terminal, or nil once the queue is stopped.
+func (queue *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	select {
+	case size := <-queue.sizes:
+		return &size
+	case <-queue.done:
+		return nil
+	}
+}
+
+// stop stops watching the terminal.
+func (queue *terminalSizeQueue) stop() {
+	queue.stopped.Do(func() { close(queue.done) })
+}
@@ -0,0 +1,25 @@
+//go:build !windows
+
+package k8sexec
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchTerminalResize calls 'resized' whenever the process receives SIGWINCH, until 'done' is closed.
+func watchTerminalResize(_ int, done <-chan struct{}, resized func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGWINCH)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-signals:
+			resized()
+		case <-done:
+			return
+		}
+	}
+}
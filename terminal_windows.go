@@ -0,0 +1,30 @@
+//go:build windows
+
+package k8sexec
+
+import (
+	"golang.org/x/term"
+	"time"
+)
+
+// terminalPollPeriod is the period of checking the size of the console, which does not signal size changes.
+const terminalPollPeriod = 250 * time.Millisecond
+
+// watchTerminalResize calls 'resized' whenever the size of the console 'fd' changes, until 'done' is closed.
+func watchTerminalResize(fd int, done <-chan struct{}, resized func()) {
+	ticker := time.NewTicker(terminalPollPeriod)
+	defer ticker.Stop()
+
+	width, height, _ := term.GetSize(fd)
+	for {
+		select {
+		case <-ticker.C:
+			if w, h, err := term.GetSize(fd); err == nil && (w != width || h != height) {
+				width, height = w, h
+				resized()
+			}
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package k8sexec
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// DefaultExecTimeout is the timeout applied to command executions when neither the caller nor
+	// the K8SExec instance provides one.
+	DefaultExecTimeout = 60 * time.Second
+	// DefaultFileOpTimeout is the timeout applied to short helper executions, such as checking whether
+	// a utility is available in a container.
+	DefaultFileOpTimeout = 5 * time.Second
+	// DefaultDiscoveryTimeout is the timeout applied to calls listing or retrieving Kubernetes resources.
+	DefaultDiscoveryTimeout = 30 * time.Second
+)
+
+// Timeouts groups the default timeouts used by a K8SExec instance. A zero value of any field means that
+// Default, or the corresponding package default (DefaultExecTimeout, DefaultFileOpTimeout,
+// DefaultDiscoveryTimeout) if Default is not set either, is used.
+type Timeouts struct {
+	// Default is applied to command executions, including helper executions, for which no more specific
+	// timeout is set. It does not apply to discovery.
+	Default time.Duration
+	// Exec is applied by Exec when it is called with a zero timeout.
+	Exec time.Duration
+	// FileOp is applied to helper executions like CheckUtilInContainer.
+	FileOp time.Duration
+	// Discovery is applied to API calls retrieving pods and workloads.
+	Discovery time.Duration
+}
+
+// override returns a copy of the timeouts with all non-zero fields of 'overrides' applied.
+func (t Timeouts) override(overrides Timeouts) Timeouts {
+	if overrides.Default > 0 {
+		t.Default = overrides.Default
+	}
+	if overrides.Exec > 0 {
+		t.Exec = overrides.Exec
+	}
+	if overrides.FileOp > 0 {
+		t.FileOp = overrides.FileOp
+	}
+	if overrides.Discovery > 0 {
+		t.Discovery = overrides.Discovery
+	}
+	return t
+}
+
+// WithDefaultTimeouts sets the default timeouts of the instance. Zero fields keep package defaults.
+func WithDefaultTimeouts(timeouts Timeouts) Option {
+	return func(k8s *K8SExec) {
+		k8s.timeouts = k8s.timeouts.override(timeouts)
+	}
+}
+
+// WithTimeouts returns a derived K8SExec instance whose default timeouts are overridden by the non-zero fields
+// of 'timeouts'. Like WithNamespace, the derived instance shares clients with the original one, which makes it
+// a cheap way to override timeouts for a single call or a group of calls:
+//
+//	k8s.WithTimeouts(k8sexec.Timeouts{FileOp: 30 * time.Second}).CheckUtilInContainer(pod, container, "tar")
+func (k8s *K8SExec) WithTimeouts(timeouts Timeouts) *K8SExec {
+	derived := *k8s
+	derived.timeouts = k8s.timeouts.override(timeouts)
+	return &derived
+}
+
+// SetDefaultTimeout sets the timeout applied to command executions, including helper executions such as
+// CheckUtilInContainer, for which no more specific timeout is configured. It is meant for slow clusters, where
+// the short package defaults of helper executions are not enough. Like the exported fields, it must not be
+// called once the instance is shared between goroutines; WithTimeouts should be used to override timeouts
+// of a shared instance instead.
+func (k8s *K8SExec) SetDefaultTimeout(timeout time.Duration) {
+	k8s.timeouts.Default = timeout
+}
+
+// ExecTimeout returns 'timeout' if it is set, or the instance's default exec timeout otherwise. It is the timeout
+// BatchExec applies to every execution, and is meant for operations on many targets built on top of K8SExec.
+func (k8s *K8SExec) ExecTimeout(timeout time.Duration) time.Duration {
+	return firstPositive(timeout, k8s.timeouts.Exec, k8s.timeouts.Default, DefaultExecTimeout)
+}
+
+// fileOpTimeout returns the timeout applied to helper executions.
+func (k8s *K8SExec) fileOpTimeout() time.Duration {
+	return firstPositive(k8s.timeouts.FileOp, k8s.timeouts.Default, DefaultFileOpTimeout)
+}
+
+// discoveryContext returns a context bounded by the discovery timeout, used by API calls retrieving resources.
+func (k8s *K8SExec) discoveryContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), firstPositive(k8s.timeouts.Discovery, DefaultDiscoveryTimeout))
+}
+
+// firstPositive returns the first positive duration from the list, or zero if there is none.
+func firstPositive(durations ...time.Duration) time.Duration {
+	for _, duration := range durations {
+		if duration > 0 {
+			return duration
+		}
+	}
+	return 0
+}
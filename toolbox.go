@@ -0,0 +1,184 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DefaultToolboxName is the file name of the uploaded toolbox binary when ToolboxOptions.Name is not set.
+const DefaultToolboxName = "busybox"
+
+// DefaultToolboxDirs are the directories tried by InstallToolbox when ToolboxOptions.Dirs is not set. Common
+// writable locations are listed, since root file systems of minimal images are often read-only.
+var DefaultToolboxDirs = []string{"/tmp", "/dev/shm", "/var/tmp", "/run"}
+
+// toolboxPath is the search path set for scripts run with the shell of a toolbox. The directory of the toolbox's
+// applets is put in front of it.
+const toolboxPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// ToolboxOptions configures InstallToolbox.
+type ToolboxOptions struct {
+	// Binaries are the variants of the toolbox binary for the architectures of the targeted containers, usually
+	// statically linked busybox builds. The variant matching the container's architecture is uploaded.
+	Binaries MultiArchBinary
+	// Name is the file name of the uploaded binary. DefaultToolboxName is used when it is not set.
+	Name string
+	// Dirs are the directories tried in turn until the binary can be uploaded to one and executed from it.
+	// DefaultToolboxDirs is used when it is empty.
+	Dirs []string
+	// Plain marks the binary as an ordinary one rather than a busybox multi-call binary: it is uploaded only,
+	// without installing applets or providing a shell.
+	Plain bool
+}
+
+// Toolbox is a binary uploaded to a container by InstallToolbox.
+type Toolbox struct {
+	Pod       string `json:"Pod"`
+	Container string `json:"Container"`
+	// Arch is the architecture of the uploaded variant.
+	Arch string `json:"Arch"`
+	// Path is the path of the uploaded binary in the container.
+	Path string `json:"Path"`
+	// Dir is the directory holding links to the busybox applets. It is empty for plain binaries.
+	Dir string `json:"Dir,omitempty"`
+	// Applets lists the utilities provided by busybox, e.g. "ls" or "find".
+	Applets []string `json:"Applets,omitempty"`
+
+	k8s *K8SExec
+}
+
+// Command returns the command running the toolbox with 'args'; for busybox, the first argument is the name of
+// the applet, e.g. toolbox.Command("find", "/", "-name", "*.pem").
+func (toolbox *Toolbox) Command(args ...string) []string {
+	return append([]string{toolbox.Path}, args...)
+}
+
+// Shell returns the command starting the shell of a busybox toolbox with its applets found first through PATH,
+// or nil for plain binaries. Arguments of the shell, e.g. "-c" and a script, are to be appended.
+func (toolbox *Toolbox) Shell() []string {
+	if toolbox.Dir == "" {
+		return nil
+	}
+	return []string{toolbox.Path, "env", "PATH=" + toolbox.Dir + ":" + toolboxPath, path.Join(toolbox.Dir, "sh")}
+}
+
+// Remove deletes the toolbox from the container and stops using its shell for scripts. The removal is governed
+// by the provided context.
+func (toolbox *Toolbox) Remove(ctx context.Context) error {
+	k8s := toolbox.k8s
+	if k8s.shells != nil {
+		k8s.shells.shells.Delete(k8s.Namespace + "/" + toolbox.Pod + "/" + toolbox.Container)
+	}
+
+	cmd := []string{"rm", "-f", toolbox.Path}
+	if toolbox.Dir != "" {
+		cmd = toolbox.Command("rm", "-rf", toolbox.Dir, toolbox.Path)
+	}
+	var stderr bytes.Buffer
+	retCode, err := k8s.ExecStream(ctx, toolbox.Pod, toolbox.Container, cmd, nil, nil, &stderr)
+	if err := copyError("removing toolbox from", toolbox.Pod, toolbox.Container, toolbox.Path, retCode, err, &stderr); err != nil {
+		return err
+	}
+	k8s.forgetArtifacts(toolbox.Pod, toolbox.Container, toolbox.Path, toolbox.Dir)
+	return nil
+}
+
+// InstallToolbox uploads a statically linked busybox, or another binary given by options.Binaries, to a container,
+// identified by the container's name and the associated pod's name, so files and processes can be probed in images
+// shipping almost no userland. The variant matching the container's architecture (see DetectArch) is uploaded
+// to the first of options.Dirs it can be written to and executed from, since some of them may be read-only or
+// mounted noexec. For busybox, links to its applets are installed in a directory next to the binary, and
+// the toolbox's shell, with the applets in its PATH, becomes the shell of ExecScript and DetectShell for
+// the container until the toolbox is removed. The upload itself requires 'sh' and 'cat' or 'dd' in
+// the container. The installation is governed by the provided context.
+func (k8s *K8SExec) InstallToolbox(ctx context.Context, podName string, containerName string, options ToolboxOptions) (*Toolbox, error) {
+	if len(options.Binaries) == 0 {
+		return nil, fmt.Errorf("installing toolbox in %s/%s: no binaries provided", podName, containerName)
+	}
+	arch, err := k8s.DetectArch(ctx, podName, containerName)
+	if err != nil {
+		return nil, err
+	}
+	binary, err := options.Binaries.Select(arch)
+	if err != nil {
+		return nil, err
+	}
+	name := options.Name
+	if name == "" {
+		name = DefaultToolboxName
+	}
+	dirs := options.Dirs
+	if len(dirs) == 0 {
+		dirs = DefaultToolboxDirs
+	}
+
+	var errs []error
+	for _, dir := range dirs {
+		toolbox := &Toolbox{Pod: podName, Container: containerName, Arch: arch, Path: path.Join(dir, "k8sexec-"+name), k8s: k8s}
+		if err := k8s.WriteFile(ctx, podName, containerName, toolbox.Path, bytes.NewReader(binary), 0o755); err != nil {
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if options.Plain {
+			return toolbox, nil
+		}
+
+		toolbox.Dir = path.Join(dir, "k8sexec-toolbox")
+		k8s.addArtifact(podName, containerName, toolbox.Dir)
+		if err := k8s.installApplets(ctx, toolbox); err != nil {
+			errs = append(errs, err)
+			if err := toolbox.removeBinary(ctx); err != nil {
+				errs = append(errs, err)
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if k8s.shells != nil {
+			k8s.shells.shells.Store(k8s.Namespace+"/"+podName+"/"+containerName, toolbox.Shell())
+		}
+		k8s.logger().Debug("toolbox installed", "pod", podName, "container", containerName, "path", toolbox.Path,
+			"arch", arch, "applets", len(toolbox.Applets))
+		return toolbox, nil
+	}
+	return nil, fmt.Errorf("installing toolbox in %s/%s: %w", podName, containerName, errors.Join(errs...))
+}
+
+// installApplets verifies that the uploaded busybox can be executed, installs links to its applets and lists
+// them.
+func (k8s *K8SExec) installApplets(ctx context.Context, toolbox *Toolbox) error {
+	var stdout, stderr bytes.Buffer
+	cmd := toolbox.Command("sh", "-c", `"$0" mkdir -p "$1" && "$0" --install -s "$1" && "$0" --list`, toolbox.Path, toolbox.Dir)
+	retCode, err := k8s.ExecStream(ctx, toolbox.Pod, toolbox.Container, cmd, nil, &stdout, &stderr)
+	if err := copyError("installing toolbox in", toolbox.Pod, toolbox.Container, toolbox.Path, retCode, err, &stderr); err != nil {
+		return err
+	}
+	toolbox.Applets = strings.Fields(stdout.String())
+	return nil
+}
+
+// removeBinary removes the uploaded binary with the container's own 'rm'. A shell alone cannot unlink files, so
+// if the container has no 'rm', the binary stays recorded as an artifact, to be removed by ShredArtifacts, and
+// an error wrapping ErrUtilNotFound is returned.
+func (toolbox *Toolbox) removeBinary(ctx context.Context) error {
+	var stderr bytes.Buffer
+	cmd := []string{"sh", "-c", `rm -f "$1"`, "sh", toolbox.Path}
+	retCode, err := toolbox.k8s.ExecStream(ctx, toolbox.Pod, toolbox.Container, cmd, nil, nil, &stderr)
+	if retCode == CommandNotFound {
+		return fmt.Errorf("removing toolbox from %s/%s:%s: %w: rm", toolbox.Pod, toolbox.Container, toolbox.Path, ErrUtilNotFound)
+	}
+	if err := copyError("removing toolbox from", toolbox.Pod, toolbox.Container, toolbox.Path, retCode, err, &stderr); err != nil {
+		return err
+	}
+	// the applet directory, if partially created, is left for ShredArtifacts
+	toolbox.k8s.forgetArtifacts(toolbox.Pod, toolbox.Container, toolbox.Path)
+	return nil
+}
@@ -0,0 +1,173 @@
+package k8sexec
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of DownloadOptions.
+const (
+	DefaultChunkSize       = 8 * 1024 * 1024
+	DefaultChunkAttempts   = 5
+	DefaultChunkRetryDelay = 2 * time.Second
+)
+
+// fileSizeScript prints the size of the file given as the first parameter.
+const fileSizeScript = checkReadableScript + `n=$(wc -c < "$1") && echo $n`
+
+// readChunkScript writes the chunk of the file given as the first parameter with the index given as the third
+// parameter, chunks being of the size given as the second parameter, to standard output.
+const readChunkScript = `exec dd if="$1" bs="$2" skip="$3" count=1 2>/dev/null`
+
+// chunkChecksumScript prints the SHA-256 checksum of a chunk like readChunkScript selects it, or nothing if
+// the container lacks 'sha256sum'.
+const chunkChecksumScript = `command -v sha256sum >/dev/null 2>&1 || exit 0
+s=$(dd if="$1" bs="$2" skip="$3" count=1 2>/dev/null | sha256sum) && echo "${s%% *}"`
+
+// DownloadOptions configures DownloadFile.
+type DownloadOptions struct {
+	// ChunkSize is the size of chunks the file is transferred in. DefaultChunkSize is used when it is not set.
+	ChunkSize int64
+	// ChunkAttempts is the number of attempts to transfer a single chunk before the download fails.
+	// DefaultChunkAttempts is used when it is not set.
+	ChunkAttempts int
+	// RetryDelay is the delay before retrying a failed chunk. DefaultChunkRetryDelay is used when it is not set.
+	RetryDelay time.Duration
+}
+
+// DownloadFile copies the file 'remotePath' in a container, identified by the container's name and
+// the associated pod's name, to the local file 'localPath' in chunks transferred by separate executions, so
+// very large files, e.g. multi-gigabyte core dumps, can be copied over unreliable connections. Every chunk is
+// verified with its SHA-256 checksum computed in the container (if it provides 'sha256sum') and retried on
+// failure. If 'localPath' already holds a partial copy, e.g. left by a download interrupted by a lost
+// connection, the download resumes after its last complete chunk, which is verified first. It requires 'dd'
+// and 'wc' in the container. The download is governed by the provided context.
+func (k8s *K8SExec) DownloadFile(ctx context.Context, podName string, containerName string, remotePath string, localPath string, options DownloadOptions) error {
+	chunkSize := cmp.Or(options.ChunkSize, DefaultChunkSize)
+	size, err := k8s.remoteFileSize(ctx, podName, containerName, remotePath)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(localPath, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("downloading %s/%s:%s: %w", podName, containerName, remotePath, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	chunks := (size + chunkSize - 1) / chunkSize
+	complete := min(info.Size()/chunkSize, chunks)
+	if err := file.Truncate(complete * chunkSize); err != nil {
+		return err
+	}
+	first := max(complete-1, 0)
+	if complete > 0 {
+		k8s.logger().Info("resuming download", "pod", podName, "container", containerName, "path", remotePath,
+			"offset", first*chunkSize, "size", size)
+	}
+
+	for index := first; index < chunks; index++ {
+		// the last complete chunk of a partial copy may not have reached the disk intact; verify it before resuming
+		if complete > 0 && index == first {
+			if local, err := localChunk(file, index, chunkSize); err == nil {
+				if k8s.chunkMatches(ctx, podName, containerName, remotePath, index, chunkSize, local) {
+					continue
+				}
+			}
+		}
+		chunk, err := k8s.downloadChunk(ctx, podName, containerName, remotePath, index, chunkSize, options)
+		if err != nil {
+			return fmt.Errorf("downloading %s/%s:%s: chunk %d of %d: %w", podName, containerName, remotePath, index+1, chunks, err)
+		}
+		if _, err := file.WriteAt(chunk, index*chunkSize); err != nil {
+			return err
+		}
+	}
+	return file.Truncate(size)
+}
+
+// remoteFileSize returns the size of a file in the container.
+func (k8s *K8SExec) remoteFileSize(ctx context.Context, podName string, containerName string, remotePath string) (int64, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"sh", "-c", fileSizeScript, "sh", remotePath}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if err := fileError("downloading", podName, containerName, remotePath, retCode, err, &stderr); err != nil {
+		return 0, err
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(stdout.String()), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("downloading %s/%s:%s: parsing size: %w", podName, containerName, remotePath, err)
+	}
+	return size, nil
+}
+
+// downloadChunk transfers and verifies a single chunk, retrying failed attempts.
+func (k8s *K8SExec) downloadChunk(ctx context.Context, podName string, containerName string, remotePath string, index int64, chunkSize int64, options DownloadOptions) ([]byte, error) {
+	attempts := cmp.Or(options.ChunkAttempts, DefaultChunkAttempts)
+	args := []string{remotePath, strconv.FormatInt(chunkSize, 10), strconv.FormatInt(index, 10)}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var stdout, stderr bytes.Buffer
+		var retCode ExitCode
+		retCode, err = k8s.ExecStream(ctx, podName, containerName, append([]string{"sh", "-c", readChunkScript, "sh"}, args...), nil, &stdout, &stderr)
+		err = copyError("reading chunk of", podName, containerName, remotePath, retCode, err, &stderr)
+		if err == nil {
+			if k8s.chunkMatches(ctx, podName, containerName, remotePath, index, chunkSize, stdout.Bytes()) {
+				return stdout.Bytes(), nil
+			}
+			err = ErrChecksumMismatch
+		}
+		if attempt >= attempts || ctx.Err() != nil {
+			return nil, err
+		}
+
+		k8s.logger().Warn("retrying chunk download", "pod", podName, "container", containerName, "path", remotePath,
+			"chunk", index, "attempt", attempt, "error", err)
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(cmp.Or(options.RetryDelay, DefaultChunkRetryDelay)):
+		}
+	}
+}
+
+// chunkMatches reports whether 'chunk' matches the checksum of the chunk in the container. Chunks are considered
+// matching if the container cannot compute checksums.
+func (k8s *K8SExec) chunkMatches(ctx context.Context, podName string, containerName string, remotePath string, index int64, chunkSize int64, chunk []byte) bool {
+	var stdout bytes.Buffer
+	cmd := []string{"sh", "-c", chunkChecksumScript, "sh", remotePath, strconv.FormatInt(chunkSize, 10), strconv.FormatInt(index, 10)}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, io.Discard)
+	if err != nil || retCode != Success {
+		return false
+	}
+	remote := strings.TrimSpace(stdout.String())
+	if remote == "" {
+		return true
+	}
+	local := sha256.Sum256(chunk)
+	return remote == hex.EncodeToString(local[:])
+}
+
+// localChunk reads a chunk of the local file.
+func localChunk(file *os.File, index int64, chunkSize int64) ([]byte, error) {
+	chunk := make([]byte, chunkSize)
+	n, err := file.ReadAt(chunk, index*chunkSize)
+	if n == 0 && err != nil {
+		return nil, err
+	}
+	return chunk[:n], nil
+}
@@ -0,0 +1,72 @@
+package k8sexec
+
+import (
+	"crypto/tls"
+	"k8s.io/apimachinery/pkg/util/httpstream/spdy"
+	"k8s.io/client-go/rest"
+	spdyTransport "k8s.io/client-go/transport/spdy"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// spdyPingPeriod is the period of SPDY pings keeping exec connections alive, the same as used by client-go.
+const spdyPingPeriod = 5 * time.Second
+
+// tlsSessionCacheSize is the number of TLS sessions kept for resumption by the transport cache.
+const tlsSessionCacheSize = 64
+
+// transportCache keeps the TLS configuration derived from rest.Config, so it is not rebuilt for every exec
+// stream. SPDY connections are upgraded and hijacked by the exec protocol, so they cannot be reused, but
+// the cached TLS configuration carries a session cache allowing for cheap TLS session resumption.
+type transportCache struct {
+	once      sync.Once
+	tlsConfig *tls.Config
+	err       error
+}
+
+// roundTripperFor returns a new SPDY round tripper and upgrader for the given configuration, equivalent to
+// the ones returned by client-go's spdy.RoundTripperFor, but built on top of the cached TLS configuration.
+// If 'hb' is set, connections are pinged and monitored according to it.
+func (cache *transportCache) roundTripperFor(config *rest.Config, hb *heartbeat) (http.RoundTripper, spdyTransport.Upgrader, error) {
+	cache.once.Do(func() {
+		tlsConfig, err := rest.TLSConfigFor(config)
+		if err != nil {
+			cache.err = err
+			return
+		}
+		if tlsConfig != nil {
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.ClientSessionCache = tls.NewLRUClientSessionCache(tlsSessionCacheSize)
+		}
+		cache.tlsConfig = tlsConfig
+	})
+	if cache.err != nil {
+		return nil, nil, cache.err
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if config.Proxy != nil {
+		proxy = config.Proxy
+	}
+	pingPeriod := spdyPingPeriod
+	if hb != nil {
+		pingPeriod = hb.interval
+	}
+	upgradeRoundTripper, err := spdy.NewRoundTripperWithConfig(spdy.RoundTripperConfig{
+		TLS:        cache.tlsConfig,
+		Proxier:    proxy,
+		PingPeriod: pingPeriod,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if hb != nil {
+		upgradeRoundTripper.Dialer = hb.dialer()
+	}
+	wrapper, err := rest.HTTPWrappersForConfig(config, upgradeRoundTripper)
+	if err != nil {
+		return nil, nil, err
+	}
+	return wrapper, upgradeRoundTripper, nil
+}
@@ -0,0 +1,42 @@
+package k8sexec
+
+import (
+	"context"
+	"golang.org/x/term"
+	"io"
+	"k8s.io/client-go/tools/remotecommand"
+	"os"
+)
+
+// WithTerminalSizeQueue allocates a pseudo-terminal for the command, like WithTTY, and resizes it whenever
+// 'sizes' delivers a new terminal size. The queue's Next method must return nil once no more sizes follow.
+func WithTerminalSizeQueue(sizes remotecommand.TerminalSizeQueue) ExecOption {
+	return func(config *execConfig) {
+		config.tty = true
+		config.terminalSizes = sizes
+	}
+}
+
+// ExecTTY executes a command provided as arguments ('args') in a pseudo-terminal allocated in the container,
+// connecting it to 'stdin' and 'stdout', which enables interactive programs such as shells or editors to be
+// driven from Go programs. Standard error of the command is merged into standard output by the terminal.
+// Size changes delivered by 'sizes', which may be nil, resize the remote terminal. If 'stdin' is a local
+// terminal, it is put in raw mode for the duration of the execution, so keystrokes (including Control-C) are
+// passed to the remote command unprocessed; its previous state is restored afterwards.
+// It returns the exit code of the command and any error encountered; ExecutionTimeOut and ExecutionCancelled
+// are returned when the context's deadline was exceeded or the context was cancelled.
+func (k8s *K8SExec) ExecTTY(ctx context.Context, podName string, containerName string, args []string, stdin io.Reader, stdout io.Writer, sizes remotecommand.TerminalSizeQueue) (ExitCode, error) {
+	if file, ok := stdin.(*os.File); ok && term.IsTerminal(int(file.Fd())) {
+		state, err := term.MakeRaw(int(file.Fd()))
+		if err != nil {
+			return InternalAppError, err
+		}
+		defer func() { _ = term.Restore(int(file.Fd()), state) }()
+	}
+
+	retCode, err := k8s.execTraced(ctx, nil, podName, containerName, args, stdin, stdout, nil, true, sizes)
+	if retCode == InternalAppError && ctx.Err() != nil {
+		retCode = ContextExitCode(ctx.Err())
+	}
+	return retCode, err
+}
@@ -0,0 +1,76 @@
+package k8sexec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// probeUtilsScript checks every utility passed as a positional parameter and prints one "<util>\t<0|1>" line
+// per utility. 'command -v' is tried first, then 'which', and finally PATH is walked for shells lacking both.
+const probeUtilsScript = `for u in "$@"; do
+  if command -v "$u" >/dev/null 2>&1 || which "$u" >/dev/null 2>&1; then
+    printf '%s\t1\n' "$u"
+    continue
+  fi
+  found=0
+  IFS=:
+  for d in $PATH; do
+    if [ -x "$d/$u" ]; then found=1; break; fi
+  done
+  unset IFS
+  printf '%s\t%s\n' "$u" "$found"
+done`
+
+// CheckUtilsInContainer verifies the existence of all 'utils' binaries within a container, identified by
+// the container's name and the associated pod's name, with a single execution. Unlike CheckUtilInContainer,
+// binaries are looked up in PATH rather than executed, so tools exiting with an error when run without arguments
+// are reported correctly. It returns a map of utility names to their availability. When the container does not
+// provide a shell, the returned error wraps ErrNoShell. The check is bounded by the instance's file-op timeout.
+func (k8s *K8SExec) CheckUtilsInContainer(podName, containerName string, utils []string) (map[string]bool, error) {
+	ctx, cancelFunc := context.WithTimeout(context.Background(), k8s.fileOpTimeout())
+	defer cancelFunc()
+
+	return k8s.CheckUtilsInContainerWithContext(ctx, podName, containerName, utils)
+}
+
+// CheckUtilsInContainerWithContext verifies the existence of all 'utils' binaries within a container with
+// a single execution governed by the provided context. See CheckUtilsInContainer for details.
+// In Windows mode utilities are looked up with PowerShell's Get-Command.
+func (k8s *K8SExec) CheckUtilsInContainerWithContext(ctx context.Context, podName, containerName string, utils []string) (map[string]bool, error) {
+	available := make(map[string]bool, len(utils))
+	if len(utils) == 0 {
+		return available, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := append([]string{"sh", "-c", probeUtilsScript, "sh"}, utils...)
+	if k8s.windows {
+		shell, err := k8s.DetectShell(ctx, podName, containerName)
+		if err != nil {
+			return nil, fmt.Errorf("probing utilities in %s/%s: %w", podName, containerName, err)
+		}
+		cmd = windowsUtilsCommand(shell, utils)
+	}
+	retCode, err := k8s.exec(ctx, podName, containerName, cmd, nil, &stdout, &stderr, false)
+	switch {
+	case retCode == CommandNotFound || retCode == CommandCannotExecute:
+		return nil, fmt.Errorf("probing utilities in %s/%s: %w", podName, containerName, ErrNoShell)
+	case err != nil:
+		return nil, fmt.Errorf("probing utilities in %s/%s: %w", podName, containerName, err)
+	}
+
+	for _, util := range utils {
+		available[util] = false
+	}
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		util, found, ok := strings.Cut(scanner.Text(), "\t")
+		if ok {
+			available[util] = found == "1"
+		}
+	}
+	return available, nil
+}
@@ -0,0 +1,224 @@
+package k8sexec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	coreV1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+)
+
+var (
+	// ErrNamespaceNotFound is returned when the namespace of the instance does not exist.
+	ErrNamespaceNotFound = errors.New("namespace not found")
+	// ErrPodNotRunning is returned when a pod is not in the Running phase.
+	ErrPodNotRunning = errors.New("pod not running")
+)
+
+// CheckStatus is the outcome of a single pre-flight check.
+type CheckStatus string
+
+const (
+	CheckPassed CheckStatus = "passed"
+	CheckFailed CheckStatus = "failed"
+	// CheckSkipped is reported for checks which could not be performed, e.g. because of missing permissions
+	// or because a previous check failed.
+	CheckSkipped CheckStatus = "skipped"
+)
+
+// CheckResult is the result of a single pre-flight check performed by Validate.
+type CheckResult struct {
+	Name    string      `json:"Name"`
+	Status  CheckStatus `json:"Status"`
+	Message string      `json:"Message,omitempty"`
+	// Hint suggests how to fix a failed check.
+	Hint string `json:"Hint,omitempty"`
+
+	err error
+}
+
+// Diagnosis is the result of Validate: the outcome of all pre-flight checks of an execution target.
+type Diagnosis struct {
+	Namespace string        `json:"Namespace"`
+	Pod       string        `json:"Pod"`
+	Container string        `json:"Container"`
+	Checks    []CheckResult `json:"Checks"`
+}
+
+// OK reports whether no check failed.
+func (diagnosis *Diagnosis) OK() bool {
+	return diagnosis.Err() == nil
+}
+
+// Err returns the error of the first failed check, wrapping the matching sentinel error (ErrNamespaceNotFound,
+// ErrPodNotFound, ErrPodNotRunning, ErrContainerNotFound or ErrContainerNotRunning), or nil if no check failed.
+func (diagnosis *Diagnosis) Err() error {
+	for _, check := range diagnosis.Checks {
+		if check.Status == CheckFailed {
+			return check.err
+		}
+	}
+	return nil
+}
+
+// String returns a human-readable multi-line summary of the diagnosis.
+func (diagnosis *Diagnosis) String() string {
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "%s/%s/%s:\n", diagnosis.Namespace, diagnosis.Pod, diagnosis.Container)
+	for _, check := range diagnosis.Checks {
+		fmt.Fprintf(&summary, "  [%s] %s", check.Status, check.Name)
+		if check.Message != "" {
+			fmt.Fprintf(&summary, ": %s", check.Message)
+		}
+		if check.Hint != "" {
+			fmt.Fprintf(&summary, " (%s)", check.Hint)
+		}
+		summary.WriteByte('\n')
+	}
+	return summary.String()
+}
+
+// Validate performs pre-flight checks of an execution target: it verifies that the namespace and the pod exist,
+// that the pod is running, and that the container exists and is running. It returns a structured diagnosis with
+// actionable hints, so tools can fail fast with clear messages before attempting thousands of executions.
+// Checks following a failed one are skipped. An empty container name selects the pod's default container.
+func (k8s *K8SExec) Validate(ctx context.Context, podName string, containerName string) *Diagnosis {
+	return k8s.validate(ctx, podName, containerName, k8s.checkNamespace(ctx))
+}
+
+// validate is Validate with the result of the namespace check provided by the caller, so validations of many
+// targets in the same namespace share a single check.
+func (k8s *K8SExec) validate(ctx context.Context, podName string, containerName string, namespaceCheck CheckResult) *Diagnosis {
+	diagnosis := &Diagnosis{Namespace: k8s.Namespace, Pod: podName, Container: containerName}
+	failed := false
+	add := func(check CheckResult) {
+		if failed {
+			check = CheckResult{Name: check.Name, Status: CheckSkipped, Message: "a previous check failed"}
+		}
+		failed = failed || check.Status == CheckFailed
+		diagnosis.Checks = append(diagnosis.Checks, check)
+	}
+
+	add(namespaceCheck)
+
+	var pod *coreV1.Pod
+	if !failed {
+		var check CheckResult
+		pod, check = k8s.checkPod(ctx, podName)
+		add(check)
+	} else {
+		add(CheckResult{Name: "pod exists"})
+	}
+
+	if !failed {
+		add(checkPodPhase(pod))
+	} else {
+		add(CheckResult{Name: "pod running"})
+	}
+
+	if !failed && containerName == "" {
+		containerName = DefaultContainerOf(pod)
+		diagnosis.Container = containerName
+	}
+	if !failed {
+		add(checkContainerState(pod, containerName))
+	} else {
+		add(CheckResult{Name: "container running"})
+	}
+
+	return diagnosis
+}
+
+// checkNamespace verifies that the namespace exists. Users allowed to work in a namespace are often not allowed
+// to get it, in which case the check is skipped.
+func (k8s *K8SExec) checkNamespace(ctx context.Context) CheckResult {
+	check := CheckResult{Name: "namespace exists", Status: CheckPassed}
+	_, err := k8s.Clientset.CoreV1().Namespaces().Get(ctx, k8s.Namespace, metaV1.GetOptions{})
+	switch {
+	case err == nil:
+	case apiErrors.IsNotFound(err):
+		check.Status = CheckFailed
+		check.Message = fmt.Sprintf("namespace %q does not exist", k8s.Namespace)
+		check.Hint = "check the namespace name or the kubeconfig context"
+		check.err = fmt.Errorf("%s: %w", k8s.Namespace, ErrNamespaceNotFound)
+	default:
+		check.Status = CheckSkipped
+		check.Message = fmt.Sprintf("cannot verify: %v", err)
+	}
+	return check
+}
+
+// checkPod verifies that the pod exists and returns it.
+func (k8s *K8SExec) checkPod(ctx context.Context, podName string) (*coreV1.Pod, CheckResult) {
+	check := CheckResult{Name: "pod exists", Status: CheckPassed}
+	pod, err := k8s.GetPodWithContext(ctx, podName, metaV1.GetOptions{})
+	if err != nil {
+		check.Status = CheckFailed
+		check.Message = err.Error()
+		check.err = err
+		switch {
+		case errors.Is(err, ErrPodNotFound):
+			check.Hint = "the pod may have been replaced; list pods again to get current names"
+		case errors.Is(err, ErrForbidden):
+			check.Hint = "grant the user 'get' permission on pods in the namespace"
+		}
+	}
+	return pod, check
+}
+
+// checkPodPhase verifies that the pod is running.
+func checkPodPhase(pod *coreV1.Pod) CheckResult {
+	check := CheckResult{Name: "pod running", Status: CheckPassed, Message: string(pod.Status.Phase)}
+	if pod.Status.Phase != coreV1.PodRunning {
+		check.Status = CheckFailed
+		check.Message = fmt.Sprintf("pod is in phase %s", pod.Status.Phase)
+		if pod.Status.Reason != "" {
+			check.Message += ": " + pod.Status.Reason
+		}
+		check.err = fmt.Errorf("%s: %w (%s)", pod.Name, ErrPodNotRunning, pod.Status.Phase)
+		switch pod.Status.Phase {
+		case coreV1.PodPending:
+			check.Hint = "wait for the pod to be scheduled and started"
+		default:
+			check.Hint = "completed pods cannot run commands; choose a running pod"
+		}
+	}
+	return check
+}
+
+// checkContainerState verifies that the container exists in the pod and is running.
+func checkContainerState(pod *coreV1.Pod, containerName string) CheckResult {
+	check := CheckResult{Name: "container running", Status: CheckPassed}
+	for _, container := range ContainersOf(pod) {
+		if container.Name != containerName {
+			continue
+		}
+		if container.State != ContainerRunning {
+			check.Status = CheckFailed
+			check.Message = fmt.Sprintf("container is %s", container.State)
+			if container.Reason != "" {
+				check.Message += ": " + container.Reason
+			}
+			check.Hint = "inspect the container's logs and events"
+			check.err = fmt.Errorf("%s/%s: %w (%s)", pod.Name, containerName, ErrContainerNotRunning, container.State)
+		}
+		return check
+	}
+	for _, container := range pod.Spec.EphemeralContainers {
+		if container.Name == containerName {
+			return check
+		}
+	}
+
+	var names []string
+	for _, container := range pod.Spec.Containers {
+		names = append(names, container.Name)
+	}
+	check.Status = CheckFailed
+	check.Message = fmt.Sprintf("container %q does not exist", containerName)
+	check.Hint = "available containers: " + strings.Join(names, ", ")
+	check.err = fmt.Errorf("%s/%s: %w", pod.Name, containerName, ErrContainerNotFound)
+	return check
+}
@@ -0,0 +1,191 @@
+package k8sexec
+
+import (
+	"context"
+	"fmt"
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchTools "k8s.io/client-go/tools/watch"
+)
+
+// WaitForPodDeleted waits until the pod with the given name is deleted. It returns immediately if the pod
+// does not exist. Waiting is based on a watch and is bounded by 'ctx'.
+func (k8s *K8SExec) WaitForPodDeleted(ctx context.Context, podName string) error {
+	lw := cache.NewListWatchFromClient(k8s.Clientset.CoreV1().RESTClient(), "pods", k8s.Namespace,
+		fields.OneTermEqualSelector("metadata.name", podName))
+
+	precondition := func(store cache.Store) (bool, error) {
+		_, exists, err := store.GetByKey(k8s.Namespace + "/" + podName)
+		return !exists, err
+	}
+	deleted := func(event watch.Event) (bool, error) {
+		return event.Type == watch.Deleted, nil
+	}
+
+	if _, err := watchTools.UntilWithSync(ctx, lw, &coreV1.Pod{}, precondition, deleted); err != nil {
+		return fmt.Errorf("waiting for deletion of pod %s/%s: %w", k8s.Namespace, podName, err)
+	}
+	return nil
+}
+
+// WaitForPodRecreated waits until the controller of the 'previous' pod (identified by the pod's controller owner
+// reference) creates a replacement pod and the replacement becomes ready, and returns the replacement.
+// It is meant for sequences restarting a pod and executing commands in its replacement: a replacement is any
+// ready pod of the same controller that is not the 'previous' pod and was not created before it.
+func (k8s *K8SExec) WaitForPodRecreated(ctx context.Context, previous *coreV1.Pod) (*coreV1.Pod, error) {
+	owner := metaV1.GetControllerOfNoCopy(previous)
+	if owner == nil {
+		return nil, fmt.Errorf("pod %s/%s has no controller and will not be recreated", previous.Namespace, previous.Name)
+	}
+
+	isReplacement := func(obj interface{}) bool {
+		pod, ok := obj.(*coreV1.Pod)
+		if !ok || pod.UID == previous.UID || pod.CreationTimestamp.Before(&previous.CreationTimestamp) {
+			return false
+		}
+		controller := metaV1.GetControllerOfNoCopy(pod)
+		return controller != nil && controller.UID == owner.UID && isPodReady(pod)
+	}
+
+	var replacement *coreV1.Pod
+	precondition := func(store cache.Store) (bool, error) {
+		for _, obj := range store.List() {
+			if isReplacement(obj) {
+				replacement = obj.(*coreV1.Pod)
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	recreated := func(event watch.Event) (bool, error) {
+		if event.Type != watch.Added && event.Type != watch.Modified || !isReplacement(event.Object) {
+			return false, nil
+		}
+		replacement = event.Object.(*coreV1.Pod)
+		return true, nil
+	}
+
+	lw := cache.NewListWatchFromClient(k8s.Clientset.CoreV1().RESTClient(), "pods", k8s.Namespace, fields.Everything())
+	if _, err := watchTools.UntilWithSync(ctx, lw, &coreV1.Pod{}, precondition, recreated); err != nil {
+		return nil, fmt.Errorf("waiting for replacement of pod %s/%s: %w", k8s.Namespace, previous.Name, err)
+	}
+	return replacement, nil
+}
+
+// WaitForRolloutComplete waits until the rollout of a Deployment, StatefulSet or DaemonSet is complete, i.e. all
+// its replicas are updated to the latest revision and available, using the same criteria as
+// 'kubectl rollout status'. Waiting is based on a watch and is bounded by 'ctx'.
+func (k8s *K8SExec) WaitForRolloutComplete(ctx context.Context, workload WorkloadRef) error {
+	var resource string
+	var objType runtime.Object
+	var rolledOut func(obj runtime.Object) (bool, error)
+
+	switch workload.Kind {
+	case KindDeployment:
+		resource, objType, rolledOut = "deployments", &appsV1.Deployment{}, deploymentRolledOut
+	case KindStatefulSet:
+		resource, objType, rolledOut = "statefulsets", &appsV1.StatefulSet{}, statefulSetRolledOut
+	case KindDaemonSet:
+		resource, objType, rolledOut = "daemonsets", &appsV1.DaemonSet{}, daemonSetRolledOut
+	default:
+		return fmt.Errorf("waiting for rollout of %s: unsupported workload kind", workload)
+	}
+
+	precondition := func(store cache.Store) (bool, error) {
+		obj, exists, err := store.GetByKey(k8s.Namespace + "/" + workload.Name)
+		if err != nil || !exists {
+			return false, err
+		}
+		return rolledOut(obj.(runtime.Object))
+	}
+	condition := func(event watch.Event) (bool, error) {
+		switch event.Type {
+		case watch.Deleted:
+			return false, fmt.Errorf("%s was deleted", workload)
+		case watch.Added, watch.Modified:
+			return rolledOut(event.Object)
+		}
+		return false, nil
+	}
+
+	lw := cache.NewListWatchFromClient(k8s.Clientset.AppsV1().RESTClient(), resource, k8s.Namespace,
+		fields.OneTermEqualSelector("metadata.name", workload.Name))
+	if _, err := watchTools.UntilWithSync(ctx, lw, objType, precondition, condition); err != nil {
+		return fmt.Errorf("waiting for rollout of %s in %s: %w", workload, k8s.Namespace, err)
+	}
+	return nil
+}
+
+// isPodReady reports whether the pod's Ready condition is true.
+func isPodReady(pod *coreV1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == coreV1.PodReady {
+			return condition.Status == coreV1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// deploymentRolledOut reports whether all replicas of a Deployment are updated and available.
+func deploymentRolledOut(obj runtime.Object) (bool, error) {
+	deployment := obj.(*appsV1.Deployment)
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return false, nil
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsV1.DeploymentProgressing && condition.Reason == "ProgressDeadlineExceeded" {
+			return false, fmt.Errorf("deployment %q exceeded its progress deadline", deployment.Name)
+		}
+	}
+	if deployment.Spec.Replicas != nil && deployment.Status.UpdatedReplicas < *deployment.Spec.Replicas {
+		return false, nil
+	}
+	if deployment.Status.Replicas > deployment.Status.UpdatedReplicas {
+		return false, nil
+	}
+	return deployment.Status.AvailableReplicas >= deployment.Status.UpdatedReplicas, nil
+}
+
+// statefulSetRolledOut reports whether all replicas of a StatefulSet are updated to its latest revision and ready.
+func statefulSetRolledOut(obj runtime.Object) (bool, error) {
+	statefulSet := obj.(*appsV1.StatefulSet)
+	if statefulSet.Spec.UpdateStrategy.Type != appsV1.RollingUpdateStatefulSetStrategyType {
+		return false, fmt.Errorf("rollout status is only available for statefulsets with the %s strategy",
+			appsV1.RollingUpdateStatefulSetStrategyType)
+	}
+	if statefulSet.Generation > statefulSet.Status.ObservedGeneration {
+		return false, nil
+	}
+	replicas := int32(1)
+	if statefulSet.Spec.Replicas != nil {
+		replicas = *statefulSet.Spec.Replicas
+	}
+	if statefulSet.Status.ReadyReplicas < replicas {
+		return false, nil
+	}
+	if rollingUpdate := statefulSet.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil {
+		return statefulSet.Status.UpdatedReplicas >= replicas-*rollingUpdate.Partition, nil
+	}
+	return statefulSet.Status.UpdateRevision == statefulSet.Status.CurrentRevision, nil
+}
+
+// daemonSetRolledOut reports whether all pods of a DaemonSet are updated and available.
+func daemonSetRolledOut(obj runtime.Object) (bool, error) {
+	daemonSet := obj.(*appsV1.DaemonSet)
+	if daemonSet.Spec.UpdateStrategy.Type != appsV1.RollingUpdateDaemonSetStrategyType {
+		return false, fmt.Errorf("rollout status is only available for daemonsets with the %s strategy",
+			appsV1.RollingUpdateDaemonSetStrategyType)
+	}
+	if daemonSet.Generation > daemonSet.Status.ObservedGeneration {
+		return false, nil
+	}
+	if daemonSet.Status.UpdatedNumberScheduled < daemonSet.Status.DesiredNumberScheduled {
+		return false, nil
+	}
+	return daemonSet.Status.NumberAvailable >= daemonSet.Status.DesiredNumberScheduled, nil
+}
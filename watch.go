@@ -0,0 +1,139 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// WatchPollInterval is the interval at which WatchPath polls watched paths in containers lacking 'inotifywait'.
+const WatchPollInterval = time.Second
+
+// FileOp is the kind of change of a file system object reported by WatchPath.
+type FileOp string
+
+// Kinds of changes reported by WatchPath.
+const (
+	FileCreated  FileOp = "create"
+	FileModified FileOp = "modify"
+	FileDeleted  FileOp = "delete"
+	// FileAttributesChanged is reported for changes of permissions, ownership or timestamps. It is not reported
+	// by the polling fallback.
+	FileAttributesChanged FileOp = "attrib"
+)
+
+// FileEvent is a change of a file system object reported by WatchPath.
+type FileEvent struct {
+	Path string
+	Op   FileOp
+	// Time is when the event was received.
+	Time time.Time
+}
+
+// inotifyOps maps inotify event names to kinds of changes. Files replaced by renaming another file over them, as
+// configuration files are by many tools, are reported as created.
+var inotifyOps = map[string]FileOp{
+	"CREATE":      FileCreated,
+	"MOVED_TO":    FileCreated,
+	"CLOSE_WRITE": FileModified,
+	"MODIFY":      FileModified,
+	"ATTRIB":      FileAttributesChanged,
+	"DELETE":      FileDeleted,
+	"MOVED_FROM":  FileDeleted,
+	"DELETE_SELF": FileDeleted,
+	"MOVE_SELF":   FileDeleted,
+}
+
+// watchPathScript prints a line "<EVENTS> <path>" for every change of the file system object given as the first
+// parameter, or of the entries of the directory given. It uses 'inotifywait', watching the parent directory of
+// files, so files replaced or recreated keep being watched, and otherwise polls the object's modification time
+// and size at the interval in seconds given as the second parameter.
+const watchPathScript = `p=$1 interval=$2
+if command -v inotifywait >/dev/null 2>&1; then
+	events=close_write,attrib,create,delete,moved_to,moved_from
+	if [ -d "$p" ] && [ ! -L "$p" ]; then
+		exec inotifywait -m -q -e "$events",delete_self,move_self --format '%e %w/%f' -- "$p"
+	fi
+	exec inotifywait -m -q -e "$events" --format '%e %w/%f' -- "$(dirname -- "$p")"
+fi
+state() {
+	if [ ! -e "$p" ] && [ ! -L "$p" ]; then echo missing; return; fi
+	stat -c '%Y %s %i' -- "$p" 2>/dev/null && return
+	echo "$(date -r "$p" +%s 2>/dev/null) $(ls -ldn -- "$p" 2>/dev/null)"
+}
+prev=$(state)
+while sleep "$interval"; do
+	cur=$(state)
+	[ "$cur" = "$prev" ] && continue
+	if [ "$cur" = missing ]; then echo "DELETE $p"
+	elif [ "$prev" = missing ]; then echo "CREATE $p"
+	else echo "MODIFY $p"
+	fi
+	prev=$cur
+done`
+
+// WatchPath watches the file system object 'filePath' in a container, identified by the container's name and
+// the associated pod's name, and streams its changes over the returned channel until 'ctx' is cancelled, e.g.
+// to verify that a configuration reload actually rewrites a file. Changes of directories' entries are reported
+// with the entries' paths. 'inotifywait' is used when the container provides it; otherwise the object's
+// modification time and size are polled every WatchPollInterval, which reports only creations, modifications and
+// deletions of the object itself. The events channel is closed when watching ends; the error channel then
+// delivers a single error, which is nil if watching was stopped by cancelling 'ctx'.
+func (k8s *K8SExec) WatchPath(ctx context.Context, podName string, containerName string, filePath string) (<-chan FileEvent, <-chan error) {
+	events := make(chan FileEvent)
+	errs := make(chan error, 1)
+	filePath = path.Clean(filePath)
+	interval := max(int(WatchPollInterval/time.Second), 1)
+	cmd := []string{"sh", "-c", watchPathScript, "sh", filePath, fmt.Sprint(interval)}
+
+	go func() {
+		defer close(errs)
+		defer close(events)
+
+		var stderr bytes.Buffer
+		stdout := &lineWriter{deliver: func(line Line) {
+			event, ok := parseFileEvent(filePath, line)
+			if !ok {
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+			}
+		}}
+		retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, stdout, &stderr)
+		stdout.flush()
+		switch {
+		case ctx.Err() != nil:
+			errs <- nil
+		case err != nil && retCode < Success:
+			errs <- fmt.Errorf("watching %s in %s/%s: %w", filePath, podName, containerName, err)
+		default:
+			errs <- fmt.Errorf("watching %s in %s/%s: exit code %d: %s", filePath, podName, containerName, retCode, strings.TrimSpace(stderr.String()))
+		}
+	}()
+	return events, errs
+}
+
+// parseFileEvent parses a line printed by watchPathScript. Events of entries of a watched file's parent directory
+// other than the file are ignored.
+func parseFileEvent(filePath string, line Line) (FileEvent, bool) {
+	names, eventPath, ok := strings.Cut(line.Text, " ")
+	if !ok {
+		return FileEvent{}, false
+	}
+	eventPath = path.Clean(eventPath)
+	// events of a watched directory itself are reported with a trailing slash, cleaned away above
+	if eventPath != filePath && path.Dir(eventPath) != filePath {
+		return FileEvent{}, false
+	}
+	for _, name := range strings.Split(names, ",") {
+		if op, ok := inotifyOps[name]; ok {
+			return FileEvent{Path: eventPath, Op: op, Time: line.Time}, true
+		}
+	}
+	return FileEvent{}, false
+}
@@ -0,0 +1,89 @@
+package k8sexec
+
+import (
+	"context"
+	"io"
+	coreV1 "k8s.io/api/core/v1"
+	"slices"
+	"strings"
+)
+
+// WithWindowsMode makes the instance target Windows containers: shells are detected among PowerShell variants,
+// and utilities are looked up with where.exe and PowerShell's Get-Command instead of POSIX shell scripts.
+// Instances working with mixed Linux and Windows clusters should use a derived instance returned by Windows
+// for pods reported by IsWindowsPod.
+func WithWindowsMode() Option {
+	return func(k8s *K8SExec) {
+		k8s.windows = true
+	}
+}
+
+// Windows returns a derived K8SExec instance targeting Windows containers, see WithWindowsMode. Like
+// WithNamespace, the derived instance shares clients with the original one, so creating it is cheap.
+func (k8s *K8SExec) Windows() *K8SExec {
+	derived := *k8s
+	derived.windows = true
+	return &derived
+}
+
+// IsWindowsPod reports whether the pod runs Windows containers, according to its OS field or, for pods
+// created without it, to its node selector.
+func IsWindowsPod(pod *coreV1.Pod) bool {
+	if pod.Spec.OS != nil {
+		return pod.Spec.OS.Name == coreV1.Windows
+	}
+	return pod.Spec.NodeSelector[coreV1.LabelOSStable] == string(coreV1.Windows)
+}
+
+// windowsShellCandidates are the shells tried by DetectShell in Windows mode, in the order of preference.
+// cmd.exe is not a candidate, since it cannot run scripts provided on standard input.
+var windowsShellCandidates = [][]string{
+	{"powershell", "-NoProfile", "-NonInteractive"},
+	{"pwsh", "-NoProfile", "-NonInteractive"},
+}
+
+// windowsExitCodeDescriptions describes exit codes specific to Windows: NTSTATUS codes of crashed processes,
+// reported as negative 32-bit integers, and codes of cmd.exe.
+var windowsExitCodeDescriptions = map[ExitCode]string{
+	-1073741819: "Access violation (STATUS_ACCESS_VIOLATION)",
+	-1073741795: "Illegal instruction (STATUS_ILLEGAL_INSTRUCTION)",
+	-1073741801: "Out of memory (STATUS_NO_MEMORY)",
+	-1073741676: "Integer division by zero (STATUS_INTEGER_DIVIDE_BY_ZERO)",
+	-1073741571: "Stack overflow (STATUS_STACK_OVERFLOW)",
+	-1073741515: "Required DLL not found (STATUS_DLL_NOT_FOUND)",
+	-1073741510: "Terminated by Control-C (STATUS_CONTROL_C_EXIT)",
+	-1073741502: "DLL initialization failed (STATUS_DLL_INIT_FAILED)",
+	-1073740791: "Stack buffer overrun (STATUS_STACK_BUFFER_OVERRUN)",
+	-1073740940: "Heap corruption (STATUS_HEAP_CORRUPTION)",
+	9009:        "Command not recognized by cmd.exe",
+}
+
+// probeUtilsPowerShell is the PowerShell equivalent of probeUtilsScript. It is invoked as a script block with
+// the utilities as arguments and prints one "<util>\t<0|1>" line per utility.
+const probeUtilsPowerShell = "& { foreach ($u in $args) { if (Get-Command $u -ErrorAction SilentlyContinue) { \"$u`t1\" } else { \"$u`t0\" } } }"
+
+// powerShellQuote quotes a string as a PowerShell single-quoted literal.
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// windowsUtilsCommand returns the command probing utilities in a Windows container with the given shell.
+func windowsUtilsCommand(shell []string, utils []string) []string {
+	return powerShellCommand(shell, probeUtilsPowerShell, utils...)
+}
+
+// powerShellCommand returns the command running the PowerShell script block 'script' with the given shell,
+// passing it 'args' quoted as literals.
+func powerShellCommand(shell []string, script string, args ...string) []string {
+	for _, arg := range args {
+		script += " " + powerShellQuote(arg)
+	}
+	return slices.Concat(shell, []string{"-Command", script})
+}
+
+// checkUtilInWindowsContainer verifies the existence of a utility in a Windows container with where.exe.
+func (k8s *K8SExec) checkUtilInWindowsContainer(ctx context.Context, podName, containerName string, util string) bool {
+	// the API server rejects executions requesting no streams, so the output is requested and dropped
+	retCode, _ := k8s.exec(ctx, podName, containerName, []string{"where.exe", "/q", util}, nil, io.Discard, nil, false)
+	return retCode == Success
+}
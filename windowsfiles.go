@@ -0,0 +1,270 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// podOSCache caches whether pods run on Windows nodes, keyed by the namespace and the name of the pod, so file
+// operations do not query the API server for the pod and its node every time.
+type podOSCache struct {
+	windows sync.Map
+}
+
+// targetsWindows reports whether file operations on the pod must use their Windows variants: always in Windows
+// mode, otherwise if the pod runs Windows containers according to IsWindowsPod or the OS label of its node.
+// Failures to look up the pod or its node, e.g. for lack of permissions to get nodes, are logged and the pod is
+// assumed to run Linux, so the operation itself reports the problem with the pod, if any.
+func (k8s *K8SExec) targetsWindows(ctx context.Context, podName string) bool {
+	if k8s.windows {
+		return true
+	}
+	key := k8s.Namespace + "/" + podName
+	if k8s.podOS != nil {
+		if windows, ok := k8s.podOS.windows.Load(key); ok {
+			return windows.(bool)
+		}
+	}
+
+	pod, err := k8s.GetPodWithContext(ctx, podName, metaV1.GetOptions{})
+	if err != nil {
+		k8s.logger().Debug("cannot determine pod OS", "pod", podName, "error", err)
+		return false
+	}
+	windows := IsWindowsPod(pod)
+	if !windows {
+		// unscheduled pods may still land on a Windows node, so they are not cached
+		if pod.Spec.NodeName == "" {
+			return false
+		}
+		node, err := k8s.Clientset.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metaV1.GetOptions{})
+		if err != nil {
+			k8s.logger().Debug("cannot determine node OS", "pod", podName, "node", pod.Spec.NodeName, "error", err)
+			return false
+		}
+		windows = node.Labels[coreV1.LabelOSStable] == string(coreV1.Windows)
+	}
+	if k8s.podOS != nil {
+		k8s.podOS.windows.Store(key, windows)
+	}
+	return windows
+}
+
+// windowsFilePrelude is the beginning of the PowerShell script blocks of Windows file operations, which take
+// the path of the file as their first argument. It makes errors terminate the script and defines the Emit
+// function writing a line to standard output in UTF-8, regardless of the code page of the container.
+const windowsFilePrelude = `$ErrorActionPreference = 'Stop'
+$p = $args[0]
+$out = [Console]::OpenStandardOutput()
+function Emit($s) { $b = [Text.Encoding]::UTF8.GetBytes($s + [char]10); $out.Write($b, 0, $b.Length) }
+`
+
+// windowsDescribeFunction defines the Describe function, which returns the tab-separated properties of the item
+// returned by Get-Item or Get-ChildItem: its type, the read-only attribute, size, modification time in seconds
+// since the epoch, owner, group, full path and the target of links. Windows forbids tabs in file names, so
+// the fields need no escaping.
+const windowsDescribeFunction = `function Describe($i) {
+	$t = 'regular'
+	if ($i.LinkType -in 'SymbolicLink', 'Junction') { $t = 'symlink' } elseif ($i.PSIsContainer) { $t = 'directory' }
+	$s = 0
+	if (-not $i.PSIsContainer) { $s = $i.Length }
+	$m = [long][Math]::Floor(($i.LastWriteTimeUtc - [datetime]'1970-01-01').TotalSeconds)
+	$a = $null
+	try { $a = Get-Acl -LiteralPath $i.FullName } catch {}
+	@($t, [int][bool]($i.Attributes -band 1), $s, $m, $a.Owner, $a.Group, $i.FullName, [string]$i.Target) -join [char]9
+}
+`
+
+// readFilePowerShell writes the content of the file to standard output, read with Get-Content as bytes.
+const readFilePowerShell = `& {
+` + windowsFilePrelude + `if (-not (Test-Path -LiteralPath $p)) { exit 66 }
+if (Test-Path -LiteralPath $p -PathType Container) { [Console]::Error.WriteLine('is a directory'); exit 1 }
+try {
+	if ($PSVersionTable.PSVersion.Major -ge 6) { $b = Get-Content -LiteralPath $p -AsByteStream -Raw }
+	else { $b = Get-Content -LiteralPath $p -Encoding Byte -Raw }
+} catch [UnauthorizedAccessException] { exit 77 }
+if ($b) { $out.Write($b, 0, $b.Length) }
+}`
+
+// statFilePowerShell prints the properties of the file, see windowsDescribeFunction.
+const statFilePowerShell = `& {
+` + windowsFilePrelude + windowsDescribeFunction + `if (-not (Test-Path -LiteralPath $p)) { exit 66 }
+try { $i = Get-Item -LiteralPath $p -Force } catch [UnauthorizedAccessException] { exit 77 }
+Emit (Describe $i)
+}`
+
+// listDirPowerShell prints the name relative to the directory followed by the properties of every entry of
+// the directory, recursively if the second argument is 1, see windowsDescribeFunction. Subdirectories which
+// cannot be read are skipped.
+const listDirPowerShell = `& {
+` + windowsFilePrelude + windowsDescribeFunction + `if (-not (Test-Path -LiteralPath $p)) { exit 66 }
+if (-not (Test-Path -LiteralPath $p -PathType Container)) { exit 65 }
+try { $items = @(Get-ChildItem -LiteralPath $p -Force) } catch [UnauthorizedAccessException] { exit 77 }
+if ($args[1] -eq '1') { $items = @(Get-ChildItem -LiteralPath $p -Force -Recurse -ErrorAction SilentlyContinue) }
+$root = (Get-Item -LiteralPath $p -Force).FullName.TrimEnd('\')
+foreach ($i in $items) { Emit ($i.FullName.Substring($root.Length + 1) + [char]9 + (Describe $i)) }
+}`
+
+// writeFilePowerShell stores standard input in the file with Set-Content, sets its read-only attribute if
+// the second argument is 1 and prints the SHA-256 checksum of the written file.
+const writeFilePowerShell = `& {
+` + windowsFilePrelude + `$buffer = New-Object IO.MemoryStream
+[Console]::OpenStandardInput().CopyTo($buffer)
+$b = $buffer.ToArray()
+try {
+	if ($b.Length -eq 0) { $null = New-Item -ItemType File -Path $p -Force }
+	elseif ($PSVersionTable.PSVersion.Major -ge 6) { Set-Content -LiteralPath $p -Value $b -AsByteStream -Force }
+	else { Set-Content -LiteralPath $p -Value $b -Encoding Byte -Force }
+	(Get-Item -LiteralPath $p -Force).IsReadOnly = ($args[1] -eq '1')
+} catch [UnauthorizedAccessException] { exit 77 }
+Emit ('sha256 ' + (Get-FileHash -LiteralPath $p -Algorithm SHA256).Hash.ToLower())
+}`
+
+// windowsFileFields is the number of fields printed by the Describe function of windowsDescribeFunction.
+const windowsFileFields = 8
+
+// windowsFileCommand returns the command running the PowerShell script of a Windows file operation with
+// the shell detected in the container.
+func (k8s *K8SExec) windowsFileCommand(ctx context.Context, podName string, containerName string, script string, args ...string) ([]string, error) {
+	shell, err := k8s.Windows().DetectShell(ctx, podName, containerName)
+	if err != nil {
+		return nil, err
+	}
+	return powerShellCommand(shell, script, args...), nil
+}
+
+// readFileWindows is the Windows variant of ReadFile, reading the file with Get-Content.
+func (k8s *K8SExec) readFileWindows(ctx context.Context, podName string, containerName string, path string) ([]byte, *ExecutionStatus, error) {
+	cmd, err := k8s.windowsFileCommand(ctx, podName, containerName, readFilePowerShell, path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %s/%s:%s: %w", podName, containerName, path, err)
+	}
+	status := k8s.ExecWithOptions(ctx, podName, containerName, cmd, WithRawOutput())
+	if err := fileError("reading", podName, containerName, path, status.RetCode, status.Err, bytes.NewBuffer(status.StderrRaw)); err != nil {
+		return nil, status, err
+	}
+	return status.StdoutRaw, status, nil
+}
+
+// statFileWindows is the Windows variant of StatFile, inspecting the file with Get-Item.
+func (k8s *K8SExec) statFileWindows(ctx context.Context, podName string, containerName string, path string) (*FileInfo, error) {
+	cmd, err := k8s.windowsFileCommand(ctx, podName, containerName, statFilePowerShell, path)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting %s/%s:%s: %w", podName, containerName, path, err)
+	}
+	var stdout, stderr bytes.Buffer
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if err := fileError("inspecting", podName, containerName, path, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+
+	fields := strings.Split(strings.TrimRight(stdout.String(), "\r\n"), "\t")
+	info, _, err := parseWindowsFileInfo(fields)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting %s/%s:%s: %w", podName, containerName, path, err)
+	}
+	info.Path = path
+	return info, nil
+}
+
+// listDirWindows is the Windows variant of ListDir, listing the directory with Get-ChildItem.
+func (k8s *K8SExec) listDirWindows(ctx context.Context, podName string, containerName string, dirPath string, recursive bool) ([]DirEntry, error) {
+	flag := "0"
+	if recursive {
+		flag = "1"
+	}
+	cmd, err := k8s.windowsFileCommand(ctx, podName, containerName, listDirPowerShell, dirPath, flag)
+	if err != nil {
+		return nil, fmt.Errorf("listing %s/%s:%s: %w", podName, containerName, dirPath, err)
+	}
+	var stdout, stderr bytes.Buffer
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, nil, &stdout, &stderr)
+	if err := fileError("listing", podName, containerName, dirPath, retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+
+	var entries []DirEntry
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			continue
+		}
+		name, rest, _ := strings.Cut(line, "\t")
+		info, target, err := parseWindowsFileInfo(strings.Split(rest, "\t"))
+		if err != nil {
+			return nil, fmt.Errorf("listing %s/%s:%s: %w", podName, containerName, dirPath, err)
+		}
+		entries = append(entries, DirEntry{FileInfo: *info, Name: name, LinkTarget: target})
+	}
+	return entries, nil
+}
+
+// writeFileWindows is the Windows variant of WriteFile, writing the file with Set-Content. Windows has no
+// permission bits, so only the lack of the owner's write permission in 'mode' is applied, as the read-only
+// attribute.
+func (k8s *K8SExec) writeFileWindows(ctx context.Context, podName string, containerName string, path string, content io.Reader, mode os.FileMode) error {
+	readOnly := "0"
+	if mode.Perm()&0o200 == 0 {
+		readOnly = "1"
+	}
+	cmd, err := k8s.windowsFileCommand(ctx, podName, containerName, writeFilePowerShell, path, readOnly)
+	if err != nil {
+		return fmt.Errorf("writing %s/%s:%s: %w", podName, containerName, path, err)
+	}
+
+	hash := sha256.New()
+	var stdout, stderr bytes.Buffer
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, io.TeeReader(content, hash), &stdout, &stderr)
+	if err := fileError("writing", podName, containerName, path, retCode, err, &stderr); err != nil {
+		return err
+	}
+
+	_, written, _ := strings.Cut(strings.TrimSpace(stdout.String()), " ")
+	if expected := hex.EncodeToString(hash.Sum(nil)); written != expected {
+		return fmt.Errorf("writing %s/%s:%s: %w: sha256 %s written, %s sent", podName, containerName, path, ErrChecksumMismatch, written, expected)
+	}
+	return nil
+}
+
+// parseWindowsFileInfo parses the fields printed by the Describe function of windowsDescribeFunction and
+// returns the properties of the item along with the target of links. Permissions are derived from the type and
+// the read-only attribute, since Windows has no permission bits.
+func parseWindowsFileInfo(fields []string) (*FileInfo, string, error) {
+	if len(fields) != windowsFileFields {
+		return nil, "", fmt.Errorf("unexpected number of fields %d", len(fields))
+	}
+	size, _ := strconv.ParseInt(fields[2], 10, 64)
+	info := &FileInfo{
+		Path:    fields[6],
+		Type:    FileType(fields[0]),
+		Owner:   fields[4],
+		Group:   fields[5],
+		Size:    size,
+		ModTime: unixTime(fields[3]),
+	}
+	switch info.Type {
+	case FileTypeDirectory:
+		info.Mode = fs.ModeDir | 0o755
+	case FileTypeSymlink:
+		info.Mode = fs.ModeSymlink | 0o777
+	case FileTypeRegular:
+		info.Mode = 0o644
+		if fields[1] == "1" {
+			info.Mode = 0o444
+		}
+	default:
+		return nil, "", fmt.Errorf("unexpected file type %q", fields[0])
+	}
+	return info, fields[7], nil
+}
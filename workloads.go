@@ -0,0 +1,20 @@
+package k8sexec
+
+import (
+	"github.com/hhruszka/k8sexec/discovery"
+)
+
+// Kinds of workloads owning pods, as reported in WorkloadRef.Kind.
+const (
+	KindDeployment  = discovery.KindDeployment
+	KindStatefulSet = discovery.KindStatefulSet
+	KindDaemonSet   = discovery.KindDaemonSet
+	KindReplicaSet  = discovery.KindReplicaSet
+	KindJob         = discovery.KindJob
+	KindCronJob     = discovery.KindCronJob
+	// KindPod is used for standalone pods, which are not managed by any workload.
+	KindPod = discovery.KindPod
+)
+
+// WorkloadRef identifies a workload by its kind (e.g. KindDeployment) and name.
+type WorkloadRef = discovery.WorkloadRef
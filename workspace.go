@@ -0,0 +1,142 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ErrWorkspaceClosed is returned by operations of a Workspace which has been cleaned up.
+var ErrWorkspaceClosed = errors.New("workspace closed")
+
+// mktempScript creates a private temporary directory with 'mktemp', or the directory given as the first
+// parameter in containers lacking it, and prints its path.
+const mktempScript = `d=$(mktemp -d /tmp/k8sexec-XXXXXXXX 2>/dev/null) || { d=$1; mkdir -m 700 "$d" || exit; }
+echo "$d"`
+
+// Workspace is a temporary directory in a container, created with Mktemp, which tracks the files created during
+// a session, so they can all be removed at its end. A Workspace is safe for concurrent use.
+type Workspace struct {
+	Pod       string
+	Container string
+	// Dir is the path of the temporary directory in the container.
+	Dir string
+
+	k8s      *K8SExec
+	mu       sync.Mutex
+	uploaded []string
+	tracked  []string
+	closed   bool
+}
+
+// Mktemp creates a private temporary directory in a container, identified by the container's name and
+// the associated pod's name, and returns it as a Workspace, so tooling can upload files to the container and
+// reliably remove them afterwards instead of littering it. The workspace must be removed with Cleanup or
+// Close. The directory is created with 'mktemp -d' in /tmp, or with 'mkdir' in containers lacking mktemp.
+// The call is governed by the provided context.
+func (k8s *K8SExec) Mktemp(ctx context.Context, podName string, containerName string) (*Workspace, error) {
+	var stdout, stderr bytes.Buffer
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, []string{"sh", "-c", mktempScript, "sh", tempPath("")}, nil, &stdout, &stderr)
+	if err := copyError("creating workspace in", podName, containerName, "/tmp", retCode, err, &stderr); err != nil {
+		return nil, err
+	}
+	dir := strings.TrimSpace(stdout.String())
+	if !path.IsAbs(dir) {
+		return nil, fmt.Errorf("creating workspace in %s/%s: unexpected output %q", podName, containerName, stdout.String())
+	}
+	k8s.addArtifact(podName, containerName, dir)
+	k8s.logger().Debug("workspace created", "pod", podName, "container", containerName, "dir", dir)
+	return &Workspace{Pod: podName, Container: containerName, Dir: dir, k8s: k8s}, nil
+}
+
+// Path returns the path of a file named 'name' in the workspace.
+func (workspace *Workspace) Path(name string) string {
+	return path.Join(workspace.Dir, path.Clean("/"+name))
+}
+
+// Upload writes 'content' to the file named 'name' in the workspace, with WriteFile, and returns its path.
+// The write is governed by the provided context.
+func (workspace *Workspace) Upload(ctx context.Context, name string, content io.Reader, mode os.FileMode) (string, error) {
+	workspace.mu.Lock()
+	closed := workspace.closed
+	workspace.mu.Unlock()
+	if closed {
+		return "", fmt.Errorf("uploading to %s/%s:%s: %w", workspace.Pod, workspace.Container, workspace.Dir, ErrWorkspaceClosed)
+	}
+
+	target := workspace.Path(name)
+	if dir := path.Dir(target); dir != workspace.Dir {
+		var stderr bytes.Buffer
+		retCode, err := workspace.k8s.ExecStream(ctx, workspace.Pod, workspace.Container, []string{"mkdir", "-p", dir}, nil, nil, &stderr)
+		if err := copyError("uploading to", workspace.Pod, workspace.Container, dir, retCode, err, &stderr); err != nil {
+			return "", err
+		}
+	}
+	if err := workspace.k8s.WriteFile(ctx, workspace.Pod, workspace.Container, target, content, mode); err != nil {
+		return "", err
+	}
+
+	workspace.mu.Lock()
+	defer workspace.mu.Unlock()
+	workspace.uploaded = append(workspace.uploaded, target)
+	return target, nil
+}
+
+// Files returns the paths of the files uploaded to the workspace, in the order of their uploads.
+func (workspace *Workspace) Files() []string {
+	workspace.mu.Lock()
+	defer workspace.mu.Unlock()
+	return slices.Clone(workspace.uploaded)
+}
+
+// Track registers a path outside of the workspace's directory, e.g. a file created by a command, to be removed
+// along with the workspace.
+func (workspace *Workspace) Track(path string) {
+	workspace.k8s.addArtifact(workspace.Pod, workspace.Container, path)
+	workspace.mu.Lock()
+	defer workspace.mu.Unlock()
+	workspace.tracked = append(workspace.tracked, path)
+}
+
+// Cleanup removes the workspace's directory with its whole content and the tracked paths from the container.
+// The workspace cannot be used afterwards; cleaning it up again does nothing. The removal is governed by
+// the provided context.
+func (workspace *Workspace) Cleanup(ctx context.Context) error {
+	workspace.mu.Lock()
+	if workspace.closed {
+		workspace.mu.Unlock()
+		return nil
+	}
+	workspace.closed = true
+	paths := append([]string{workspace.Dir}, workspace.tracked...)
+	workspace.mu.Unlock()
+
+	var stderr bytes.Buffer
+	cmd := append([]string{"rm", "-rf", "--"}, paths...)
+	retCode, err := workspace.k8s.ExecStream(ctx, workspace.Pod, workspace.Container, cmd, nil, nil, &stderr)
+	if err := copyError("removing workspace from", workspace.Pod, workspace.Container, workspace.Dir, retCode, err, &stderr); err != nil {
+		// allow retrying a failed removal
+		workspace.mu.Lock()
+		workspace.closed = false
+		workspace.mu.Unlock()
+		return err
+	}
+	workspace.k8s.forgetArtifacts(workspace.Pod, workspace.Container, paths...)
+	workspace.k8s.logger().Debug("workspace removed", "pod", workspace.Pod, "container", workspace.Container, "dir", workspace.Dir)
+	return nil
+}
+
+// Close removes the workspace like Cleanup, with the instance's timeout of file operations, so it can be
+// deferred right after Mktemp.
+func (workspace *Workspace) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), workspace.k8s.fileOpTimeout())
+	defer cancel()
+	return workspace.Cleanup(ctx)
+}
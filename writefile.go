@@ -0,0 +1,63 @@
+package k8sexec
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// writeFileScript stores standard input in the file given as the first parameter with 'cat', or 'dd' in
+// containers lacking it, sets the octal permissions given as the second parameter and prints the SHA-256
+// checksum of the written file, or its size if 'sha256sum' is not available.
+const writeFileScript = `p=$1
+if command -v cat >/dev/null 2>&1; then cat > "$p"; else dd of="$p" 2>/dev/null; fi || exit
+chmod "$2" "$p" || exit
+if command -v sha256sum >/dev/null 2>&1; then
+  s=$(sha256sum < "$p") && printf 'sha256 %s\n' "${s%% *}"
+else
+  n=$(wc -c < "$p") && printf 'size %s\n' $n
+fi`
+
+// WriteFile writes 'content' to the file 'path' in a container, identified by the container's name and
+// the associated pod's name, and sets its permissions to 'mode'. The content is streamed to the container's
+// 'cat' (or 'dd') without being buffered. The written file is verified afterwards by comparing its SHA-256
+// checksum, or only its size if the container lacks 'sha256sum', with the content that was sent; a mismatch is
+// reported with an error wrapping ErrChecksumMismatch. The parent directory of 'path' must exist. The file is
+// recorded as an artifact to be removed by ShredArtifacts.
+// In pods running on Windows nodes the file is written with PowerShell's Set-Content, which buffers the content
+// in the container's memory, and 'mode' only decides whether the file is read-only. The write is governed by
+// the provided context.
+func (k8s *K8SExec) WriteFile(ctx context.Context, podName string, containerName string, path string, content io.Reader, mode os.FileMode) error {
+	if k8s.targetsWindows(ctx, podName) {
+		return k8s.writeFileWindows(ctx, podName, containerName, path, content, mode)
+	}
+	// recorded up front, so partially written files are removed by ShredArtifacts as well
+	k8s.addArtifact(podName, containerName, path)
+
+	hash := sha256.New()
+	var size streamCounter
+	input := io.TeeReader(content, io.MultiWriter(hash, &countingWriter{writer: io.Discard, counter: &size}))
+
+	var stdout, stderr bytes.Buffer
+	cmd := []string{"sh", "-c", writeFileScript, "sh", path, strconv.FormatUint(uint64(mode.Perm()), 8)}
+	retCode, err := k8s.ExecStream(ctx, podName, containerName, cmd, input, &stdout, &stderr)
+	if err := fileError("writing", podName, containerName, path, retCode, err, &stderr); err != nil {
+		return err
+	}
+
+	kind, value, _ := strings.Cut(strings.TrimSpace(stdout.String()), " ")
+	expected := hex.EncodeToString(hash.Sum(nil))
+	if kind == "size" {
+		expected = strconv.FormatInt(size.bytes(), 10)
+	}
+	if value != expected {
+		return fmt.Errorf("writing %s/%s:%s: %w: %s %s written, %s sent", podName, containerName, path, ErrChecksumMismatch, kind, value, expected)
+	}
+	return nil
+}